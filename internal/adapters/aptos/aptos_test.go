@@ -0,0 +1,131 @@
+package aptos
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/r1der/epos/internal/domain/entity/network"
+)
+
+// the key of the RFC 8032 test 1 and its Aptos account address, sha3-256(public key | 0x00)
+const (
+	testKey     = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+	testPubKey  = "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a"
+	testAddress = "0x63c5215e87770d17b9f4cd47c777e322f4eb152cfd2054c1080fd9d57c48913b"
+)
+
+// rawTransactionPrefix is sha3-256("APTOS::RawTransaction")
+const rawTransactionPrefix = "b5e97db07fa0bd0e5598aa3643a9bc6f6693bddc1a9fec9e674a461eaa00b193"
+
+func decode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestAddress(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(decode(t, testKey))
+	pub := key.Public().(ed25519.PublicKey)
+	if hex.EncodeToString(pub) != testPubKey {
+		t.Fatalf("public key %x", pub)
+	}
+	if got := Address(pub); got != testAddress {
+		t.Fatalf("address %s, want %s", got, testAddress)
+	}
+}
+
+func TestSignTransaction(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(decode(t, testKey))
+	s := &TxSigner{address: testAddress, key: key}
+	rawTxn := []byte{0x01, 0x02, 0x03, 0xff}
+
+	auth := s.SignTransaction(rawTxn)
+	if hex.EncodeToString(auth.PublicKey) != testPubKey {
+		t.Fatalf("authenticator of the public key %x", auth.PublicKey)
+	}
+	// the signature is over the prefix followed by the transaction and over nothing else
+	msg := append(decode(t, rawTransactionPrefix), rawTxn...)
+	if !ed25519.Verify(auth.PublicKey, msg, auth.Signature) {
+		t.Fatal("the signature does not verify over the prefixed transaction")
+	}
+	if ed25519.Verify(auth.PublicKey, rawTxn, auth.Signature) {
+		t.Fatal("the signature verifies over the transaction without the prefix")
+	}
+	if other := s.SignTransaction([]byte{0x01, 0x02, 0x03}); ed25519.Verify(other.PublicKey, msg, other.Signature) {
+		t.Fatal("the signature of another transaction verifies")
+	}
+}
+
+func TestKeyStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	ks, err := Open(dir, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = ks.Import(ctx, network.Ethereum, testKey); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Fatalf("expected the unsupported network error, got %v", err)
+	}
+	if _, _, err = ks.Import(ctx, network.Aptos, "0x1234"); err == nil {
+		t.Fatal("imported a short key")
+	}
+
+	// the key of the Aptos CLI format is imported as the plain hex one
+	addr, ref, err := ks.Import(ctx, network.Aptos, "ed25519-priv-0x"+testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != testAddress || ref != RefPrefix+testAddress {
+		t.Fatalf("imported %s as %s", addr, ref)
+	}
+	again, againRef, err := ks.Import(ctx, network.Aptos, testKey)
+	if err != nil || again != addr || againRef != ref {
+		t.Fatalf("imported again %s as %s, %v", again, againRef, err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("the key is stored in %d files", len(files))
+	}
+
+	// the reopened store decrypts the key, the reference is case-insensitive
+	ks, err = Open(dir, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ks.Address(ctx, network.Aptos, RefPrefix+strings.ToUpper(testAddress[2:])); err == nil || got != "" {
+		t.Fatalf("resolved the reference without the 0x prefix to %s", got)
+	}
+	if got, err := ks.Address(ctx, network.Aptos, RefPrefix+"0x"+strings.ToUpper(testAddress[2:])); err != nil || got != addr {
+		t.Fatalf("Address() = %s, %v, want %s", got, err, addr)
+	}
+	signer, err := ks.TxSigner(ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := signer.SignTransaction([]byte{0x2a})
+	if signer.Address() != addr || hex.EncodeToString(auth.PublicKey) != testPubKey {
+		t.Fatalf("signer of %s with the public key %x", signer.Address(), auth.PublicKey)
+	}
+
+	if _, err = ks.TxSigner(ctx, "keystore:"+addr); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected the unknown key error, got %v", err)
+	}
+	if _, err = ks.Signer(ctx, ref); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Fatalf("expected the EVM signer error, got %v", err)
+	}
+	if _, err = Open(dir, "wrong"); err == nil {
+		t.Fatal("opened by a wrong passphrase")
+	}
+}
@@ -0,0 +1,187 @@
+// Package aptos keeps the Aptos ed25519 wallet keys encrypted at rest and signs the Aptos transactions,
+// the keys are encrypted with scrypt by the passphrase supplied at the startup like the EVM key store
+package aptos
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/r1der/epos/internal/domain/entity/network"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+// RefPrefix prefixes the key references of the keys kept by the key store, e.g. "aptos:0x8f3e...".
+const RefPrefix = "aptos:"
+
+// ed25519Scheme is the authentication key scheme of the single ed25519 key accounts
+const ed25519Scheme = 0x00
+
+var (
+	ErrUnknownKey         = errors.New("unknown key")
+	ErrUnsupportedNetwork = errors.New("unsupported network")
+)
+
+// keyFile is the stored key, the private key seed is encrypted in the Web3 Secret Storage format
+type keyFile struct {
+	Address string              `json:"address"`
+	Crypto  keystore.CryptoJSON `json:"crypto"`
+}
+
+// KeyStore keeps the keys in the directory, one encrypted key file per key
+type KeyStore struct {
+	dir        string
+	passphrase string
+
+	mu   sync.RWMutex
+	keys map[string]ed25519.PrivateKey
+}
+
+// Open opens the key store directory creating it when missing and decrypts all its keys with the passphrase,
+// it fails when any of the keys cannot be decrypted by the passphrase
+func Open(dir, passphrase string) (*KeyStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create key store: %w", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeyStore{dir: dir, passphrase: passphrase, keys: make(map[string]ed25519.PrivateKey, len(files))}
+	for _, f := range files {
+		raw, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("read key %s: %w", f, err)
+		}
+		var kf keyFile
+		if err = json.Unmarshal(raw, &kf); err != nil {
+			return nil, fmt.Errorf("decode key %s: %w", f, err)
+		}
+		seed, err := keystore.DecryptDataV3(kf.Crypto, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt key %s: %w", kf.Address, err)
+		}
+		if len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("decrypt key %s: invalid key size %d", kf.Address, len(seed))
+		}
+		key := ed25519.NewKeyFromSeed(seed)
+		if addr := Address(key.Public().(ed25519.PublicKey)); addr != kf.Address {
+			return nil, fmt.Errorf("key %s belongs to %s", kf.Address, addr)
+		}
+		ks.keys[kf.Address] = key
+	}
+	return ks, nil
+}
+
+// Address returns the account address of the single ed25519 key account, the address is the authentication key
+// sha3-256(public key | 0x00) of the account created for the key
+func Address(pub ed25519.PublicKey) string {
+	authKey := sha3.Sum256(append(append([]byte{}, pub...), ed25519Scheme))
+	return "0x" + hex.EncodeToString(authKey[:])
+}
+
+// Import encrypts the hex encoded 32 bytes ed25519 private key into the key store, the key may have
+// the "ed25519-priv-" prefix of the Aptos CLI, importing a key which is already stored returns the reference to it
+func (k *KeyStore) Import(_ context.Context, net, privateKey string) (string, string, error) {
+	if net != network.Aptos {
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedNetwork, net)
+	}
+
+	raw := strings.TrimPrefix(strings.TrimPrefix(privateKey, "ed25519-priv-"), "0x")
+	seed, err := hex.DecodeString(raw)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return "", "", errors.New("parse private key: 32 bytes hex ed25519 key expected")
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	addr := Address(key.Public().(ed25519.PublicKey))
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.keys[addr]; ok {
+		return addr, RefPrefix + addr, nil
+	}
+
+	enc, err := keystore.EncryptDataV3(seed, []byte(k.passphrase), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return "", "", fmt.Errorf("encrypt key: %w", err)
+	}
+	data, err := json.Marshal(keyFile{Address: addr, Crypto: enc})
+	if err != nil {
+		return "", "", err
+	}
+	if err = writeFile(filepath.Join(k.dir, addr+".json"), data); err != nil {
+		return "", "", fmt.Errorf("store key: %w", err)
+	}
+	k.keys[addr] = key
+
+	return addr, RefPrefix + addr, nil
+}
+
+// writeFile writes the file through a temporary file so a crash never leaves a partial key file
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".key-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Address returns the address of the key referenced by the key reference
+func (k *KeyStore) Address(_ context.Context, net, keyRef string) (string, error) {
+	if net != network.Aptos {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedNetwork, net)
+	}
+	addr, _, err := k.key(keyRef)
+	return addr, err
+}
+
+// Signer fails, the Aptos keys sign no EVM transactions, see TxSigner
+func (k *KeyStore) Signer(context.Context, string) (uniswap.Signer, error) {
+	return nil, fmt.Errorf("%w: the Aptos keys sign by TxSigner", ErrUnsupportedNetwork)
+}
+
+// TxSigner returns the Aptos transaction signer of the key referenced by the key reference
+func (k *KeyStore) TxSigner(_ context.Context, keyRef string) (*TxSigner, error) {
+	addr, key, err := k.key(keyRef)
+	if err != nil {
+		return nil, err
+	}
+	return &TxSigner{address: addr, key: key}, nil
+}
+
+func (k *KeyStore) key(keyRef string) (string, ed25519.PrivateKey, error) {
+	addr, ok := strings.CutPrefix(keyRef, RefPrefix)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: invalid key reference %q", ErrUnknownKey, keyRef)
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[strings.ToLower(addr)]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnknownKey, addr)
+	}
+	return strings.ToLower(addr), key, nil
+}
@@ -0,0 +1,35 @@
+package aptos
+
+import (
+	"crypto/ed25519"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// rawTransactionSalt is the domain separator prefixed to the signed raw transactions
+const rawTransactionSalt = "APTOS::RawTransaction"
+
+// Authenticator is the single ed25519 key transaction authenticator
+type Authenticator struct {
+	PublicKey ed25519.PublicKey
+	Signature []byte
+}
+
+// TxSigner signs the Aptos transactions by the ed25519 key, the key never leaves the signer
+type TxSigner struct {
+	address string
+	key     ed25519.PrivateKey
+}
+
+func (s *TxSigner) Address() string { return s.address }
+
+// SignTransaction signs the BCS serialized raw transaction, the signing message is
+// sha3-256("APTOS::RawTransaction") followed by the serialized transaction
+func (s *TxSigner) SignTransaction(rawTxn []byte) Authenticator {
+	prefix := sha3.Sum256([]byte(rawTransactionSalt))
+	msg := append(prefix[:], rawTxn...)
+	return Authenticator{
+		PublicKey: s.key.Public().(ed25519.PublicKey),
+		Signature: ed25519.Sign(s.key, msg),
+	}
+}
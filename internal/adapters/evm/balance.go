@@ -0,0 +1,49 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+	"github.com/r1der/epos/internal/domain/ports"
+	"github.com/r1der/epos/internal/domain/values"
+	"github.com/r1der/epos/pkg/erc20"
+)
+
+type balance struct {
+	networks map[string]erc20.Manager
+}
+
+// NewBalance creates a wallet balance adapter, networks maps a network to its ERC-20 manager
+func NewBalance(networks map[string]erc20.Manager) ports.Balance {
+	return &balance{networks: networks}
+}
+
+// Get gets the native coin balance for the wallet native token and the ERC-20 balance for any other token
+func (b *balance) Get(ctx context.Context, wa *wallet.Wallet, t *token.Token) (values.Amount, error) {
+	m, ok := b.networks[t.Network()]
+	if !ok {
+		return values.Amount{}, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, t.Network())
+	}
+
+	owner := common.HexToAddress(wa.Address())
+
+	var (
+		value *big.Int
+		err   error
+	)
+	if wa.NativeToken() != nil && wa.NativeToken().Eq(t) {
+		value, err = m.GetBalance(ctx, owner)
+	} else {
+		value, err = m.GetTokenBalance(ctx, owner, address(t))
+	}
+	if err != nil {
+		return values.Amount{}, fmt.Errorf("get %s balance of %s: %w", t, wa.Address(), err)
+	}
+
+	return values.NewAmount(t, value), nil
+}
@@ -0,0 +1,88 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+	"github.com/r1der/epos/pkg/erc20"
+)
+
+// fakeTokens keeps the native coin and the token balances of a single owner
+type fakeTokens struct {
+	erc20.Manager
+
+	owner    common.Address
+	native   *big.Int
+	balances map[common.Address]*big.Int
+	err      error
+}
+
+func (f *fakeTokens) GetBalance(_ context.Context, address common.Address) (*big.Int, error) {
+	if address != f.owner {
+		return new(big.Int), f.err
+	}
+	return f.native, f.err
+}
+
+func (f *fakeTokens) GetTokenBalance(_ context.Context, address, tokenAddress common.Address) (*big.Int, error) {
+	if address != f.owner || f.balances[tokenAddress] == nil {
+		return new(big.Int), f.err
+	}
+	return f.balances[tokenAddress], f.err
+}
+
+func TestBalance(t *testing.T) {
+	ctx := context.Background()
+	eth := token.New("eth", "0x0000000000000000000000000000000000000000", "ETH", 18)
+	owner := common.HexToAddress("0x7000000000000000000000000000000000000001")
+	tokens := &fakeTokens{
+		owner:  owner,
+		native: big.NewInt(3e18),
+		balances: map[common.Address]*big.Int{
+			address(weth): big.NewInt(2e18),
+			address(usdc): big.NewInt(1_500_000_000),
+		},
+	}
+	b := NewBalance(map[string]erc20.Manager{"eth": tokens})
+
+	wa := wallet.Restore(wallet.Snapshot{Network: "eth", Address: owner.Hex(), NativeToken: eth})
+	tests := []struct {
+		token *token.Token
+		want  int64
+	}{
+		// the native coin balance is read from the account, not from a token contract
+		{eth, 3e18},
+		{weth, 2e18},
+		{usdc, 1_500_000_000},
+		{token.New("eth", "0x7000000000000000000000000000000000000002", "DAI", 18), 0},
+	}
+	for _, tt := range tests {
+		got, err := b.Get(ctx, wa, tt.token)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.token, err)
+		}
+		if got.Value().Int64() != tt.want || !got.Token().Eq(tt.token) {
+			t.Errorf("%s: balance %s, want %d", tt.token, got, tt.want)
+		}
+	}
+
+	// the wallet without a native token holds the tokens only
+	noNative := wallet.Restore(wallet.Snapshot{Network: "eth", Address: owner.Hex()})
+	if got, err := b.Get(ctx, noNative, weth); err != nil || got.Value().Int64() != 2e18 {
+		t.Errorf("weth balance %s, %v", got, err)
+	}
+
+	if _, err := b.Get(ctx, wa, token.New("arbitrum", "0x1", "ARB", 18)); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Errorf("expected the unsupported network error, got %v", err)
+	}
+	tokens.err = errors.New("connection refused")
+	if _, err := b.Get(ctx, wa, eth); !errors.Is(err, tokens.err) {
+		t.Errorf("expected the manager error, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package evm
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/values"
+	"github.com/r1der/epos/pkg/clmath"
+)
+
+// feeTier converts a pool fee to the uint24 fee tier in hundredths of a bip
+func feeTier(fee values.Percent) *big.Int {
+	return big.NewInt(int64(math.Round(fee.Value() * 1e6)))
+}
+
+func address(t *token.Token) common.Address {
+	return common.HexToAddress(t.Address())
+}
+
+// amountsForLiquidity calculates the token0 and token1 amounts held by the liquidity in the ticks range
+func amountsForLiquidity(liquidity, sqrtPriceX96 *big.Int, tickLower, tickUpper int) (*big.Int, *big.Int, error) {
+	sqrtRatioAX96, err := clmath.GetSqrtRatioAtTick(tickLower)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqrtRatioBX96, err := clmath.GetSqrtRatioAtTick(tickUpper)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clmath.GetAmountsForLiquidity(sqrtPriceX96, sqrtRatioAX96, sqrtRatioBX96, liquidity)
+}
+
+// toPoolAmounts orders the base and quote amounts as token0 and token1 amounts
+func toPoolAmounts(pair *token.Pair, baseAmount, quoteAmount values.Amount) (*big.Int, *big.Int) {
+	if pair.IsBaseToken0() {
+		return valueOf(baseAmount), valueOf(quoteAmount)
+	}
+	return valueOf(quoteAmount), valueOf(baseAmount)
+}
+
+// fromPoolAmounts converts the token0 and token1 amounts to the base and quote amounts
+func fromPoolAmounts(pair *token.Pair, amount0, amount1 *big.Int) (values.Amount, values.Amount) {
+	if pair.IsBaseToken0() {
+		return values.NewAmount(pair.BaseToken(), amount0), values.NewAmount(pair.QuoteToken(), amount1)
+	}
+	return values.NewAmount(pair.BaseToken(), amount1), values.NewAmount(pair.QuoteToken(), amount0)
+}
+
+func valueOf(a values.Amount) *big.Int {
+	if a.Value() == nil {
+		return big.NewInt(0)
+	}
+	return a.Value()
+}
@@ -0,0 +1,125 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/ports"
+	"github.com/r1der/epos/internal/domain/values"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+type factory struct {
+	networks networks
+}
+
+// NewFactory creates a Uniswap v3 factory adapter, networks maps a network to its Uniswap client
+func NewFactory(networks map[string]uniswap.Uniswap) ports.Factory {
+	return &factory{networks: networks}
+}
+
+// FindPool resolves the pool address via the factory and loads its state
+func (f *factory) FindPool(ctx context.Context, network, protocol string, pair *token.Pair, fee values.Percent) (*ports.Pool, error) {
+	cli, err := f.networks.client(network, protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := cli.FindPool(ctx, address(pair.BaseToken()), address(pair.QuoteToken()), feeTier(fee))
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: find pool: %w", err)
+	}
+
+	return &ports.Pool{
+		Address:     p.Address.Hex(),
+		LastPrice:   values.NewPriceFromSqrtPriceX96(pair, p.SqrtPriceX96),
+		Liquidity:   p.Liquidity,
+		TickSpacing: p.TickSpacing,
+	}, nil
+}
+
+// GetPool loads the pool state
+func (f *factory) GetPool(ctx context.Context, network, protocol string, pair *token.Pair, addr string) (*ports.Pool, error) {
+	cli, err := f.networks.client(network, protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := cli.GetPool(ctx, common.HexToAddress(addr))
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: get pool: %w", err)
+	}
+
+	return &ports.Pool{
+		Address:     p.Address.Hex(),
+		LastPrice:   values.NewPriceFromSqrtPriceX96(pair, p.SqrtPriceX96),
+		Liquidity:   p.Liquidity,
+		TickSpacing: p.TickSpacing,
+	}, nil
+}
+
+// CalculateRange calculates the price range around the current pool price
+func (f *factory) CalculateRange(ctx context.Context, in *ports.CalculateRangeInput) (*ports.CalculateRangeOutput, error) {
+	p, err := f.GetPool(ctx, in.Network, in.Protocol, in.Pair, in.PoolAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	one := big.NewRat(1, 1)
+	lower := p.LastPrice.Mul(new(big.Rat).Sub(one, in.BaseVolatility.Rat()))
+	upper := p.LastPrice.Mul(new(big.Rat).Add(one, in.QuoteVolatility.Rat()))
+
+	return &ports.CalculateRangeOutput{
+		Network:     in.Network,
+		Protocol:    in.Protocol,
+		PoolAddress: p.Address,
+		LastPrice:   p.LastPrice,
+		LowerPrice:  lower,
+		UpperPrice:  upper,
+		TickSpacing: p.TickSpacing,
+	}, nil
+}
+
+// PriceHistory returns the time weighted average prices of the periods from the oldest one,
+// the prices are derived from the pool oracle observations
+func (f *factory) PriceHistory(ctx context.Context, in *ports.PriceHistoryInput) ([]values.Price, error) {
+	cli, err := f.networks.client(in.Network, in.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := int64(in.Interval / time.Second)
+	// the oracle looks back by uint32 seconds
+	if interval <= 0 || in.Periods <= 0 || int64(in.Periods)*interval > math.MaxUint32 {
+		return nil, fmt.Errorf("invalid price history of %d periods of %s", in.Periods, in.Interval)
+	}
+
+	secondsAgos := make([]uint32, in.Periods+1)
+	for i := range secondsAgos {
+		secondsAgos[i] = uint32(int64(in.Periods-i) * interval)
+	}
+	cumulatives, err := cli.Observe(ctx, common.HexToAddress(in.PoolAddress), secondsAgos)
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: observe: %w", err)
+	}
+
+	prices := make([]values.Price, 0, in.Periods)
+	for i := 1; i < len(cumulatives); i++ {
+		// the euclidean division rounds the average tick to the negative infinity like the Uniswap oracle library does
+		delta := new(big.Int).Sub(cumulatives[i], cumulatives[i-1])
+		tick := new(big.Int).Div(delta, big.NewInt(interval))
+
+		price, err := values.NewPriceAtTick(in.Pair, int(tick.Int64()))
+		if err != nil {
+			return nil, fmt.Errorf("price at tick %s: %w", tick, err)
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
@@ -0,0 +1,264 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/r1der/epos/internal/domain/entity/protocol"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/ports"
+	"github.com/r1der/epos/internal/domain/values"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+var (
+	// usdc sorts before weth, so usdc is the token0 of their pool
+	usdc = token.New("eth", "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "USDC", 6)
+	weth = token.New("eth", "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "WETH", 18)
+
+	poolAddress = common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640")
+)
+
+// fakeUniswap replies with the fixed pool state and records the requests
+type fakeUniswap struct {
+	pool        *uniswap.Pool
+	cumulatives []*big.Int
+	position    *uniswap.Position
+	fees        *uniswap.Fees
+	swap        *uniswap.SwapOutput
+	increase    *uniswap.IncreaseLiquidityOutput
+	decrease    *uniswap.DecreaseLiquidityOutput
+	collect     *uniswap.CollectFeesOutput
+	// err fails all the requests when set
+	err error
+
+	findTokens  [2]common.Address
+	findFee     *big.Int
+	secondsAgos []uint32
+	signer      uniswap.Signer
+	increaseIn  *uniswap.IncreaseLiquidityInput
+	decreaseIn  *uniswap.DecreaseLiquidityInput
+	collectIn   *uniswap.CollectFeesInput
+	exactIn     *uniswap.ExactInputSingleInput
+	exactOut    *uniswap.ExactOutputSingleInput
+}
+
+func (f *fakeUniswap) FindPool(_ context.Context, tokenA, tokenB common.Address, fee *big.Int) (*uniswap.Pool, error) {
+	f.findTokens, f.findFee = [2]common.Address{tokenA, tokenB}, fee
+	return f.pool, f.err
+}
+
+func (f *fakeUniswap) GetPool(_ context.Context, address common.Address) (*uniswap.Pool, error) {
+	if f.err == nil && address != f.pool.Address {
+		return nil, errors.New("pool not found")
+	}
+	return f.pool, f.err
+}
+
+func (f *fakeUniswap) Observe(_ context.Context, _ common.Address, secondsAgos []uint32) ([]*big.Int, error) {
+	f.secondsAgos = secondsAgos
+	return f.cumulatives, f.err
+}
+
+func (f *fakeUniswap) IncreaseLiquidity(_ context.Context, signer uniswap.Signer, in *uniswap.IncreaseLiquidityInput) (*uniswap.IncreaseLiquidityOutput, error) {
+	f.signer, f.increaseIn = signer, in
+	return f.increase, f.err
+}
+
+func (f *fakeUniswap) DecreaseLiquidity(_ context.Context, signer uniswap.Signer, in *uniswap.DecreaseLiquidityInput) (*uniswap.DecreaseLiquidityOutput, error) {
+	f.signer, f.decreaseIn = signer, in
+	return f.decrease, f.err
+}
+
+func (f *fakeUniswap) GetPosition(_ context.Context, _ *big.Int) (*uniswap.Position, error) {
+	return f.position, f.err
+}
+
+func (f *fakeUniswap) GetFees(_ context.Context, _ common.Address, _ *big.Int) (*uniswap.Fees, error) {
+	return f.fees, f.err
+}
+
+func (f *fakeUniswap) CollectFees(_ context.Context, signer uniswap.Signer, in *uniswap.CollectFeesInput) (*uniswap.CollectFeesOutput, error) {
+	f.signer, f.collectIn = signer, in
+	return f.collect, f.err
+}
+
+func (f *fakeUniswap) ExactInputSingle(_ context.Context, signer uniswap.Signer, in *uniswap.ExactInputSingleInput) (*uniswap.SwapOutput, error) {
+	f.signer, f.exactIn = signer, in
+	return f.swap, f.err
+}
+
+func (f *fakeUniswap) ExactOutputSingle(_ context.Context, signer uniswap.Signer, in *uniswap.ExactOutputSingleInput) (*uniswap.SwapOutput, error) {
+	f.signer, f.exactOut = signer, in
+	return f.swap, f.err
+}
+
+// newFakeUniswap creates the fake with the pool of usdc and weth at the price of weth in usdc
+func newFakeUniswap(t *testing.T, price float64) *fakeUniswap {
+	t.Helper()
+	sqrtPriceX96, err := values.NewPrice(token.NewPair(weth, usdc), price).SqrtPriceX96()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeUniswap{pool: &uniswap.Pool{
+		Address:      poolAddress,
+		Token0:       address(usdc),
+		Token1:       address(weth),
+		Fee:          big.NewInt(500),
+		TickSpacing:  10,
+		SqrtPriceX96: sqrtPriceX96,
+		Liquidity:    big.NewInt(1e18),
+	}}
+}
+
+// near reports whether the price is within the relative tolerance of the value
+func near(p values.Price, want float64, tolerance float64) bool {
+	got, _ := p.Float().Float64()
+	return got >= want*(1-tolerance) && got <= want*(1+tolerance)
+}
+
+func TestFactoryPool(t *testing.T) {
+	ctx := context.Background()
+	cli := newFakeUniswap(t, 2000)
+	f := NewFactory(map[string]uniswap.Uniswap{"eth": cli})
+
+	for _, pair := range []*token.Pair{token.NewPair(weth, usdc), token.NewPair(usdc, weth)} {
+		p, err := f.FindPool(ctx, "eth", protocol.Uniswap, pair, values.NewPercent(0.0005))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Address != poolAddress.Hex() || p.TickSpacing != 10 || p.Liquidity.Cmp(big.NewInt(1e18)) != 0 {
+			t.Errorf("%s: unexpected pool %+v", pair, p)
+		}
+		if cli.findTokens != [2]common.Address{address(pair.BaseToken()), address(pair.QuoteToken())} || cli.findFee.Int64() != 500 {
+			t.Errorf("%s: pool looked up by %v and fee %s", pair, cli.findTokens, cli.findFee)
+		}
+
+		// the price is of the pair base token whichever token is the token0
+		want := 2000.0
+		if pair.BaseToken() == usdc {
+			want = 1 / want
+		}
+		if !near(p.LastPrice, want, 1e-12) || p.LastPrice.Pair() != pair {
+			t.Errorf("%s: last price %s, want %v", pair, p.LastPrice, want)
+		}
+
+		got, err := f.GetPool(ctx, "eth", protocol.Uniswap, pair, poolAddress.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Address != p.Address || got.LastPrice.Cmp(p.LastPrice) != 0 {
+			t.Errorf("%s: got pool %+v, found %+v", pair, got, p)
+		}
+	}
+
+	pair := token.NewPair(weth, usdc)
+	if _, err := f.FindPool(ctx, "arbitrum", protocol.Uniswap, pair, 0.0005); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Errorf("expected the unsupported network error, got %v", err)
+	}
+	if _, err := f.GetPool(ctx, "eth", protocol.Sushiswap, pair, poolAddress.Hex()); !errors.Is(err, ErrUnsupportedProtocol) {
+		t.Errorf("expected the unsupported protocol error, got %v", err)
+	}
+	cli.err = errors.New("execution reverted")
+	if _, err := f.FindPool(ctx, "eth", protocol.Uniswap, pair, 0.0005); !errors.Is(err, cli.err) {
+		t.Errorf("expected the client error, got %v", err)
+	}
+}
+
+func TestFactoryCalculateRange(t *testing.T) {
+	ctx := context.Background()
+	f := NewFactory(map[string]uniswap.Uniswap{"eth": newFakeUniswap(t, 2000)})
+
+	for _, pair := range []*token.Pair{token.NewPair(weth, usdc), token.NewPair(usdc, weth)} {
+		out, err := f.CalculateRange(ctx, &ports.CalculateRangeInput{
+			Network:         "eth",
+			Protocol:        protocol.Uniswap,
+			PoolAddress:     poolAddress.Hex(),
+			Pair:            pair,
+			BaseVolatility:  values.NewPercent(0.1),
+			QuoteVolatility: values.NewPercent(0.25),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		last := 2000.0
+		if pair.BaseToken() == usdc {
+			last = 1 / last
+		}
+		if !near(out.LastPrice, last, 1e-12) || !near(out.LowerPrice, last*0.9, 1e-12) || !near(out.UpperPrice, last*1.25, 1e-12) {
+			t.Errorf("%s: range [%s, %s] around %s", pair, out.LowerPrice, out.UpperPrice, out.LastPrice)
+		}
+		if out.PoolAddress != poolAddress.Hex() || out.TickSpacing != 10 || out.Network != "eth" || out.Protocol != protocol.Uniswap {
+			t.Errorf("%s: unexpected range output %+v", pair, out)
+		}
+	}
+
+	_, err := f.CalculateRange(ctx, &ports.CalculateRangeInput{
+		Network: "eth", Protocol: protocol.Uniswap, PoolAddress: "0x1", Pair: token.NewPair(weth, usdc),
+	})
+	if err == nil {
+		t.Error("expected the unknown pool error")
+	}
+}
+
+func TestFactoryPriceHistory(t *testing.T) {
+	ctx := context.Background()
+	pair := token.NewPair(weth, usdc)
+	hour := int64(time.Hour / time.Second)
+
+	// the average ticks of the periods are 200311, 200320 and the rounded down -7.5
+	cli := newFakeUniswap(t, 2000)
+	cli.cumulatives = []*big.Int{
+		big.NewInt(-1000),
+		big.NewInt(-1000 + 200311*hour),
+		big.NewInt(-1000 + 200311*hour + 200320*hour),
+		big.NewInt(-1000 + 200311*hour + 200320*hour - 7*hour - hour/2),
+	}
+	f := NewFactory(map[string]uniswap.Uniswap{"eth": cli})
+
+	prices, err := f.PriceHistory(ctx, &ports.PriceHistoryInput{
+		Network: "eth", Protocol: protocol.Uniswap, PoolAddress: poolAddress.Hex(), Pair: pair,
+		Interval: time.Hour, Periods: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint32{10800, 7200, 3600, 0}; len(cli.secondsAgos) != len(want) ||
+		cli.secondsAgos[0] != want[0] || cli.secondsAgos[1] != want[1] || cli.secondsAgos[2] != want[2] || cli.secondsAgos[3] != want[3] {
+		t.Fatalf("observed at %v, want %v", cli.secondsAgos, want)
+	}
+	if len(prices) != 3 {
+		t.Fatalf("got %d prices, want 3", len(prices))
+	}
+	for i, tick := range []int{200311, 200320, -8} {
+		want, err := values.NewPriceAtTick(pair, tick)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prices[i].Cmp(want) != 0 {
+			t.Errorf("period %d: price %s, want %s at tick %d", i, prices[i], want, tick)
+		}
+	}
+	// weth is the token1, so its price in usdc falls as the tick grows
+	if prices[0].Cmp(prices[1]) <= 0 || !near(prices[0], 2000, 0.001) {
+		t.Errorf("unexpected prices %v", prices)
+	}
+
+	for _, in := range []ports.PriceHistoryInput{
+		{Interval: 0, Periods: 3},
+		{Interval: time.Millisecond, Periods: 3},
+		{Interval: time.Hour, Periods: 0},
+		{Interval: 24 * time.Hour, Periods: 50_000},
+	} {
+		in.Network, in.Protocol, in.PoolAddress, in.Pair = "eth", protocol.Uniswap, poolAddress.Hex(), pair
+		if _, err = f.PriceHistory(ctx, &in); err == nil {
+			t.Errorf("%d periods of %s: expected the invalid history error", in.Periods, in.Interval)
+		}
+	}
+}
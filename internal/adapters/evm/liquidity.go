@@ -0,0 +1,186 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/r1der/epos/internal/domain/ports"
+	"github.com/r1der/epos/internal/domain/values"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+type liquidityManager struct {
+	networks networks
+	signers  Signers
+}
+
+// NewLiquidityManager creates a NonfungiblePositionManager adapter, networks maps a network to its Uniswap client
+// and signers resolve the signers of the wallets
+func NewLiquidityManager(networks map[string]uniswap.Uniswap, signers Signers) ports.LiquidityManager {
+	return &liquidityManager{networks: networks, signers: signers}
+}
+
+// IncreaseLiquidity mints a new position, the position address is the NFT token id
+func (lm *liquidityManager) IncreaseLiquidity(ctx context.Context, in *ports.IncreaseLiquidityInput) (*ports.IncreaseLiquidityOutput, error) {
+	cli, err := lm.networks.client(in.Network, in.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	s, err := signer(ctx, lm.signers, in.Wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := cli.GetPool(ctx, common.HexToAddress(in.PoolAddress))
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: get pool: %w", err)
+	}
+
+	if in.LowerTick%p.TickSpacing != 0 || in.UpperTick%p.TickSpacing != 0 || in.LowerTick >= in.UpperTick {
+		return nil, fmt.Errorf("invalid ticks range [%d, %d] for tick spacing %d", in.LowerTick, in.UpperTick, p.TickSpacing)
+	}
+	amount0, amount1 := toPoolAmounts(in.Pair, in.BaseAmount, in.QuoteAmount)
+	amount0Min, amount1Min := toPoolAmounts(in.Pair, in.BaseMinAmount, in.QuoteMinAmount)
+
+	data, err := cli.IncreaseLiquidity(ctx, s, &uniswap.IncreaseLiquidityInput{
+		Token0:         p.Token0,
+		Token1:         p.Token1,
+		Fee:            p.Fee,
+		TickLower:      in.LowerTick,
+		TickUpper:      in.UpperTick,
+		Amount0Desired: amount0,
+		Amount1Desired: amount1,
+		Amount0Min:     amount0Min,
+		Amount1Min:     amount1Min,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: increase liquidity: %w", err)
+	}
+
+	baseAmount, quoteAmount := fromPoolAmounts(in.Pair, data.Amount0, data.Amount1)
+	return &ports.IncreaseLiquidityOutput{
+		Address:        data.TokenID.String(),
+		Liquidity:      data.Liquidity,
+		BaseAmount:     baseAmount,
+		QuoteAmount:    quoteAmount,
+		TransactionFee: data.TransactionFee,
+	}, nil
+}
+
+// DecreaseLiquidity removes the liquidity from the position and withdraws the released tokens
+func (lm *liquidityManager) DecreaseLiquidity(ctx context.Context, in *ports.DecreaseLiquidityInput) (*ports.DecreaseLiquidityOutput, error) {
+	cli, err := lm.networks.client(in.Network, in.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	s, err := signer(ctx, lm.signers, in.Wallet)
+	if err != nil {
+		return nil, err
+	}
+	tokenID, err := parseTokenID(in.PositionAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	amount0Min, amount1Min := toPoolAmounts(in.Pair, in.BaseMinAmount, in.QuoteMinAmount)
+
+	data, err := cli.DecreaseLiquidity(ctx, s, &uniswap.DecreaseLiquidityInput{
+		TokenID:    tokenID,
+		Liquidity:  in.Liquidity,
+		Amount0Min: amount0Min,
+		Amount1Min: amount1Min,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: decrease liquidity: %w", err)
+	}
+
+	baseAmount, quoteAmount := fromPoolAmounts(in.Pair, data.Amount0, data.Amount1)
+	return &ports.DecreaseLiquidityOutput{
+		Address:        in.PositionAddress,
+		Liquidity:      in.Liquidity,
+		BaseAmount:     baseAmount,
+		QuoteAmount:    quoteAmount,
+		TransactionFee: data.TransactionFee,
+	}, nil
+}
+
+// GetPosition reads the position state and values it at the current pool price
+func (lm *liquidityManager) GetPosition(ctx context.Context, in *ports.GetPositionInput) (*ports.GetPositionOutput, error) {
+	cli, err := lm.networks.client(in.Network, in.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	tokenID, err := parseTokenID(in.PositionAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	pos, err := cli.GetPosition(ctx, tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: get position: %w", err)
+	}
+	p, err := cli.GetPool(ctx, common.HexToAddress(in.PoolAddress))
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: get pool: %w", err)
+	}
+	fees, err := cli.GetFees(ctx, common.HexToAddress(in.Wallet.Address()), tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: get fees: %w", err)
+	}
+
+	amount0, amount1, err := amountsForLiquidity(pos.Liquidity, p.SqrtPriceX96, pos.TickLower, pos.TickUpper)
+	if err != nil {
+		return nil, fmt.Errorf("calculate position amounts: %w", err)
+	}
+	baseAmount, quoteAmount := fromPoolAmounts(in.Pair, amount0, amount1)
+	baseFees, quoteFees := fromPoolAmounts(in.Pair, fees.Amount0, fees.Amount1)
+
+	return &ports.GetPositionOutput{
+		CurrentPrice:     values.NewPriceFromSqrtPriceX96(in.Pair, p.SqrtPriceX96),
+		Liquidity:        pos.Liquidity,
+		BaseAmount:       baseAmount,
+		QuoteAmount:      quoteAmount,
+		BaseAccruedFees:  baseFees,
+		QuoteAccruedFees: quoteFees,
+	}, nil
+}
+
+// CollectFees withdraws all the fees accrued by the position
+func (lm *liquidityManager) CollectFees(ctx context.Context, in *ports.CollectFeesInput) (*ports.CollectFeesOutput, error) {
+	cli, err := lm.networks.client(in.Network, in.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	s, err := signer(ctx, lm.signers, in.Wallet)
+	if err != nil {
+		return nil, err
+	}
+	tokenID, err := parseTokenID(in.PositionAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := cli.CollectFees(ctx, s, &uniswap.CollectFeesInput{TokenID: tokenID})
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: collect fees: %w", err)
+	}
+
+	baseAmount, quoteAmount := fromPoolAmounts(in.Pair, data.Amount0, data.Amount1)
+	return &ports.CollectFeesOutput{
+		Address:        data.TransactionHash.Hex(),
+		BaseAmount:     baseAmount,
+		QuoteAmount:    quoteAmount,
+		TransactionFee: data.TransactionFee,
+	}, nil
+}
+
+func parseTokenID(positionAddress string) (*big.Int, error) {
+	tokenID, ok := new(big.Int).SetString(positionAddress, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid position token id: %s", positionAddress)
+	}
+	return tokenID, nil
+}
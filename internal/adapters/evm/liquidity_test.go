@@ -0,0 +1,240 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/r1der/epos/internal/domain/entity/protocol"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+	"github.com/r1der/epos/internal/domain/ports"
+	"github.com/r1der/epos/internal/domain/values"
+	"github.com/r1der/epos/pkg/clmath"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+const testKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// signers resolves the key reference of the test wallet only
+type signers struct {
+	signer uniswap.Signer
+}
+
+func newSigners(t *testing.T) *signers {
+	t.Helper()
+	s, err := uniswap.NewSigner(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &signers{signer: s}
+}
+
+func (s *signers) Signer(_ context.Context, keyRef string) (uniswap.Signer, error) {
+	if keyRef != "keystore:"+s.signer.Address().Hex() {
+		return nil, errors.New("unknown key")
+	}
+	return s.signer, nil
+}
+
+func (s *signers) wallet() *wallet.Wallet {
+	return wallet.Restore(wallet.Snapshot{
+		Name: "main", Network: "eth", Address: s.signer.Address().Hex(), KeyRef: "keystore:" + s.signer.Address().Hex(),
+		NativeToken: weth,
+	})
+}
+
+func TestIncreaseLiquidity(t *testing.T) {
+	ctx := context.Background()
+	s := newSigners(t)
+
+	for _, pair := range []*token.Pair{token.NewPair(weth, usdc), token.NewPair(usdc, weth)} {
+		cli := newFakeUniswap(t, 2000)
+		cli.increase = &uniswap.IncreaseLiquidityOutput{
+			TokenID: big.NewInt(42), Liquidity: big.NewInt(1e12),
+			Amount0: big.NewInt(1_900_000_000), Amount1: big.NewInt(9e17), TransactionFee: big.NewInt(3e14),
+		}
+		lm := NewLiquidityManager(map[string]uniswap.Uniswap{"eth": cli}, s)
+
+		in := &ports.IncreaseLiquidityInput{
+			Network: "eth", Protocol: protocol.Uniswap, PoolAddress: poolAddress.Hex(), Pair: pair, Wallet: s.wallet(),
+			LowerTick: 199_990, UpperTick: 200_500,
+		}
+		// the amounts of the pair base token are weth ones in the first pair and usdc ones in the second
+		wethAmount, usdcAmount := values.NewAmount(weth, int64(1e18)), values.NewAmount(usdc, 2_000_000_000)
+		wethMin, usdcMin := values.NewAmount(weth, int64(99e16)), values.NewAmount(usdc, 1_980_000_000)
+		if pair.BaseToken() == weth {
+			in.BaseAmount, in.QuoteAmount, in.BaseMinAmount, in.QuoteMinAmount = wethAmount, usdcAmount, wethMin, usdcMin
+		} else {
+			in.BaseAmount, in.QuoteAmount, in.BaseMinAmount, in.QuoteMinAmount = usdcAmount, wethAmount, usdcMin, wethMin
+		}
+
+		out, err := lm.IncreaseLiquidity(ctx, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// usdc is the token0 whichever token is the base one
+		req := cli.increaseIn
+		if req.Token0 != address(usdc) || req.Token1 != address(weth) || req.Fee.Int64() != 500 ||
+			req.TickLower != 199_990 || req.TickUpper != 200_500 {
+			t.Errorf("%s: unexpected mint request %+v", pair, req)
+		}
+		if req.Amount0Desired.Cmp(usdcAmount.Value()) != 0 || req.Amount1Desired.Cmp(wethAmount.Value()) != 0 ||
+			req.Amount0Min.Cmp(usdcMin.Value()) != 0 || req.Amount1Min.Cmp(wethMin.Value()) != 0 {
+			t.Errorf("%s: minted %s/%s of token0 and %s/%s of token1", pair,
+				req.Amount0Desired, req.Amount0Min, req.Amount1Desired, req.Amount1Min)
+		}
+		if cli.signer != s.signer {
+			t.Errorf("%s: minted by another signer", pair)
+		}
+
+		gotUSDC, gotWETH := out.QuoteAmount, out.BaseAmount
+		if pair.BaseToken() == usdc {
+			gotUSDC, gotWETH = out.BaseAmount, out.QuoteAmount
+		}
+		if out.Address != "42" || out.Liquidity.Int64() != 1e12 || out.TransactionFee.Int64() != 3e14 ||
+			!gotUSDC.Token().Eq(usdc) || gotUSDC.Value().Int64() != 1_900_000_000 ||
+			!gotWETH.Token().Eq(weth) || gotWETH.Value().Int64() != 9e17 {
+			t.Errorf("%s: unexpected mint output %+v", pair, out)
+		}
+	}
+
+	cli := newFakeUniswap(t, 2000)
+	lm := NewLiquidityManager(map[string]uniswap.Uniswap{"eth": cli}, s)
+	for _, ticks := range [][2]int{{199_995, 200_500}, {199_990, 200_505}, {200_500, 200_500}, {200_500, 199_990}} {
+		_, err := lm.IncreaseLiquidity(ctx, &ports.IncreaseLiquidityInput{
+			Network: "eth", Protocol: protocol.Uniswap, PoolAddress: poolAddress.Hex(), Pair: token.NewPair(weth, usdc),
+			Wallet: s.wallet(), LowerTick: ticks[0], UpperTick: ticks[1],
+		})
+		if err == nil {
+			t.Errorf("ticks %v: expected the invalid range error", ticks)
+		}
+	}
+	if cli.increaseIn != nil {
+		t.Error("minted a position of an invalid range")
+	}
+
+	other := wallet.Restore(wallet.Snapshot{Network: "eth", Address: "0x1", KeyRef: "keystore:0x1"})
+	_, err := lm.IncreaseLiquidity(ctx, &ports.IncreaseLiquidityInput{
+		Network: "eth", Protocol: protocol.Uniswap, PoolAddress: poolAddress.Hex(), Pair: token.NewPair(weth, usdc),
+		Wallet: other, LowerTick: 199_990, UpperTick: 200_500,
+	})
+	if err == nil {
+		t.Error("expected the unknown signer error")
+	}
+}
+
+func TestDecreaseLiquidity(t *testing.T) {
+	ctx := context.Background()
+	s := newSigners(t)
+	cli := newFakeUniswap(t, 2000)
+	cli.decrease = &uniswap.DecreaseLiquidityOutput{
+		Amount0: big.NewInt(1_000_000), Amount1: big.NewInt(5e17), TransactionFee: big.NewInt(2e14),
+	}
+	lm := NewLiquidityManager(map[string]uniswap.Uniswap{"eth": cli}, s)
+
+	pair := token.NewPair(weth, usdc)
+	out, err := lm.DecreaseLiquidity(ctx, &ports.DecreaseLiquidityInput{
+		Network: "eth", Protocol: protocol.Uniswap, PoolAddress: poolAddress.Hex(), Pair: pair, Wallet: s.wallet(),
+		PositionAddress: "42", Liquidity: big.NewInt(1e12),
+		BaseMinAmount: values.NewAmount(weth, int64(4e17)), QuoteMinAmount: values.NewAmount(usdc, 900_000),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := cli.decreaseIn
+	if req.TokenID.Int64() != 42 || req.Liquidity.Int64() != 1e12 || req.Amount0Min.Int64() != 900_000 || req.Amount1Min.Int64() != 4e17 {
+		t.Errorf("unexpected decrease request %+v", req)
+	}
+	if out.Address != "42" || out.BaseAmount.Value().Int64() != 5e17 || out.QuoteAmount.Value().Int64() != 1_000_000 ||
+		out.TransactionFee.Int64() != 2e14 || out.Liquidity.Int64() != 1e12 {
+		t.Errorf("unexpected decrease output %+v", out)
+	}
+
+	_, err = lm.DecreaseLiquidity(ctx, &ports.DecreaseLiquidityInput{
+		Network: "eth", Protocol: protocol.Uniswap, Pair: pair, Wallet: s.wallet(), PositionAddress: "0x2a",
+	})
+	if err == nil {
+		t.Error("expected the invalid token id error")
+	}
+
+	cli.err = errors.New("execution reverted: Price slippage check")
+	_, err = lm.DecreaseLiquidity(ctx, &ports.DecreaseLiquidityInput{
+		Network: "eth", Protocol: protocol.Uniswap, Pair: pair, Wallet: s.wallet(), PositionAddress: "42",
+	})
+	if !errors.Is(err, cli.err) {
+		t.Errorf("expected the client error, got %v", err)
+	}
+}
+
+func TestGetPosition(t *testing.T) {
+	ctx := context.Background()
+	s := newSigners(t)
+	cli := newFakeUniswap(t, 2000)
+	tick, err := clmath.GetTickAtSqrtRatio(cli.pool.SqrtPriceX96)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli.pool.Tick = tick
+	cli.fees = &uniswap.Fees{Amount0: big.NewInt(1_500), Amount1: big.NewInt(3e12)}
+	lm := NewLiquidityManager(map[string]uniswap.Uniswap{"eth": cli}, s)
+
+	tests := []struct {
+		name               string
+		lower, upper       int
+		usdcHeld, wethHeld bool
+	}{
+		// weth is the token1, so below the range the position holds usdc only and above it weth only
+		{"in range", tick - 100, tick + 100, true, true},
+		{"below", tick + 100, tick + 200, true, false},
+		{"above", tick - 200, tick - 100, false, true},
+	}
+	pair := token.NewPair(weth, usdc)
+	for _, tt := range tests {
+		cli.position = &uniswap.Position{TokenID: big.NewInt(42), TickLower: tt.lower, TickUpper: tt.upper, Liquidity: big.NewInt(1e15)}
+		out, err := lm.GetPosition(ctx, &ports.GetPositionInput{
+			Network: "eth", Protocol: protocol.Uniswap, PoolAddress: poolAddress.Hex(), Pair: pair, Wallet: s.wallet(),
+			PositionAddress: "42",
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if (out.QuoteAmount.Sign() > 0) != tt.usdcHeld || (out.BaseAmount.Sign() > 0) != tt.wethHeld {
+			t.Errorf("%s: holds %s and %s", tt.name, out.BaseAmount, out.QuoteAmount)
+		}
+		if out.BaseAccruedFees.Value().Int64() != 3e12 || out.QuoteAccruedFees.Value().Int64() != 1_500 ||
+			out.Liquidity.Int64() != 1e15 || !near(out.CurrentPrice, 2000, 1e-12) {
+			t.Errorf("%s: unexpected position output %+v", tt.name, out)
+		}
+	}
+}
+
+func TestCollectFees(t *testing.T) {
+	ctx := context.Background()
+	s := newSigners(t)
+	cli := newFakeUniswap(t, 2000)
+	cli.collect = &uniswap.CollectFeesOutput{
+		TransactionHash: common.HexToHash("0xc0"), Amount0: big.NewInt(1_500), Amount1: big.NewInt(3e12), TransactionFee: big.NewInt(1e14),
+	}
+	lm := NewLiquidityManager(map[string]uniswap.Uniswap{"eth": cli}, s)
+
+	out, err := lm.CollectFees(ctx, &ports.CollectFeesInput{
+		Network: "eth", Protocol: protocol.Uniswap, PoolAddress: poolAddress.Hex(), Pair: token.NewPair(usdc, weth),
+		Wallet: s.wallet(), PositionAddress: "42",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// all the owed fees are collected
+	if cli.collectIn.TokenID.Int64() != 42 || cli.collectIn.Amount0Max != nil || cli.collectIn.Amount1Max != nil {
+		t.Errorf("unexpected collect request %+v", cli.collectIn)
+	}
+	if out.Address != common.HexToHash("0xc0").Hex() || out.BaseAmount.Value().Int64() != 1_500 ||
+		out.QuoteAmount.Value().Int64() != 3e12 || out.TransactionFee.Int64() != 1e14 {
+		t.Errorf("unexpected collect output %+v", out)
+	}
+}
@@ -0,0 +1,44 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/r1der/epos/internal/domain/entity/protocol"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+var (
+	ErrUnsupportedNetwork  = errors.New("unsupported network")
+	ErrUnsupportedProtocol = errors.New("unsupported protocol")
+)
+
+// networks maps a network to its Uniswap client
+type networks map[string]uniswap.Uniswap
+
+func (n networks) client(network, proto string) (uniswap.Uniswap, error) {
+	if proto != protocol.Uniswap {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProtocol, proto)
+	}
+	cli, ok := n[network]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, network)
+	}
+	return cli, nil
+}
+
+// Signers resolves the wallet key reference to the signer of the wallet transactions
+type Signers interface {
+	Signer(ctx context.Context, keyRef string) (uniswap.Signer, error)
+}
+
+// signer resolves the transactions signer of the wallet
+func signer(ctx context.Context, signers Signers, wa *wallet.Wallet) (uniswap.Signer, error) {
+	s, err := signers.Signer(ctx, wa.KeyRef())
+	if err != nil {
+		return nil, fmt.Errorf("wallet %s signer: %w", wa.Address(), err)
+	}
+	return s, nil
+}
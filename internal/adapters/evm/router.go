@@ -0,0 +1,85 @@
+package evm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/ports"
+	"github.com/r1der/epos/internal/domain/values"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+type router struct {
+	networks networks
+	signers  Signers
+}
+
+// NewRouter creates a SwapRouter02 adapter, networks maps a network to its Uniswap client
+// and signers resolve the signers of the wallets
+func NewRouter(networks map[string]uniswap.Uniswap, signers Signers) ports.Router {
+	return &router{networks: networks, signers: signers}
+}
+
+// Swap swaps the tokens, the swap mode selects which of the amounts is exact and which is the slippage limit
+func (r *router) Swap(ctx context.Context, in *ports.SwapInput) (*ports.SwapOutput, error) {
+	cli, err := r.networks.client(in.Network, in.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	s, err := signer(ctx, r.signers, in.Wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := common.HexToAddress(in.PoolAddress)
+	tokenIn, tokenOut := address(in.AmountIn.Token()), address(in.AmountOut.Token())
+
+	var data *uniswap.SwapOutput
+	switch in.Mode {
+	case ports.ExactInput:
+		data, err = cli.ExactInputSingle(ctx, s, &uniswap.ExactInputSingleInput{
+			Pool:             pool,
+			TokenIn:          tokenIn,
+			TokenOut:         tokenOut,
+			Fee:              feeTier(in.Fee),
+			AmountIn:         valueOf(in.AmountIn),
+			AmountOutMinimum: valueOf(in.AmountOut),
+		})
+	case ports.ExactOutput:
+		data, err = cli.ExactOutputSingle(ctx, s, &uniswap.ExactOutputSingleInput{
+			Pool:            pool,
+			TokenIn:         tokenIn,
+			TokenOut:        tokenOut,
+			Fee:             feeTier(in.Fee),
+			AmountOut:       valueOf(in.AmountOut),
+			AmountInMaximum: valueOf(in.AmountIn),
+		})
+	default:
+		return nil, fmt.Errorf("invalid swap mode: %s", in.Mode)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("uniswap: swap %s: %w", in.Mode, err)
+	}
+
+	amountIn := values.NewAmount(in.AmountIn.Token(), data.AmountIn)
+	amountOut := values.NewAmount(in.AmountOut.Token(), data.AmountOut)
+
+	return &ports.SwapOutput{
+		Address:        data.TransactionHash.Hex(),
+		AmountIn:       amountIn,
+		AmountOut:      amountOut,
+		FilledPrice:    filledPrice(in.Pair, amountIn, amountOut),
+		TransactionFee: data.TransactionFee,
+	}, nil
+}
+
+// filledPrice calculates the price of the base token in the quote token from the swapped amounts
+func filledPrice(pair *token.Pair, amountIn, amountOut values.Amount) values.Price {
+	if pair.BaseToken().Eq(amountIn.Token()) {
+		return values.NewPriceFromAmounts(amountIn, amountOut)
+	}
+	return values.NewPriceFromAmounts(amountOut, amountIn)
+}
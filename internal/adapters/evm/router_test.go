@@ -0,0 +1,122 @@
+package evm
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/r1der/epos/internal/domain/entity/protocol"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/ports"
+	"github.com/r1der/epos/internal/domain/values"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+func TestSwap(t *testing.T) {
+	ctx := context.Background()
+	s := newSigners(t)
+
+	// 1.5 weth are swapped for 3000 usdc at the price of 2000 usdc per weth
+	wethSwapped, usdcSwapped := big.NewInt(15e17), big.NewInt(3_000_000_000)
+	tests := []struct {
+		name     string
+		pair     *token.Pair
+		mode     ports.SwapMode
+		sellWETH bool
+		want     float64
+	}{
+		{"exact input sell base", token.NewPair(weth, usdc), ports.ExactInput, true, 2000},
+		{"exact input buy base", token.NewPair(weth, usdc), ports.ExactInput, false, 2000},
+		{"exact output sell base", token.NewPair(weth, usdc), ports.ExactOutput, true, 2000},
+		{"exact output buy base", token.NewPair(weth, usdc), ports.ExactOutput, false, 2000},
+		{"exact input sell quote", token.NewPair(usdc, weth), ports.ExactInput, true, 1.0 / 2000},
+		{"exact input buy quote", token.NewPair(usdc, weth), ports.ExactInput, false, 1.0 / 2000},
+		{"exact output sell quote", token.NewPair(usdc, weth), ports.ExactOutput, true, 1.0 / 2000},
+		{"exact output buy quote", token.NewPair(usdc, weth), ports.ExactOutput, false, 1.0 / 2000},
+	}
+	for _, tt := range tests {
+		cli := newFakeUniswap(t, 2000)
+		amountIn, amountOut := values.NewAmount(usdc, 3_100_000_000), values.NewAmount(weth, int64(14e17))
+		cli.swap = &uniswap.SwapOutput{
+			TransactionHash: common.HexToHash("0x5a"), AmountIn: usdcSwapped, AmountOut: wethSwapped, TransactionFee: big.NewInt(1e14),
+		}
+		if tt.sellWETH {
+			amountIn, amountOut = values.NewAmount(weth, int64(16e17)), values.NewAmount(usdc, 2_900_000_000)
+			cli.swap.AmountIn, cli.swap.AmountOut = wethSwapped, usdcSwapped
+		}
+		r := NewRouter(map[string]uniswap.Uniswap{"eth": cli}, s)
+
+		out, err := r.Swap(ctx, &ports.SwapInput{
+			Network: "eth", Protocol: protocol.Uniswap, PoolAddress: poolAddress.Hex(), Fee: values.NewPercent(0.0005),
+			Pair: tt.pair, Wallet: s.wallet(), Mode: tt.mode, AmountIn: amountIn, AmountOut: amountOut,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		// the exact amount and the slippage limit go to the parameters of the swap mode
+		switch tt.mode {
+		case ports.ExactInput:
+			req := cli.exactIn
+			if req == nil || cli.exactOut != nil || req.AmountIn.Cmp(amountIn.Value()) != 0 ||
+				req.AmountOutMinimum.Cmp(amountOut.Value()) != 0 || req.TokenIn != address(amountIn.Token()) ||
+				req.TokenOut != address(amountOut.Token()) || req.Fee.Int64() != 500 || req.Pool != poolAddress {
+				t.Errorf("%s: unexpected exact input request %+v", tt.name, req)
+			}
+		case ports.ExactOutput:
+			req := cli.exactOut
+			if req == nil || cli.exactIn != nil || req.AmountOut.Cmp(amountOut.Value()) != 0 ||
+				req.AmountInMaximum.Cmp(amountIn.Value()) != 0 || req.TokenIn != address(amountIn.Token()) ||
+				req.TokenOut != address(amountOut.Token()) || req.Fee.Int64() != 500 || req.Pool != poolAddress {
+				t.Errorf("%s: unexpected exact output request %+v", tt.name, req)
+			}
+		}
+		if cli.signer != s.signer {
+			t.Errorf("%s: swapped by another signer", tt.name)
+		}
+
+		// the filled price is of the pair base token, whichever token was sold
+		if got := out.FilledPrice; !got.Pair().BaseToken().Eq(tt.pair.BaseToken()) ||
+			!got.Pair().QuoteToken().Eq(tt.pair.QuoteToken()) || !near(got, tt.want, 1e-15) {
+			t.Errorf("%s: filled price %s, want %v", tt.name, got, tt.want)
+		}
+		if out.AmountIn.Value().Cmp(cli.swap.AmountIn) != 0 || !out.AmountIn.Token().Eq(amountIn.Token()) ||
+			out.AmountOut.Value().Cmp(cli.swap.AmountOut) != 0 || !out.AmountOut.Token().Eq(amountOut.Token()) ||
+			out.Address != common.HexToHash("0x5a").Hex() || out.TransactionFee.Int64() != 1e14 {
+			t.Errorf("%s: unexpected swap output %+v", tt.name, out)
+		}
+	}
+
+	cli := newFakeUniswap(t, 2000)
+	r := NewRouter(map[string]uniswap.Uniswap{"eth": cli}, s)
+	in := &ports.SwapInput{
+		Network: "eth", Protocol: protocol.Uniswap, PoolAddress: poolAddress.Hex(), Fee: values.NewPercent(0.0005),
+		Pair: token.NewPair(weth, usdc), Wallet: s.wallet(), Mode: "exact",
+		AmountIn: values.NewAmount(weth, 1), AmountOut: values.NewAmount(usdc, 0),
+	}
+	if _, err := r.Swap(ctx, in); err == nil {
+		t.Error("expected the invalid mode error")
+	}
+	in.Mode = ports.ExactInput
+	cli.err = errors.New("execution reverted: Too little received")
+	if _, err := r.Swap(ctx, in); !errors.Is(err, cli.err) {
+		t.Errorf("expected the client error, got %v", err)
+	}
+}
+
+func TestFilledPrice(t *testing.T) {
+	// a zero amount of the base token gives the zero price rather than a division by zero
+	price := filledPrice(token.NewPair(weth, usdc), values.NewAmount(weth, 0), values.NewAmount(usdc, 10))
+	if !price.IsZero() {
+		t.Errorf("price %s of a zero base amount", price)
+	}
+
+	// the amounts are exact, so the price keeps the decimals of both tokens
+	price = filledPrice(token.NewPair(usdc, weth), values.NewAmount(weth, 1), values.NewAmount(usdc, 3))
+	if want := values.NewPrice(token.NewPair(usdc, weth), big.NewRat(1, 3_000_000_000_000)); price.Cmp(want) != 0 {
+		t.Errorf("price %s, want %s", price, want)
+	}
+}
@@ -0,0 +1,88 @@
+package hdwallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+)
+
+// the test vectors 1 and 2 of BIP-32
+func TestBIP32Vectors(t *testing.T) {
+	tests := []struct {
+		seed      string
+		path      string
+		chainCode string
+		key       string
+	}{
+		{
+			"000102030405060708090a0b0c0d0e0f", "m",
+			"873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508",
+			"e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35",
+		},
+		{
+			"000102030405060708090a0b0c0d0e0f", "m/0'",
+			"47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141",
+			"edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		},
+		{
+			"000102030405060708090a0b0c0d0e0f", "m/0'/1",
+			"2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19",
+			"3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+		},
+		{
+			"000102030405060708090a0b0c0d0e0f", "m/0'/1/2'",
+			"04466b9cc8e161e966409ca52986c584f07e9dc81f735db683c3ff6ec7b1503f",
+			"cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca",
+		},
+		{
+			"000102030405060708090a0b0c0d0e0f", "m/0'/1/2'/2",
+			"cfb71883f01676f587d023cc53a35bc7f88f724b1f8c2892ac1275ac822a3edd",
+			"0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4",
+		},
+		{
+			"000102030405060708090a0b0c0d0e0f", "m/0'/1/2'/2/1000000000",
+			"c783e67b921d2beb8f6b389cc646d7263b4145701dadd2161548a8b078e65e9e",
+			"471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8",
+		},
+		{
+			"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542",
+			"m",
+			"60499f801b896d83179a4374aeb7822aaeaceaa0db1f85ee3e904c4defbd9689",
+			"4b03d6fc340455b363f51020ad3ecca4f0850280cf436c70c727923f6db46c3e",
+		},
+		{
+			"fffcf9f6f3f0edeae7e4e1dedbd8d5d2cfccc9c6c3c0bdbab7b4b1aeaba8a5a29f9c999693908d8a8784817e7b7875726f6c696663605d5a5754514e4b484542",
+			"m/0",
+			"f0909affaa7ee7abe5dd4e100598d4dc53cd709d5a5c2cac40e7412f232f7c9c",
+			"abe74a98f6c7eabee0428f53798f0ab8aa1bd37873999041703c742f15ac7e1e",
+		},
+	}
+	for _, tt := range tests {
+		seed, err := hex.DecodeString(tt.seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k, err := masterKey(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := accounts.DerivationPath{}
+		if tt.path != "m" {
+			if path, err = accounts.ParseDerivationPath(tt.path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, i := range path {
+			if k, err = k.child(i); err != nil {
+				t.Fatalf("%s: %v", tt.path, err)
+			}
+		}
+		if got := hex.EncodeToString(k.chainCode); got != tt.chainCode {
+			t.Errorf("%s: chain code %s, want %s", tt.path, got, tt.chainCode)
+		}
+		if got := hex.EncodeToString(k.key); got != tt.key {
+			t.Errorf("%s: key %s, want %s", tt.path, got, tt.key)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Package hdwallet derives the EVM wallet keys from BIP-39 mnemonics by the BIP-32/44 derivation paths,
+// the wallets keep only the seed name and the path so the keys are never stored
+package hdwallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/r1der/epos/internal/domain/entity/network"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+// RefPrefix prefixes the key references of the derived keys, the reference is the seed name
+// and the derivation path, e.g. "hd:treasury/m/44'/60'/0'/0/3"
+const RefPrefix = "hd:"
+
+var (
+	ErrUnknownKey         = errors.New("unknown key")
+	ErrUnknownSeed        = errors.New("unknown seed")
+	ErrInvalidMnemonic    = errors.New("invalid mnemonic")
+	ErrUnsupportedNetwork = errors.New("unsupported network")
+	ErrImportUnsupported  = errors.New("derived keys are not imported")
+)
+
+// Seed is the BIP-39 mnemonic with its optional passphrase
+type Seed struct {
+	Mnemonic   string
+	Passphrase string
+}
+
+// Wallet derives the keys of the named seeds
+type Wallet struct {
+	masters map[string]*extendedKey
+
+	mu   sync.Mutex
+	keys map[string]*ecdsa.PrivateKey
+}
+
+// New creates the wallet of the seeds by their names, the names are the part of the key references
+// so a seed must keep its name for the wallets derived from it
+func New(seeds map[string]Seed) (*Wallet, error) {
+	w := &Wallet{masters: make(map[string]*extendedKey, len(seeds)), keys: make(map[string]*ecdsa.PrivateKey)}
+	for name, s := range seeds {
+		if name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid seed name %q", name)
+		}
+		if !bip39.IsMnemonicValid(s.Mnemonic) {
+			return nil, fmt.Errorf("seed %s: %w", name, ErrInvalidMnemonic)
+		}
+		master, err := masterKey(bip39.NewSeed(s.Mnemonic, s.Passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("seed %s: %w", name, err)
+		}
+		w.masters[name] = master
+	}
+	return w, nil
+}
+
+// Derive derives the key of the seed at the BIP-44 address index of the first account, m/44'/60'/0'/0/index
+func (w *Wallet) Derive(_ context.Context, net, seed string, index uint32) (string, string, error) {
+	if !isEVM(net) {
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedNetwork, net)
+	}
+
+	path := make(accounts.DerivationPath, len(accounts.DefaultBaseDerivationPath))
+	copy(path, accounts.DefaultBaseDerivationPath)
+	path[len(path)-1] = index
+
+	keyRef := RefPrefix + seed + "/" + path.String()
+	key, err := w.key(keyRef)
+	if err != nil {
+		return "", "", err
+	}
+	return crypto.PubkeyToAddress(key.PublicKey).Hex(), keyRef, nil
+}
+
+// Import is not supported, the keys are derived from the seeds
+func (w *Wallet) Import(context.Context, string, string) (string, string, error) {
+	return "", "", ErrImportUnsupported
+}
+
+// Address returns the address of the key derived by the key reference
+func (w *Wallet) Address(_ context.Context, net, keyRef string) (string, error) {
+	if !isEVM(net) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedNetwork, net)
+	}
+	key, err := w.key(keyRef)
+	if err != nil {
+		return "", err
+	}
+	return crypto.PubkeyToAddress(key.PublicKey).Hex(), nil
+}
+
+// Signer returns the signer of the key derived by the key reference
+func (w *Wallet) Signer(_ context.Context, keyRef string) (uniswap.Signer, error) {
+	key, err := w.key(keyRef)
+	if err != nil {
+		return nil, err
+	}
+	return uniswap.NewSigner(hexutil.Encode(crypto.FromECDSA(key)))
+}
+
+// key derives the key by the key reference once, the derived keys are kept in memory only
+func (w *Wallet) key(keyRef string) (*ecdsa.PrivateKey, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if key, ok := w.keys[keyRef]; ok {
+		return key, nil
+	}
+
+	ref, ok := strings.CutPrefix(keyRef, RefPrefix)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid key reference %q", ErrUnknownKey, keyRef)
+	}
+	seed, rawPath, ok := strings.Cut(ref, "/")
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid key reference %q", ErrUnknownKey, keyRef)
+	}
+	master, ok := w.masters[seed]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSeed, seed)
+	}
+	path, err := accounts.ParseDerivationPath(rawPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnknownKey, err)
+	}
+
+	key, err := master.derive(path)
+	if err != nil {
+		return nil, fmt.Errorf("derive %s: %w", keyRef, err)
+	}
+	w.keys[keyRef] = key
+	return key, nil
+}
+
+func isEVM(net string) bool {
+	switch net {
+	case network.Ethereum, network.Arbitrum, network.Optimism, network.Avalanche:
+		return true
+	}
+	return false
+}
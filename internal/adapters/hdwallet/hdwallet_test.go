@@ -0,0 +1,118 @@
+package hdwallet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/r1der/epos/internal/domain/entity/network"
+)
+
+// the mnemonic of the Hardhat and Anvil development accounts
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestDerive(t *testing.T) {
+	ctx := context.Background()
+	w, err := New(map[string]Seed{
+		"dev":    {Mnemonic: testMnemonic},
+		"salted": {Mnemonic: testMnemonic, Passphrase: "salt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first development accounts, m/44'/60'/0'/0/i
+	for i, want := range []string{
+		"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC",
+	} {
+		address, keyRef, err := w.Derive(ctx, network.Ethereum, "dev", uint32(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantRef := RefPrefix + "dev/m/44'/60'/0'/0/" + strconv.Itoa(i)
+		if address != want || keyRef != wantRef {
+			t.Errorf("index %d: derived %s as %s, want %s as %s", i, address, keyRef, want, wantRef)
+		}
+		if got, err := w.Address(ctx, network.Arbitrum, keyRef); err != nil || got != want {
+			t.Errorf("index %d: address %s, %v", i, got, err)
+		}
+	}
+
+	// the passphrase derives other keys of the same mnemonic
+	salted, _, err := w.Derive(ctx, network.Ethereum, "salted", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if salted == "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266" {
+		t.Error("the passphrase is ignored")
+	}
+
+	if _, _, err = w.Derive(ctx, network.Starknet, "dev", 0); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Errorf("expected the unsupported network error, got %v", err)
+	}
+	if _, _, err = w.Derive(ctx, network.Ethereum, "other", 0); !errors.Is(err, ErrUnknownSeed) {
+		t.Errorf("expected the unknown seed error, got %v", err)
+	}
+	if _, _, err = w.Import(ctx, network.Ethereum, "0x01"); !errors.Is(err, ErrImportUnsupported) {
+		t.Errorf("expected the unsupported import error, got %v", err)
+	}
+}
+
+func TestSigner(t *testing.T) {
+	ctx := context.Background()
+	w, err := New(map[string]Seed{"dev": {Mnemonic: testMnemonic}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, keyRef, err := w.Derive(ctx, network.Ethereum, "dev", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := w.Signer(ctx, keyRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(1)
+	tx, err := s.SignTx(ctx, types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 1, Gas: 21000}), chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender.Hex() != address || s.Address() != sender {
+		t.Fatalf("signed by %s, signer of %s, want %s", sender, s.Address(), address)
+	}
+
+	for _, ref := range []string{
+		"keystore:" + address,
+		RefPrefix + "dev",
+		RefPrefix + "dev/m/44'/60'/x",
+		RefPrefix + "other/m/44'/60'/0'/0/0",
+	} {
+		if _, err = w.Signer(ctx, ref); !errors.Is(err, ErrUnknownKey) && !errors.Is(err, ErrUnknownSeed) {
+			t.Errorf("%s: expected the unknown key error, got %v", ref, err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for name, seeds := range map[string]map[string]Seed{
+		"invalid mnemonic": {"dev": {Mnemonic: "test test test"}},
+		"wrong checksum":   {"dev": {Mnemonic: "test test test test test test test test test test test test"}},
+		"empty name":       {"": {Mnemonic: testMnemonic}},
+		"name with slash":  {"dev/1": {Mnemonic: testMnemonic}},
+	} {
+		if _, err := New(seeds); err == nil {
+			t.Errorf("%s: expected the error", name)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package keyring routes the wallet key references to the stores keeping the keys,
+// e.g. the local encrypted key store and the remote signer
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+var (
+	ErrUnknownStore = errors.New("unknown key store")
+)
+
+// Store keeps the wallet keys and signs by them
+type Store interface {
+	Import(ctx context.Context, network, privateKey string) (address, keyRef string, err error)
+	Address(ctx context.Context, network, keyRef string) (string, error)
+	Signer(ctx context.Context, keyRef string) (uniswap.Signer, error)
+}
+
+// Keyring is the key store and the signers of all the configured stores
+type Keyring struct {
+	importers map[string]Store
+	stores    map[string]Store
+}
+
+// New creates the keyring, the private keys are imported into the importers by the networks
+// and the key references are routed to the stores by their prefixes, e.g. "keystore:"
+func New(importers map[string]Store, stores map[string]Store) *Keyring {
+	return &Keyring{importers: importers, stores: stores}
+}
+
+// Import imports the private key into the key store of the network
+func (k *Keyring) Import(ctx context.Context, network, privateKey string) (string, string, error) {
+	s, ok := k.importers[network]
+	if !ok {
+		return "", "", fmt.Errorf("%w: no key store imports the %s keys", ErrUnknownStore, network)
+	}
+	return s.Import(ctx, network, privateKey)
+}
+
+// Address resolves the address of the key by the store of the key reference
+func (k *Keyring) Address(ctx context.Context, network, keyRef string) (string, error) {
+	s, err := k.store(keyRef)
+	if err != nil {
+		return "", err
+	}
+	return s.Address(ctx, network, keyRef)
+}
+
+// Signer resolves the signer of the key by the store of the key reference
+func (k *Keyring) Signer(ctx context.Context, keyRef string) (uniswap.Signer, error) {
+	s, err := k.store(keyRef)
+	if err != nil {
+		return nil, err
+	}
+	return s.Signer(ctx, keyRef)
+}
+
+func (k *Keyring) store(keyRef string) (Store, error) {
+	prefix, _, found := strings.Cut(keyRef, ":")
+	if !found {
+		return nil, fmt.Errorf("%w: invalid key reference %q", ErrUnknownStore, keyRef)
+	}
+	s, ok := k.stores[prefix+":"]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownStore, prefix)
+	}
+	return s, nil
+}
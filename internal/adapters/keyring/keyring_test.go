@@ -0,0 +1,159 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/r1der/epos/internal/domain/entity/network"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+// store records the key references and the networks it is called with
+type store struct {
+	name  string
+	calls []string
+}
+
+func (s *store) Import(_ context.Context, net, _ string) (string, string, error) {
+	s.calls = append(s.calls, "import "+net)
+	return s.name, s.name + ":" + net, nil
+}
+
+func (s *store) Address(_ context.Context, net, keyRef string) (string, error) {
+	s.calls = append(s.calls, "address "+net+" "+keyRef)
+	return s.name, nil
+}
+
+func (s *store) Signer(_ context.Context, keyRef string) (uniswap.Signer, error) {
+	s.calls = append(s.calls, "signer "+keyRef)
+	return &signer{store: s.name}, nil
+}
+
+// signer tells the store which resolved it
+type signer struct {
+	store string
+}
+
+func (s *signer) Address() common.Address { return common.Address{} }
+
+func (s *signer) SignTx(_ context.Context, tx *types.Transaction, _ *big.Int) (*types.Transaction, error) {
+	return tx, nil
+}
+
+func newKeyring() (*Keyring, map[string]*store) {
+	stores := map[string]*store{}
+	for _, name := range []string{"keystore", "hd", "web3signer", "aptos", "starknet"} {
+		stores[name] = &store{name: name}
+	}
+	k := New(
+		map[string]Store{
+			network.Ethereum: stores["keystore"],
+			network.Arbitrum: stores["keystore"],
+			network.Aptos:    stores["aptos"],
+			network.Starknet: stores["starknet"],
+		},
+		map[string]Store{
+			"keystore:":   stores["keystore"],
+			"hd:":         stores["hd"],
+			"web3signer:": stores["web3signer"],
+			"aptos:":      stores["aptos"],
+			"starknet:":   stores["starknet"],
+		},
+	)
+	return k, stores
+}
+
+func TestRoute(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		keyRef, network, store string
+	}{
+		{"keystore:0x2c7536E3605D9C16a7a3D7b1898e529396a65c23", network.Ethereum, "keystore"},
+		{"hd:main/0", network.Arbitrum, "hd"},
+		{"web3signer:0x2c7536E3605D9C16a7a3D7b1898e529396a65c23", network.Ethereum, "web3signer"},
+		{"aptos:0xa11ce", network.Aptos, "aptos"},
+		{"starknet:0x5ta4", network.Starknet, "starknet"},
+	}
+	for _, tt := range tests {
+		k, stores := newKeyring()
+		address, err := k.Address(ctx, tt.network, tt.keyRef)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.keyRef, err)
+		}
+		s, err := k.Signer(ctx, tt.keyRef)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.keyRef, err)
+		}
+		if address != tt.store || s.(*signer).store != tt.store {
+			t.Errorf("%s: routed to %s and %s, want %s", tt.keyRef, address, s.(*signer).store, tt.store)
+		}
+		// only the routed store is called, with the key reference as it is
+		for name, st := range stores {
+			want := 0
+			if name == tt.store {
+				want = 2
+			}
+			if len(st.calls) != want {
+				t.Errorf("%s: store %s called %v", tt.keyRef, name, st.calls)
+			}
+		}
+		if got := stores[tt.store].calls; got[0] != "address "+tt.network+" "+tt.keyRef || got[1] != "signer "+tt.keyRef {
+			t.Errorf("%s: calls %v", tt.keyRef, got)
+		}
+	}
+}
+
+func TestRouteUnknown(t *testing.T) {
+	ctx := context.Background()
+	k, stores := newKeyring()
+	for _, keyRef := range []string{
+		"ledger:0x2c7536E3605D9C16a7a3D7b1898e529396a65c23",
+		"0x2c7536E3605D9C16a7a3D7b1898e529396a65c23",
+		// the prefix is matched as a whole and case-sensitively
+		"key:0x2c75",
+		"KEYSTORE:0x2c75",
+		"",
+	} {
+		if _, err := k.Address(ctx, network.Ethereum, keyRef); !errors.Is(err, ErrUnknownStore) {
+			t.Errorf("%q: expected the unknown store error, got %v", keyRef, err)
+		}
+		if _, err := k.Signer(ctx, keyRef); !errors.Is(err, ErrUnknownStore) {
+			t.Errorf("%q: expected the unknown store error, got %v", keyRef, err)
+		}
+	}
+	for name, st := range stores {
+		if len(st.calls) != 0 {
+			t.Errorf("store %s called %v", name, st.calls)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	for net, want := range map[string]string{
+		network.Ethereum: "keystore",
+		network.Arbitrum: "keystore",
+		network.Aptos:    "aptos",
+		network.Starknet: "starknet",
+	} {
+		k, stores := newKeyring()
+		address, keyRef, err := k.Import(ctx, net, "0x01")
+		if err != nil {
+			t.Fatalf("%s: %v", net, err)
+		}
+		if address != want || keyRef != want+":"+net || len(stores[want].calls) != 1 {
+			t.Errorf("%s: imported %s as %s into %s", net, address, keyRef, want)
+		}
+	}
+
+	// the keys of a network no store imports are rejected
+	k, _ := newKeyring()
+	if _, _, err := k.Import(ctx, network.Optimism, "0x01"); !errors.Is(err, ErrUnknownStore) {
+		t.Fatalf("expected the unknown store error, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package keystore keeps the EVM wallet keys encrypted at rest in the Web3 Secret Storage format,
+// the keys are encrypted with scrypt by the passphrase supplied at the startup
+package keystore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/r1der/epos/internal/domain/entity/network"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+// RefPrefix prefixes the key references of the keys kept by the key store, e.g. "keystore:0x5aAe...".
+const RefPrefix = "keystore:"
+
+var (
+	ErrUnknownKey         = errors.New("unknown key")
+	ErrUnsupportedNetwork = errors.New("unsupported network")
+)
+
+// KeyStore keeps the keys in the directory, one encrypted key file per key
+type KeyStore struct {
+	ks         *keystore.KeyStore
+	passphrase string
+}
+
+// Open opens the key store directory creating it when missing and unlocks all its keys with the passphrase,
+// it fails when any of the keys cannot be decrypted by the passphrase
+func Open(dir, passphrase string) (*KeyStore, error) {
+	return open(dir, passphrase, keystore.StandardScryptN, keystore.StandardScryptP)
+}
+
+// open opens the key store encrypting the imported keys by the scrypt parameters, the tests use the light ones
+func open(dir, passphrase string, scryptN, scryptP int) (*KeyStore, error) {
+	ks := keystore.NewKeyStore(dir, scryptN, scryptP)
+	for _, acc := range ks.Accounts() {
+		if err := ks.Unlock(acc, passphrase); err != nil {
+			return nil, fmt.Errorf("unlock key %s: %w", acc.Address, err)
+		}
+	}
+	return &KeyStore{ks: ks, passphrase: passphrase}, nil
+}
+
+// Import encrypts the hex encoded private key into the key store and unlocks it,
+// importing a key which is already stored returns the reference to the stored key
+func (k *KeyStore) Import(_ context.Context, net, privateKey string) (string, string, error) {
+	switch net {
+	case network.Ethereum, network.Arbitrum, network.Optimism, network.Avalanche:
+	default:
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedNetwork, net)
+	}
+
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		return "", "", fmt.Errorf("parse private key: %w", err)
+	}
+
+	acc, err := k.ks.ImportECDSA(key, k.passphrase)
+	switch {
+	case errors.Is(err, keystore.ErrAccountAlreadyExists):
+		acc = accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey)}
+	case err != nil:
+		return "", "", fmt.Errorf("import key: %w", err)
+	}
+	if err = k.ks.Unlock(acc, k.passphrase); err != nil {
+		return "", "", fmt.Errorf("unlock key %s: %w", acc.Address, err)
+	}
+
+	return acc.Address.Hex(), RefPrefix + acc.Address.Hex(), nil
+}
+
+// Address returns the address of the key referenced by the key reference
+func (k *KeyStore) Address(_ context.Context, _, keyRef string) (string, error) {
+	acc, err := k.account(keyRef)
+	if err != nil {
+		return "", err
+	}
+	return acc.Address.Hex(), nil
+}
+
+// Signer returns the signer of the key referenced by the key reference
+func (k *KeyStore) Signer(_ context.Context, keyRef string) (uniswap.Signer, error) {
+	acc, err := k.account(keyRef)
+	if err != nil {
+		return nil, err
+	}
+	return &signer{ks: k.ks, account: acc}, nil
+}
+
+func (k *KeyStore) account(keyRef string) (accounts.Account, error) {
+	hex, ok := strings.CutPrefix(keyRef, RefPrefix)
+	if !ok || !common.IsHexAddress(hex) {
+		return accounts.Account{}, fmt.Errorf("%w: invalid key reference %q", ErrUnknownKey, keyRef)
+	}
+
+	acc := accounts.Account{Address: common.HexToAddress(hex)}
+	if !k.ks.HasAddress(acc.Address) {
+		return accounts.Account{}, fmt.Errorf("%w: %s", ErrUnknownKey, acc.Address)
+	}
+	return acc, nil
+}
+
+// signer signs by the unlocked key of the key store, the key never leaves the key store
+type signer struct {
+	ks      *keystore.KeyStore
+	account accounts.Account
+}
+
+func (s *signer) Address() common.Address { return s.account.Address }
+
+func (s *signer) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.ks.SignTx(s.account, tx, chainID)
+}
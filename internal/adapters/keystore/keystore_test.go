@@ -0,0 +1,144 @@
+package keystore
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/r1der/epos/internal/domain/entity/network"
+)
+
+// the key of the Web3 Secret Storage examples and its address
+const (
+	testKey     = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+	testAddress = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+)
+
+func openLight(t *testing.T, dir, passphrase string) (*KeyStore, error) {
+	t.Helper()
+	return open(dir, passphrase, keystore.LightScryptN, keystore.LightScryptP)
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ks, err := openLight(t, dir, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address, keyRef, err := ks.Import(ctx, network.Ethereum, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != testAddress || keyRef != RefPrefix+testAddress {
+		t.Fatalf("imported %s as %s", address, keyRef)
+	}
+
+	// importing the same key again, also without the 0x prefix and for another network, returns the stored key
+	for _, net := range []string{network.Ethereum, network.Arbitrum} {
+		again, againRef, err := ks.Import(ctx, net, testKey[2:])
+		if err != nil {
+			t.Fatalf("%s: %v", net, err)
+		}
+		if again != address || againRef != keyRef {
+			t.Fatalf("%s: imported again as %s, %s", net, again, againRef)
+		}
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || len(ks.ks.Accounts()) != 1 {
+		t.Fatalf("the key is stored in %d files as %d accounts", len(files), len(ks.ks.Accounts()))
+	}
+
+	if _, _, err = ks.Import(ctx, network.Starknet, testKey); !errors.Is(err, ErrUnsupportedNetwork) {
+		t.Fatalf("expected the unsupported network error, got %v", err)
+	}
+	if _, _, err = ks.Import(ctx, network.Ethereum, "0x1234"); err == nil {
+		t.Fatal("expected the invalid key error")
+	}
+}
+
+func TestSigner(t *testing.T) {
+	ctx := context.Background()
+	ks, err := openLight(t, t.TempDir(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, keyRef, err := ks.Import(ctx, network.Ethereum, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if address, err := ks.Address(ctx, network.Ethereum, keyRef); err != nil || address != testAddress {
+		t.Fatalf("address %s, %v", address, err)
+	}
+	s, err := ks.Signer(ctx, keyRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID := big.NewInt(1)
+	tx, err := s.SignTx(ctx, types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 1, Gas: 21000}), chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != common.HexToAddress(testAddress) || s.Address() != sender {
+		t.Fatalf("signed by %s, signer of %s", sender, s.Address())
+	}
+
+	for _, ref := range []string{
+		testAddress,
+		"hd:" + testAddress,
+		RefPrefix + "0x1234",
+		RefPrefix + "0x0000000000000000000000000000000000000001",
+	} {
+		if _, err = ks.Signer(ctx, ref); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("%s: expected the unknown key error, got %v", ref, err)
+		}
+		if _, err = ks.Address(ctx, network.Ethereum, ref); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("%s: expected the unknown key error, got %v", ref, err)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ks, err := openLight(t, dir, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, keyRef, err := ks.Import(ctx, network.Ethereum, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the reopened key store unlocks the stored keys by the same passphrase
+	reopened, err := openLight(t, dir, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = reopened.Signer(ctx, keyRef); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = openLight(t, dir, "wrong"); !errors.Is(err, keystore.ErrDecrypt) {
+		t.Fatalf("expected the decryption error, got %v", err)
+	}
+	// the empty key store opens by any passphrase
+	if _, err = openLight(t, t.TempDir(), "wrong"); err != nil {
+		t.Fatal(err)
+	}
+}
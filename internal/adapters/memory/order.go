@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"strings"
+
+	"github.com/r1der/epos/internal/domain/entity/order"
+)
+
+// orderOrdering sorts the orders like the SQL store, the equal ones by their key
+var orderOrdering = ordering[*order.Order, order.OrderBy]{
+	fields: map[order.OrderBy]field[*order.Order]{
+		order.OrderByCreatedAt: {cmp: func(a, b *order.Order) int { return a.CreatedAt().Compare(b.CreatedAt()) }},
+	},
+	byDefault: order.OrderByCreatedAt,
+	key: func(a, b *order.Order) int {
+		return cmp.Or(strings.Compare(a.Pool().Network(), b.Pool().Network()), strings.Compare(a.Address(), b.Address()))
+	},
+}
+
+type orderRepository struct {
+	store *store[*order.Order, order.Snapshot]
+}
+
+// NewOrderRepository creates an in-memory order repository
+func NewOrderRepository() order.Repository {
+	return &orderRepository{store: newStore(func(o *order.Order) string {
+		return o.Pool().Network() + "/" + o.Address()
+	}, order.ErrConflict, orderSnapshot, restoreOrder)}
+}
+
+// FindOne finds the first order matching the filter
+func (r *orderRepository) FindOne(ctx context.Context, filter order.Filter) (*order.Order, error) {
+	oo, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(oo) == 0 {
+		return nil, order.ErrNotFound
+	}
+	return oo[0], nil
+}
+
+// Find finds the orders matching the filter
+func (r *orderRepository) Find(_ context.Context, filter order.Filter) ([]*order.Order, error) {
+	found := r.store.find(func(o *order.Order) bool {
+		return in(filter.Projects, o.Project(), sameProject) &&
+			in(filter.Pools, o.Pool(), samePool) &&
+			in(filter.Addresses, o.Address(), equal[string]) &&
+			in(filter.Directions, o.Direction(), equal[order.Direction]) &&
+			in(filter.TokensIn, o.AmountIn().Token(), sameToken) &&
+			in(filter.TokensOut, o.AmountOut().Token(), sameToken) &&
+			between(o.CreatedAt(), filter.CreatedFrom, filter.CreatedTo)
+	})
+	return query(found, filter.OrderBy, orderOrdering, filter.Limit, filter.Offset)
+}
+
+// Save saves the order
+func (r *orderRepository) Save(_ context.Context, o *order.Order) error {
+	return r.store.save(o)
+}
+
+func orderSnapshot(o *order.Order) order.Snapshot {
+	return detachOrder(o.Snapshot())
+}
+
+func restoreOrder(s order.Snapshot) *order.Order {
+	return order.Restore(detachOrder(s))
+}
+
+func detachOrder(s order.Snapshot) order.Snapshot {
+	s.Project = copyProject(s.Project)
+	s.Pool = copyPool(s.Pool)
+	return s
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"strings"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+// poolOrdering sorts the pools like the SQL store, the equal ones by their key
+var poolOrdering = ordering[*pool.Pool, pool.OrderBy]{
+	fields: map[pool.OrderBy]field[*pool.Pool]{
+		pool.OrderByNetwork: {cmp: func(a, b *pool.Pool) int { return strings.Compare(a.Network(), b.Network()) }},
+		pool.OrderByFee:     {cmp: func(a, b *pool.Pool) int { return cmp.Compare(a.Fee(), b.Fee()) }},
+	},
+	byDefault: pool.OrderByNetwork,
+	key: func(a, b *pool.Pool) int {
+		return cmp.Or(strings.Compare(a.Network(), b.Network()), strings.Compare(a.Protocol(), b.Protocol()),
+			strings.Compare(a.Address(), b.Address()))
+	},
+}
+
+type poolRepository struct {
+	store *store[*pool.Pool, pool.Snapshot]
+}
+
+// NewPoolRepository creates an in-memory pool repository
+func NewPoolRepository() pool.Repository {
+	return &poolRepository{store: newStore(poolKey, pool.ErrConflict, (*pool.Pool).Snapshot, pool.Restore)}
+}
+
+// FindOne finds the first pool matching the filter
+func (r *poolRepository) FindOne(ctx context.Context, filter pool.Filter) (*pool.Pool, error) {
+	pp, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(pp) == 0 {
+		return nil, pool.ErrNotFound
+	}
+	return pp[0], nil
+}
+
+// Find finds the pools matching the filter
+func (r *poolRepository) Find(_ context.Context, filter pool.Filter) ([]*pool.Pool, error) {
+	found := r.store.find(func(p *pool.Pool) bool {
+		return in(filter.Networks, p.Network(), equal[string]) &&
+			in(filter.Protocols, p.Protocol(), equal[string]) &&
+			in(filter.Addresses, p.Address(), equal[string]) &&
+			in(filter.BaseTokens, p.Pair().BaseToken(), sameToken) &&
+			in(filter.QuoteTokens, p.Pair().QuoteToken(), sameToken) &&
+			in(filter.Fees, p.Fee(), equal[values.Percent])
+	})
+	return query(found, filter.OrderBy, poolOrdering, filter.Limit, filter.Offset)
+}
+
+// Save saves the pool
+func (r *poolRepository) Save(_ context.Context, p *pool.Pool) error {
+	return r.store.save(p)
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"strings"
+
+	"github.com/r1der/epos/internal/domain/entity/position"
+)
+
+// positionOrdering sorts the positions like the SQL store, the equal ones by their key
+var positionOrdering = ordering[*position.Position, position.OrderBy]{
+	fields: map[position.OrderBy]field[*position.Position]{
+		position.OrderByCreatedAt: {cmp: func(a, b *position.Position) int { return a.CreatedAt().Compare(b.CreatedAt()) }},
+		position.OrderByClosedAt: {
+			cmp:     func(a, b *position.Position) int { return a.ClosedAt().Compare(*b.ClosedAt()) },
+			missing: func(p *position.Position) bool { return p.ClosedAt() == nil },
+		},
+	},
+	byDefault: position.OrderByCreatedAt,
+	key: func(a, b *position.Position) int {
+		return cmp.Or(strings.Compare(a.Pool().Network(), b.Pool().Network()), strings.Compare(a.Pool().Protocol(), b.Pool().Protocol()),
+			strings.Compare(a.Pool().Address(), b.Pool().Address()), strings.Compare(a.Address(), b.Address()))
+	},
+}
+
+type positionRepository struct {
+	store *store[*position.Position, position.Snapshot]
+}
+
+// NewPositionRepository creates an in-memory position repository
+func NewPositionRepository() position.Repository {
+	return &positionRepository{store: newStore(positionKey, position.ErrConflict, positionSnapshot, restorePosition)}
+}
+
+// FindOne finds the first position matching the filter
+func (r *positionRepository) FindOne(ctx context.Context, filter position.Filter) (*position.Position, error) {
+	pp, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(pp) == 0 {
+		return nil, position.ErrNotFound
+	}
+	return pp[0], nil
+}
+
+// Find finds the positions matching the filter
+func (r *positionRepository) Find(_ context.Context, filter position.Filter) ([]*position.Position, error) {
+	found := r.store.find(func(p *position.Position) bool {
+		return in(filter.Projects, p.Project(), sameProject) &&
+			in(filter.Pools, p.Pool(), samePool) &&
+			in(filter.Addresses, p.Address(), equal[string]) &&
+			in(filter.Statuses, p.Status(), equal[position.Status]) &&
+			between(p.CreatedAt(), filter.CreatedFrom, filter.CreatedTo) &&
+			betweenNullable(p.ClosedAt(), filter.ClosedFrom, filter.ClosedTo)
+	})
+	return query(found, filter.OrderBy, positionOrdering, filter.Limit, filter.Offset)
+}
+
+// Save saves the position
+func (r *positionRepository) Save(_ context.Context, p *position.Position) error {
+	return r.store.save(p)
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/project"
+)
+
+// projectOrdering sorts the projects like the SQL store, the equal ones by their key
+var projectOrdering = ordering[*project.Project, project.OrderBy]{
+	fields: map[project.OrderBy]field[*project.Project]{
+		project.OrderByName:      {cmp: func(a, b *project.Project) int { return strings.Compare(a.Name(), b.Name()) }},
+		project.OrderByCreatedAt: {cmp: func(a, b *project.Project) int { return a.CreatedAt().Compare(b.CreatedAt()) }},
+	},
+	byDefault: project.OrderByCreatedAt,
+	key:       func(a, b *project.Project) int { return strings.Compare(a.ID().String(), b.ID().String()) },
+}
+
+type projectRepository struct {
+	store *store[*project.Project, project.Snapshot]
+}
+
+// NewProjectRepository creates an in-memory project repository
+func NewProjectRepository() project.Repository {
+	return &projectRepository{store: newStore(func(p *project.Project) string { return p.ID().String() }, project.ErrConflict,
+		projectSnapshot, restoreProject)}
+}
+
+// FindOne finds the first project matching the filter
+func (r *projectRepository) FindOne(ctx context.Context, filter project.Filter) (*project.Project, error) {
+	pp, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(pp) == 0 {
+		return nil, project.ErrNotFound
+	}
+	return pp[0], nil
+}
+
+// Find finds the projects matching the filter
+func (r *projectRepository) Find(_ context.Context, filter project.Filter) ([]*project.Project, error) {
+	found := r.store.find(func(p *project.Project) bool {
+		return in(filter.Ids, p.ID(), equal[uuid.UUID]) &&
+			in(filter.Wallets, p.Wallet(), sameWallet) &&
+			in(filter.Pools, p.Pool(), samePool) &&
+			in(filter.Statuses, p.Status(), equal[project.Status]) &&
+			in(filter.Reasons, p.InactiveReason(), equal[project.InactiveReason]) &&
+			between(p.CreatedAt(), filter.CreatedFrom, filter.CreatedTo)
+	})
+	return query(found, filter.OrderBy, projectOrdering, filter.Limit, filter.Offset)
+}
+
+// Save saves the project
+func (r *projectRepository) Save(_ context.Context, p *project.Project) error {
+	return r.store.save(p)
+}
@@ -0,0 +1,21 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/r1der/epos/internal/adapters/repotest"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(*testing.T) repotest.Repositories {
+		return repotest.Repositories{
+			Tokens:    NewTokenRepository(),
+			Wallets:   NewWalletRepository(),
+			Pools:     NewPoolRepository(),
+			Projects:  NewProjectRepository(),
+			Positions: NewPositionRepository(),
+			Orders:    NewOrderRepository(),
+			Rewards:   NewRewardRepository(),
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"strings"
+
+	"github.com/r1der/epos/internal/domain/entity/reward"
+)
+
+// rewardOrdering sorts the rewards like the SQL store, the equal ones by their key
+var rewardOrdering = ordering[*reward.Reward, reward.OrderBy]{
+	fields: map[reward.OrderBy]field[*reward.Reward]{
+		reward.OrderByCreatedAt: {cmp: func(a, b *reward.Reward) int { return a.CreatedAt().Compare(b.CreatedAt()) }},
+	},
+	byDefault: reward.OrderByCreatedAt,
+	key:       func(a, b *reward.Reward) int { return strings.Compare(a.ID().String(), b.ID().String()) },
+}
+
+type rewardRepository struct {
+	store *store[*reward.Reward, reward.Snapshot]
+}
+
+// NewRewardRepository creates an in-memory reward repository
+func NewRewardRepository() reward.Repository {
+	return &rewardRepository{store: newStore(func(r *reward.Reward) string { return r.ID().String() }, reward.ErrConflict,
+		rewardSnapshot, restoreReward)}
+}
+
+// FindOne finds the first reward matching the filter
+func (r *rewardRepository) FindOne(ctx context.Context, filter reward.Filter) (*reward.Reward, error) {
+	rr, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(rr) == 0 {
+		return nil, reward.ErrNotFound
+	}
+	return rr[0], nil
+}
+
+// Find finds the rewards matching the filter
+func (r *rewardRepository) Find(_ context.Context, filter reward.Filter) ([]*reward.Reward, error) {
+	found := r.store.find(func(rw *reward.Reward) bool {
+		return in(filter.Positions, rw.Position(), samePosition) &&
+			in(filter.Tokens, rw.Amount().Token(), sameToken) &&
+			between(rw.CreatedAt(), filter.CreatedFrom, filter.CreatedTo)
+	})
+	return query(found, filter.OrderBy, rewardOrdering, filter.Limit, filter.Offset)
+}
+
+// Save saves the rewards
+func (r *rewardRepository) Save(_ context.Context, rewards ...*reward.Reward) error {
+	return r.store.save(rewards...)
+}
+
+func rewardSnapshot(r *reward.Reward) reward.Snapshot {
+	return detachReward(r.Snapshot())
+}
+
+func restoreReward(s reward.Snapshot) *reward.Reward {
+	return reward.Restore(detachReward(s))
+}
+
+func detachReward(s reward.Snapshot) reward.Snapshot {
+	s.Position = copyPosition(s.Position)
+	return s
+}
@@ -0,0 +1,278 @@
+// Package memory implements the entity repositories in memory,
+// it lets the executor run in tests, paper trading and demos without a database
+package memory
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+// versioned is an entity saved with the optimistic concurrency control
+type versioned interface {
+	Version() int
+	SetVersion(int)
+}
+
+// store keeps the snapshots of the entities in the insertion order, an entity is replaced by the one saved
+// with the same key. The entities are found as the copies restored from the snapshots, so the changes
+// of the found entities are seen by the others only once saved. The versioned entities are saved only
+// when they are based on the stored version, otherwise the save fails with the conflict error
+type store[T, S any] struct {
+	mu       sync.RWMutex
+	key      func(T) string
+	conflict error
+	snapshot func(T) S
+	restore  func(S) T
+	items    []S
+	index    map[string]int
+	versions map[string]int
+}
+
+func newStore[T, S any](key func(T) string, conflict error, snapshot func(T) S, restore func(S) T) *store[T, S] {
+	return &store[T, S]{key: key, conflict: conflict, snapshot: snapshot, restore: restore,
+		index: make(map[string]int), versions: make(map[string]int)}
+}
+
+func (s *store[T, S]) find(match func(T) bool) []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []T
+	for _, snap := range s.items {
+		if item := s.restore(snap); match(item) {
+			found = append(found, item)
+		}
+	}
+	return found
+}
+
+// save saves all the items or none of them when one of them conflicts with the stored version
+func (s *store[T, S]) save(items ...T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range items {
+		if v, ok := any(item).(versioned); ok && v.Version() != s.versions[s.key(item)] {
+			return fmt.Errorf("%w: %s is based on version %d, stored %d",
+				s.conflict, s.key(item), v.Version(), s.versions[s.key(item)])
+		}
+	}
+
+	for _, item := range items {
+		k := s.key(item)
+		if v, ok := any(item).(versioned); ok {
+			s.versions[k]++
+			v.SetVersion(s.versions[k])
+		}
+		if i, ok := s.index[k]; ok {
+			s.items[i] = s.snapshot(item)
+			continue
+		}
+		s.index[k] = len(s.items)
+		s.items = append(s.items, s.snapshot(item))
+	}
+	return nil
+}
+
+// the snapshots keep the copies of the referenced entities and the restored entities get their own copies,
+// so neither the saved nor the found entities share their references with the stored ones
+
+func copyWallet(w *wallet.Wallet) *wallet.Wallet {
+	if w == nil {
+		return nil
+	}
+	return wallet.Restore(w.Snapshot())
+}
+
+func copyPool(p *pool.Pool) *pool.Pool {
+	if p == nil {
+		return nil
+	}
+	return pool.Restore(p.Snapshot())
+}
+
+func projectSnapshot(p *project.Project) project.Snapshot {
+	return detachProject(p.Snapshot())
+}
+
+func restoreProject(s project.Snapshot) *project.Project {
+	return project.Restore(detachProject(s))
+}
+
+func detachProject(s project.Snapshot) project.Snapshot {
+	s.Wallet = copyWallet(s.Wallet)
+	s.Pool = copyPool(s.Pool)
+	return s
+}
+
+func copyProject(p *project.Project) *project.Project {
+	if p == nil {
+		return nil
+	}
+	return restoreProject(p.Snapshot())
+}
+
+func positionSnapshot(p *position.Position) position.Snapshot {
+	return detachPosition(p.Snapshot())
+}
+
+func restorePosition(s position.Snapshot) *position.Position {
+	return position.Restore(detachPosition(s))
+}
+
+func detachPosition(s position.Snapshot) position.Snapshot {
+	s.Project = copyProject(s.Project)
+	s.Pool = copyPool(s.Pool)
+	return s
+}
+
+func copyPosition(p *position.Position) *position.Position {
+	if p == nil {
+		return nil
+	}
+	return restorePosition(p.Snapshot())
+}
+
+// identity keeps the immutable entities as they are, e.g. the tokens
+func identity[T any](v T) T { return v }
+
+// in reports whether the value matches one of the filter values, an empty filter matches any value
+func in[T any](filter []T, v T, eq func(T, T) bool) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if eq(f, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// between reports whether the time is in [from, to), a zero bound is unbounded
+func between(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
+
+// betweenNullable is between for an optional time, a missing time matches the unbounded range only
+func betweenNullable(t *time.Time, from, to time.Time) bool {
+	if t == nil {
+		return from.IsZero() && to.IsZero()
+	}
+	return between(*t, from, to)
+}
+
+// field compares the entities by one of their fields
+type field[T any] struct {
+	cmp func(a, b T) int
+	// missing reports the entities without the field value, they go last in both orders
+	missing func(T) bool
+}
+
+// ordering sorts the entities the way the SQL store does: by the order by field, by the default field when
+// the order by is empty, and the entities equal by the field by their key
+type ordering[T any, O ~string] struct {
+	fields    map[O]field[T]
+	byDefault O
+	key       func(a, b T) int
+}
+
+// sorted sorts the entities by the order by field, the "-" prefix sorts in the descending order
+func sorted[T any, O ~string](items []T, orderBy O, o ordering[T, O]) ([]T, error) {
+	if orderBy == "" {
+		orderBy = o.byDefault
+	}
+	name, desc := strings.CutPrefix(string(orderBy), "-")
+	f, ok := o.fields[O(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported order by: %s", orderBy)
+	}
+	slices.SortFunc(items, func(a, b T) int {
+		if f.missing != nil {
+			ma, mb := f.missing(a), f.missing(b)
+			if ma || mb {
+				return cmp.Or(boolCmp(ma, mb), o.key(a, b))
+			}
+		}
+		c := f.cmp(a, b)
+		if desc {
+			c = -c
+		}
+		return cmp.Or(c, o.key(a, b))
+	})
+	return items, nil
+}
+
+func boolCmp(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// paginate returns the page of the sorted entities, the zero limit means no limit
+func paginate[T any](items []T, limit, offset int) ([]T, error) {
+	if limit < 0 || offset < 0 {
+		return nil, errors.New("negative limit or offset")
+	}
+	if offset >= len(items) {
+		return nil, nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// query sorts and paginates the found entities
+func query[T any, O ~string](items []T, orderBy O, o ordering[T, O], limit, offset int) ([]T, error) {
+	items, err := sorted(items, orderBy, o)
+	if err != nil {
+		return nil, err
+	}
+	return paginate(items, limit, offset)
+}
+
+func equal[T comparable](a, b T) bool { return a == b }
+
+func sameToken(a, b *token.Token) bool { return a.Eq(b) }
+
+func samePool(a, b *pool.Pool) bool { return poolKey(a) == poolKey(b) }
+
+func sameProject(a, b *project.Project) bool { return a.ID() == b.ID() }
+
+func samePosition(a, b *position.Position) bool { return positionKey(a) == positionKey(b) }
+
+func sameWallet(a, b *wallet.Wallet) bool { return walletKey(a) == walletKey(b) }
+
+func poolKey(p *pool.Pool) string {
+	return p.Network() + "/" + p.Protocol() + "/" + p.Address()
+}
+
+func positionKey(p *position.Position) string {
+	return poolKey(p.Pool()) + "/" + p.Address()
+}
+
+func tokenKey(t *token.Token) string {
+	return t.Network() + "/" + t.Address()
+}
+
+func walletKey(w *wallet.Wallet) string {
+	return w.NetworkId() + "/" + w.Address()
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+func testProject() *project.Project {
+	weth := token.New("eth", "0xa", "WETH", 18)
+	usdc := token.New("eth", "0xb", "USDC", 6)
+	pair := token.NewPair(weth, usdc)
+	return project.Restore(project.Snapshot{
+		ID:          uuid.New(),
+		Wallet:      wallet.Restore(wallet.Snapshot{Name: "main", Network: "eth", Address: "0xw", NativeToken: weth}),
+		Pool:        pool.Restore(pool.Snapshot{Network: "eth", Protocol: "uniswap", Address: "0xp", Pair: pair, LastPrice: values.NewPrice(pair, 2000)}),
+		Name:        "eth/usdc",
+		Investments: values.NewAmount(usdc, 1000),
+		Status:      project.Active,
+		CreatedAt:   time.Now(),
+	})
+}
+
+func TestStoreConflict(t *testing.T) {
+	ctx := context.Background()
+	repo := NewProjectRepository()
+
+	p := testProject()
+	if err := repo.Save(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Version() != 1 {
+		t.Fatalf("saved version %d", p.Version())
+	}
+
+	a, err := repo.FindOne(ctx, project.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := repo.FindOne(ctx, project.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b || a == p {
+		t.Fatal("the found projects share the instance")
+	}
+
+	renamed := a.Snapshot()
+	renamed.Name = "renamed"
+	if err = repo.Save(ctx, project.Restore(renamed)); err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Save(ctx, b); !errors.Is(err, project.ErrConflict) {
+		t.Fatalf("expected the conflict, got %v", err)
+	}
+	if b.Name() != "eth/usdc" {
+		t.Fatalf("the found project changed by the save of another copy: %s", b.Name())
+	}
+
+	stored, err := repo.FindOne(ctx, project.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name() != "renamed" || stored.Version() != 2 {
+		t.Fatalf("stored %s at version %d", stored.Name(), stored.Version())
+	}
+}
+
+func TestStoreIsolation(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPositionRepository()
+
+	proj := testProject()
+	pos := position.Restore(position.Snapshot{Project: proj, Pool: proj.Pool(), Address: "1", Status: position.Open})
+	if err := repo.Save(ctx, pos); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := repo.FindOne(ctx, position.Filter{Projects: []*project.Project{proj}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found == pos || found.Project() == proj || found.Pool() == proj.Pool() {
+		t.Fatal("the found position shares the saved instances")
+	}
+	if found.Project().ID() != proj.ID() || found.Version() != 1 {
+		t.Fatalf("found project %s at version %d", found.Project().ID(), found.Version())
+	}
+
+	// the found copies do not change the stored position until saved
+	found.SetVersion(5)
+	again, err := repo.FindOne(ctx, position.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Version() != 1 {
+		t.Fatalf("stored version %d", again.Version())
+	}
+}
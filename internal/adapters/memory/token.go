@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"strings"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+)
+
+// tokenOrdering sorts the tokens like the SQL store, the equal ones by their key
+var tokenOrdering = ordering[*token.Token, token.OrderBy]{
+	fields: map[token.OrderBy]field[*token.Token]{
+		token.OrderByNetwork: {cmp: func(a, b *token.Token) int { return strings.Compare(a.Network(), b.Network()) }},
+		token.OrderByTicker:  {cmp: func(a, b *token.Token) int { return strings.Compare(a.Ticker().String(), b.Ticker().String()) }},
+	},
+	byDefault: token.OrderByNetwork,
+	key: func(a, b *token.Token) int {
+		return cmp.Or(strings.Compare(a.Network(), b.Network()), strings.Compare(a.Address(), b.Address()))
+	},
+}
+
+type tokenRepository struct {
+	store *store[*token.Token, *token.Token]
+}
+
+// NewTokenRepository creates an in-memory token repository
+func NewTokenRepository() token.Repository {
+	return &tokenRepository{store: newStore(tokenKey, nil, identity[*token.Token], identity[*token.Token])}
+}
+
+// FindOne finds the first token matching the filter
+func (r *tokenRepository) FindOne(ctx context.Context, filter token.Filter) (*token.Token, error) {
+	tt, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(tt) == 0 {
+		return nil, token.ErrNotFound
+	}
+	return tt[0], nil
+}
+
+// Find finds the tokens matching the filter
+func (r *tokenRepository) Find(_ context.Context, filter token.Filter) ([]*token.Token, error) {
+	found := r.store.find(func(t *token.Token) bool {
+		return in(filter.Networks, t.Network(), equal[string]) &&
+			in(filter.Addresses, t.Address(), equal[string]) &&
+			in(filter.Tickers, t.Ticker(), equal[token.Ticker])
+	})
+	return query(found, filter.OrderBy, tokenOrdering, filter.Limit, filter.Offset)
+}
+
+// Save saves the token
+func (r *tokenRepository) Save(_ context.Context, t *token.Token) error {
+	return r.store.save(t)
+}
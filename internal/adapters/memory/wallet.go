@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"strings"
+
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+// walletOrdering sorts the wallets like the SQL store, the equal ones by their key
+var walletOrdering = ordering[*wallet.Wallet, wallet.OrderBy]{
+	fields: map[wallet.OrderBy]field[*wallet.Wallet]{
+		wallet.OrderByName:      {cmp: func(a, b *wallet.Wallet) int { return strings.Compare(a.Name(), b.Name()) }},
+		wallet.OrderByCreatedAt: {cmp: func(a, b *wallet.Wallet) int { return a.CreatedAt().Compare(b.CreatedAt()) }},
+	},
+	byDefault: wallet.OrderByCreatedAt,
+	key: func(a, b *wallet.Wallet) int {
+		return cmp.Or(strings.Compare(a.NetworkId(), b.NetworkId()), strings.Compare(a.Address(), b.Address()))
+	},
+}
+
+type walletRepository struct {
+	store *store[*wallet.Wallet, wallet.Snapshot]
+}
+
+// NewWalletRepository creates an in-memory wallet repository
+func NewWalletRepository() wallet.Repository {
+	return &walletRepository{store: newStore(walletKey, wallet.ErrConflict, (*wallet.Wallet).Snapshot, wallet.Restore)}
+}
+
+// FindOne finds the first wallet matching the filter
+func (r *walletRepository) FindOne(ctx context.Context, filter wallet.Filter) (*wallet.Wallet, error) {
+	ww, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(ww) == 0 {
+		return nil, wallet.ErrNotFound
+	}
+	return ww[0], nil
+}
+
+// Find finds the wallets matching the filter
+func (r *walletRepository) Find(_ context.Context, filter wallet.Filter) ([]*wallet.Wallet, error) {
+	found := r.store.find(func(w *wallet.Wallet) bool {
+		return in(filter.Networks, w.NetworkId(), equal[string]) &&
+			in(filter.Addresses, w.Address(), equal[string]) &&
+			between(w.CreatedAt(), filter.CreatedFrom, filter.CreatedTo)
+	})
+	return query(found, filter.OrderBy, walletOrdering, filter.Limit, filter.Offset)
+}
+
+// Save saves the wallet
+func (r *walletRepository) Save(_ context.Context, w *wallet.Wallet) error {
+	return r.store.save(w)
+}
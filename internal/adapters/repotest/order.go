@@ -0,0 +1,83 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/r1der/epos/internal/domain/entity/order"
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+func testOrders(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	f := newFixture(t, r)
+	eth := f.project("eth", f.main, f.ethPool, createdAt)
+	arb := f.project("arb", f.arb, f.arbPool, createdAt)
+	saveProjects(t, r, eth, arb)
+
+	// newOrder creates the order of the project swapping the quote token for the base one or the other way round
+	newOrder := func(proj *project.Project, address string, direction order.Direction, createdAt time.Time) *order.Order {
+		pair := proj.Pool().Pair()
+		in, out := values.NewAmount(pair.QuoteToken(), 2000), values.NewAmount(pair.BaseToken(), 1)
+		if direction == order.Sell {
+			in, out = values.NewAmount(pair.BaseToken(), 1), values.NewAmount(pair.QuoteToken(), 2000)
+		}
+		return order.Restore(order.Snapshot{
+			Project: proj, Pool: proj.Pool(), Address: address, Direction: direction, AmountIn: in, AmountOut: out,
+			FilledPrice: values.NewPrice(pair, 2000), TransactionFee: values.NewAmount(pair.BaseToken(), 1), CreatedAt: createdAt,
+		})
+	}
+	for _, o := range []*order.Order{
+		newOrder(eth, "0x4", order.Buy, createdAt.Add(2*time.Minute)),
+		newOrder(eth, "0x3", order.Buy, createdAt.Add(2*time.Minute)),
+		newOrder(arb, "0x2", order.Buy, createdAt.Add(time.Minute)),
+		newOrder(eth, "0x1", order.Sell, createdAt),
+	} {
+		if err := r.Orders.Save(ctx, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	label := func(o *order.Order) string { return o.Address() }
+	checkFind(t, r.Orders.Find, label, []findCase[order.Filter]{
+		// the orders are sorted by the creation time, the ones created at once by their network and address
+		{"all", order.Filter{}, []string{"0x1", "0x2", "0x3", "0x4"}},
+		{"project", order.Filter{Projects: []*project.Project{arb}}, []string{"0x2"}},
+		{"pool", order.Filter{Pools: []*pool.Pool{f.ethPool}}, []string{"0x1", "0x3", "0x4"}},
+		{"addresses", order.Filter{Addresses: []string{"0x4", "0x2"}}, []string{"0x2", "0x4"}},
+		{"direction", order.Filter{Directions: []order.Direction{order.Sell}}, []string{"0x1"}},
+		{"token in", order.Filter{TokensIn: []*token.Token{f.ethUSDC}}, []string{"0x3", "0x4"}},
+		{"token out", order.Filter{TokensOut: []*token.Token{f.arbWETH}}, []string{"0x2"}},
+		{"tokens in", order.Filter{TokensIn: []*token.Token{f.ethWETH, f.arbUSDC}}, []string{"0x1", "0x2"}},
+		{"project and direction", order.Filter{Projects: []*project.Project{eth}, Directions: []order.Direction{order.Buy}}, []string{"0x3", "0x4"}},
+		{"created", order.Filter{CreatedFrom: createdAt.Add(time.Minute), CreatedTo: createdAt.Add(2 * time.Minute)}, []string{"0x2"}},
+		{"created from", order.Filter{CreatedFrom: createdAt.Add(time.Minute)}, []string{"0x2", "0x3", "0x4"}},
+		{"by created", order.Filter{OrderBy: order.OrderByCreatedAt}, []string{"0x1", "0x2", "0x3", "0x4"}},
+		{"by created desc", order.Filter{OrderBy: order.OrderByCreatedAtDesc}, []string{"0x3", "0x4", "0x2", "0x1"}},
+		{"page", order.Filter{Limit: 1, Offset: 2}, []string{"0x3"}},
+		{"sorted page", order.Filter{OrderBy: order.OrderByCreatedAtDesc, Limit: 2, Offset: 1}, []string{"0x4", "0x2"}},
+		{"past the end", order.Filter{Offset: 4}, []string{}},
+	})
+	checkInvalid(t, r.Orders.Find, map[string]order.Filter{
+		"negative limit":  {Limit: -1},
+		"negative offset": {Offset: -1},
+		"unsupported":     {OrderBy: "direction"},
+	})
+
+	stored, err := r.Orders.FindOne(ctx, order.Filter{Projects: []*project.Project{eth}, OrderBy: order.OrderByCreatedAtDesc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Address() != "0x3" || !stored.IsBuy() || !stored.AmountIn().Token().Eq(f.ethUSDC) || stored.AmountIn().Value().Int64() != 2000 ||
+		stored.Project().ID() != eth.ID() {
+		t.Fatalf("unexpected stored order %+v", stored.Snapshot())
+	}
+	if _, err = r.Orders.FindOne(ctx, order.Filter{Projects: []*project.Project{arb}, Directions: []order.Direction{order.Sell}}); !errors.Is(err, order.ErrNotFound) {
+		t.Fatalf("expected the not found error, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+func testPools(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	f := newFixture(t, r)
+	// the medium fee pool has the reversed pair, it quotes USDC in WETH
+	sushi := f.pool("eth", "sushiswap", "0xe9", pool.HighFee, token.NewPair(f.ethWETH, f.ethUSDC))
+	medium := f.pool("eth", "uniswap", "0xe3", pool.MediumFee, token.NewPair(f.ethUSDC, f.ethWETH))
+	for _, p := range []*pool.Pool{sushi, medium} {
+		if err := r.Pools.Save(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	label := func(p *pool.Pool) string { return p.Address() }
+	checkFind(t, r.Pools.Find, label, []findCase[pool.Filter]{
+		// the pools are sorted by the network and then by the protocol and the address
+		{"all", pool.Filter{}, []string{"0xa5", "0xe9", "0xe3", "0xe5"}},
+		{"network", pool.Filter{Networks: []string{"eth"}}, []string{"0xe9", "0xe3", "0xe5"}},
+		{"protocol", pool.Filter{Protocols: []string{"uniswap"}}, []string{"0xa5", "0xe3", "0xe5"}},
+		{"addresses", pool.Filter{Addresses: []string{"0xe5", "0xa5"}}, []string{"0xa5", "0xe5"}},
+		{"base token", pool.Filter{BaseTokens: []*token.Token{f.ethWETH}}, []string{"0xe9", "0xe5"}},
+		{"quote token", pool.Filter{QuoteTokens: []*token.Token{f.ethWETH}}, []string{"0xe3"}},
+		{"base token of other network", pool.Filter{BaseTokens: []*token.Token{f.arbWETH}}, []string{"0xa5"}},
+		{"fee", pool.Filter{Fees: []values.Percent{pool.LowFee}}, []string{"0xa5", "0xe5"}},
+		{"fees", pool.Filter{Fees: []values.Percent{pool.MediumFee, pool.HighFee}}, []string{"0xe9", "0xe3"}},
+		{"protocol and fee", pool.Filter{Protocols: []string{"uniswap"}, Fees: []values.Percent{pool.HighFee}}, []string{}},
+		{"by network", pool.Filter{OrderBy: pool.OrderByNetwork}, []string{"0xa5", "0xe9", "0xe3", "0xe5"}},
+		{"by fee", pool.Filter{OrderBy: pool.OrderByFee}, []string{"0xa5", "0xe5", "0xe3", "0xe9"}},
+		// the pools of the same fee keep their key order in both orders
+		{"by fee desc", pool.Filter{OrderBy: pool.OrderByFeeDesc}, []string{"0xe9", "0xe3", "0xa5", "0xe5"}},
+		{"page", pool.Filter{Limit: 2, Offset: 1}, []string{"0xe9", "0xe3"}},
+		{"sorted page", pool.Filter{OrderBy: pool.OrderByFee, Limit: 2, Offset: 1}, []string{"0xe5", "0xe3"}},
+		{"past the end", pool.Filter{Offset: 4}, []string{}},
+	})
+	checkInvalid(t, r.Pools.Find, map[string]pool.Filter{
+		"negative limit":  {Limit: -1},
+		"negative offset": {Offset: -1},
+		"unsupported":     {OrderBy: "address"},
+	})
+
+	stored, err := r.Pools.FindOne(ctx, pool.Filter{Networks: []string{"eth"}, OrderBy: pool.OrderByFee})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Address() != "0xe5" || stored.Fee() != pool.LowFee || !stored.Pair().BaseToken().Eq(f.ethWETH) ||
+		!stored.Pair().QuoteToken().Eq(f.ethUSDC) {
+		t.Fatalf("unexpected stored pool %+v", stored.Snapshot())
+	}
+	if _, err = r.Pools.FindOne(ctx, pool.Filter{Protocols: []string{"curve"}}); !errors.Is(err, pool.ErrNotFound) {
+		t.Fatalf("expected the not found error, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+)
+
+func testPositions(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	f := newFixture(t, r)
+	eth := f.project("eth", f.main, f.ethPool, createdAt)
+	arb := f.project("arb", f.arb, f.arbPool, createdAt)
+	saveProjects(t, r, eth, arb)
+
+	// the positions of the different pools share the address
+	closedAt1, closedAt2 := createdAt.Add(time.Hour), createdAt.Add(2*time.Hour)
+	open := f.position(eth, "3", createdAt.Add(time.Minute), nil)
+	late := f.position(eth, "1", createdAt.Add(time.Minute), &closedAt2)
+	early := f.position(eth, "2", createdAt.Add(2*time.Minute), &closedAt1)
+	other := f.position(arb, "1", createdAt, nil)
+	savePositions(t, r, open, other, early, late)
+
+	label := func(p *position.Position) string { return p.Pool().Network() + ":" + p.Address() }
+	checkFind(t, r.Positions.Find, label, []findCase[position.Filter]{
+		// the positions are sorted by the creation time, the ones created at once by their pool and address
+		{"all", position.Filter{}, []string{"arb:1", "eth:1", "eth:3", "eth:2"}},
+		{"project", position.Filter{Projects: []*project.Project{eth}}, []string{"eth:1", "eth:3", "eth:2"}},
+		{"pool", position.Filter{Pools: []*pool.Pool{f.arbPool}}, []string{"arb:1"}},
+		{"address", position.Filter{Addresses: []string{"1"}}, []string{"arb:1", "eth:1"}},
+		{"pool and address", position.Filter{Pools: []*pool.Pool{f.ethPool}, Addresses: []string{"1"}}, []string{"eth:1"}},
+		{"status", position.Filter{Statuses: []position.Status{position.Closed}}, []string{"eth:1", "eth:2"}},
+		{"created", position.Filter{CreatedFrom: createdAt.Add(time.Minute), CreatedTo: createdAt.Add(2 * time.Minute)}, []string{"eth:1", "eth:3"}},
+		// the open positions match the unbounded closing time only
+		{"closed from", position.Filter{ClosedFrom: closedAt2}, []string{"eth:1"}},
+		{"closed before", position.Filter{ClosedTo: closedAt2}, []string{"eth:2"}},
+		{"by created desc", position.Filter{OrderBy: position.OrderByCreatedAtDesc}, []string{"eth:2", "eth:1", "eth:3", "arb:1"}},
+		// the open positions go last in both orders
+		{"by closed", position.Filter{OrderBy: position.OrderByClosedAt}, []string{"eth:2", "eth:1", "arb:1", "eth:3"}},
+		{"by closed desc", position.Filter{OrderBy: position.OrderByClosedAtDesc}, []string{"eth:1", "eth:2", "arb:1", "eth:3"}},
+		{"page", position.Filter{Limit: 2, Offset: 1}, []string{"eth:1", "eth:3"}},
+		{"sorted page", position.Filter{OrderBy: position.OrderByClosedAt, Limit: 2, Offset: 1}, []string{"eth:1", "arb:1"}},
+		{"past the end", position.Filter{Limit: 1, Offset: 4}, []string{}},
+	})
+	checkInvalid(t, r.Positions.Find, map[string]position.Filter{
+		"negative limit":  {Limit: -1},
+		"negative offset": {Offset: -1},
+		"unsupported":     {OrderBy: "status"},
+	})
+
+	stored, err := r.Positions.FindOne(ctx, position.Filter{Projects: []*project.Project{eth}, OrderBy: position.OrderByClosedAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Address() != "2" || stored.Status() != position.Closed || stored.ClosedAt() == nil || !stored.ClosedAt().Equal(closedAt1) ||
+		stored.Project().ID() != eth.ID() {
+		t.Fatalf("unexpected stored position %+v", stored.Snapshot())
+	}
+	if _, err = r.Positions.FindOne(ctx, position.Filter{Projects: []*project.Project{arb}, Statuses: []position.Status{position.Closed}}); !errors.Is(err, position.ErrNotFound) {
+		t.Fatalf("expected the not found error, got %v", err)
+	}
+}
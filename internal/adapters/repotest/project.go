@@ -0,0 +1,68 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+func testProjects(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	f := newFixture(t, r)
+
+	inactive := func(p *project.Project, reason project.InactiveReason) *project.Project {
+		snap := p.Snapshot()
+		snap.Status, snap.InactiveReason = project.Inactive, reason
+		return project.Restore(snap)
+	}
+	a := f.project("a", f.main, f.ethPool, createdAt.Add(time.Hour))
+	b := f.project("b", f.main, f.ethPool, createdAt)
+	c := inactive(f.project("c", f.arb, f.arbPool, createdAt.Add(2*time.Hour)), project.StopLoss)
+	d := inactive(f.project("d", f.main, f.ethPool, createdAt.Add(3*time.Hour)), project.NotEnoughGas)
+	saveProjects(t, r, c, a, d, b)
+
+	label := func(p *project.Project) string { return p.Name() }
+	checkFind(t, r.Projects.Find, label, []findCase[project.Filter]{
+		// the projects are sorted by the creation time
+		{"all", project.Filter{}, []string{"b", "a", "c", "d"}},
+		{"ids", project.Filter{Ids: []uuid.UUID{d.ID(), b.ID()}}, []string{"b", "d"}},
+		{"wallet", project.Filter{Wallets: []*wallet.Wallet{f.main}}, []string{"b", "a", "d"}},
+		{"pool", project.Filter{Pools: []*pool.Pool{f.arbPool}}, []string{"c"}},
+		{"status", project.Filter{Statuses: []project.Status{project.Inactive}}, []string{"c", "d"}},
+		{"reason", project.Filter{Reasons: []project.InactiveReason{project.StopLoss}}, []string{"c"}},
+		{"empty reason", project.Filter{Reasons: []project.InactiveReason{project.EmptyReason}}, []string{"b", "a"}},
+		{"status and wallet", project.Filter{Statuses: []project.Status{project.Inactive}, Wallets: []*wallet.Wallet{f.arb}}, []string{"c"}},
+		{"created", project.Filter{CreatedFrom: createdAt.Add(time.Hour), CreatedTo: createdAt.Add(3 * time.Hour)}, []string{"a", "c"}},
+		{"unknown id", project.Filter{Ids: []uuid.UUID{uuid.New()}}, []string{}},
+		{"by name", project.Filter{OrderBy: project.OrderByName}, []string{"a", "b", "c", "d"}},
+		{"by created", project.Filter{OrderBy: project.OrderByCreatedAt}, []string{"b", "a", "c", "d"}},
+		{"by created desc", project.Filter{OrderBy: project.OrderByCreatedAtDesc}, []string{"d", "c", "a", "b"}},
+		{"page", project.Filter{Limit: 2, Offset: 1}, []string{"a", "c"}},
+		{"sorted page", project.Filter{OrderBy: project.OrderByName, Limit: 3, Offset: 2}, []string{"c", "d"}},
+		{"past the end", project.Filter{Offset: 4}, []string{}},
+	})
+	checkInvalid(t, r.Projects.Find, map[string]project.Filter{
+		"negative limit":  {Limit: -1},
+		"negative offset": {Offset: -1},
+		"unsupported":     {OrderBy: "status"},
+	})
+
+	stored, err := r.Projects.FindOne(ctx, project.Filter{Wallets: []*wallet.Wallet{f.main}, OrderBy: project.OrderByCreatedAtDesc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ID() != d.ID() || stored.InactiveReason() != project.NotEnoughGas || stored.Pool().Address() != f.ethPool.Address() ||
+		stored.Wallet().Address() != f.main.Address() || !stored.CreatedAt().Equal(d.CreatedAt()) {
+		t.Fatalf("unexpected stored project %+v", stored.Snapshot())
+	}
+	if _, err = r.Projects.FindOne(ctx, project.Filter{Reasons: []project.InactiveReason{project.TakeProfit}}); !errors.Is(err, project.ErrNotFound) {
+		t.Fatalf("expected the not found error, got %v", err)
+	}
+}
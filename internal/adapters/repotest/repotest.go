@@ -0,0 +1,192 @@
+// Package repotest holds the contract tests of the entity repositories, every store runs them
+// so the entities are found, sorted and paginated the same way whichever store keeps them
+package repotest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/order"
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/reward"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+// Repositories are the repositories of the store under test
+type Repositories struct {
+	Tokens    token.Repository
+	Wallets   wallet.Repository
+	Pools     pool.Repository
+	Projects  project.Repository
+	Positions position.Repository
+	Orders    order.Repository
+	Rewards   reward.Repository
+}
+
+// Run runs the contract tests of every repository, each of them on the empty repositories of open
+func Run(t *testing.T, open func(t *testing.T) Repositories) {
+	t.Run("tokens", func(t *testing.T) { testTokens(t, open(t)) })
+	t.Run("wallets", func(t *testing.T) { testWallets(t, open(t)) })
+	t.Run("pools", func(t *testing.T) { testPools(t, open(t)) })
+	t.Run("projects", func(t *testing.T) { testProjects(t, open(t)) })
+	t.Run("positions", func(t *testing.T) { testPositions(t, open(t)) })
+	t.Run("orders", func(t *testing.T) { testOrders(t, open(t)) })
+	t.Run("rewards", func(t *testing.T) { testRewards(t, open(t)) })
+}
+
+// createdAt is the creation time of the earliest entities, the stores keep the times to the second
+var createdAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// findCase is the filter and the labels of the entities it finds in their order
+type findCase[F any] struct {
+	name   string
+	filter F
+	want   []string
+}
+
+// checkFind finds the entities of every case and compares their labels in the found order
+func checkFind[T, F any](t *testing.T, find func(context.Context, F) ([]T, error), label func(T) string, tests []findCase[F]) {
+	t.Helper()
+	for _, tt := range tests {
+		found, err := find(context.Background(), tt.filter)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		got := make([]string, len(found))
+		for i, v := range found {
+			got[i] = label(v)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// checkInvalid expects every filter to fail the find
+func checkInvalid[T, F any](t *testing.T, find func(context.Context, F) ([]T, error), filters map[string]F) {
+	t.Helper()
+	for name, filter := range filters {
+		if _, err := find(context.Background(), filter); err == nil {
+			t.Errorf("%s: expected the invalid filter error", name)
+		}
+	}
+}
+
+// fixture holds the saved entities the tested entities refer to
+type fixture struct {
+	ethWETH, ethUSDC, arbWETH, arbUSDC *token.Token
+	// main is the eth wallet created first, arb is the arb wallet created two hours later
+	main, arb *wallet.Wallet
+	// ethPool and arbPool are the low fee WETH/USDC pools
+	ethPool, arbPool *pool.Pool
+}
+
+func newFixture(t *testing.T, r Repositories) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	f := &fixture{
+		ethWETH: token.New("eth", "0xc02a", "WETH", 18),
+		ethUSDC: token.New("eth", "0xa0b8", "USDC", 6),
+		arbWETH: token.New("arb", "0x82af", "WETH", 18),
+		arbUSDC: token.New("arb", "0xaf88", "USDC", 6),
+	}
+	// the tokens are saved out of their order
+	for _, tk := range []*token.Token{f.ethWETH, f.arbUSDC, f.ethUSDC, f.arbWETH} {
+		if err := r.Tokens.Save(ctx, tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.main = f.wallet("main", "eth", "0xe1", f.ethWETH, createdAt)
+	f.arb = f.wallet("arb", "arb", "0xa1", f.arbWETH, createdAt.Add(2*time.Hour))
+	for _, w := range []*wallet.Wallet{f.main, f.arb} {
+		if err := r.Wallets.Save(ctx, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.ethPool = f.pool("eth", "uniswap", "0xe5", pool.LowFee, token.NewPair(f.ethWETH, f.ethUSDC))
+	f.arbPool = f.pool("arb", "uniswap", "0xa5", pool.LowFee, token.NewPair(f.arbWETH, f.arbUSDC))
+	for _, p := range []*pool.Pool{f.ethPool, f.arbPool} {
+		if err := r.Pools.Save(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+func (f *fixture) wallet(name, network, address string, native *token.Token, createdAt time.Time) *wallet.Wallet {
+	return wallet.Restore(wallet.Snapshot{
+		Name: name, Network: network, Address: address, KeyRef: "keystore:" + address, NativeToken: native, CreatedAt: createdAt,
+	})
+}
+
+func (f *fixture) pool(network, protocol, address string, fee values.Percent, pair *token.Pair) *pool.Pool {
+	return pool.Restore(pool.Snapshot{
+		Network: network, Protocol: protocol, Address: address, Fee: fee, Pair: pair, LastPrice: values.NewPrice(pair, 2000),
+	})
+}
+
+// project creates an active project of the wallet investing 1000 of the pool quote token
+func (f *fixture) project(name string, w *wallet.Wallet, p *pool.Pool, createdAt time.Time) *project.Project {
+	base, quote := p.Pair().BaseToken(), p.Pair().QuoteToken()
+	return project.Restore(project.Snapshot{
+		ID: uuid.New(), Wallet: w, Pool: p, Name: name,
+		Investments:     values.NewAmount(quote, 1000),
+		CurrentValue:    values.NewAmount(quote, 1000),
+		PeakValue:       values.NewAmount(quote, 1000),
+		IdleBase:        values.NewAmount(base, 0),
+		IdleQuote:       values.NewAmount(quote, 1000),
+		RangeVolatility: 0.05,
+		Slippage:        0.01,
+		ActivePositions: 1,
+		Status:          project.Active,
+		CreatedAt:       createdAt,
+	})
+}
+
+// position creates a position of the project in its pool, the position is closed when closedAt is set
+func (f *fixture) position(proj *project.Project, address string, createdAt time.Time, closedAt *time.Time) *position.Position {
+	p := proj.Pool()
+	base, quote := p.Pair().BaseToken(), p.Pair().QuoteToken()
+	status := position.Open
+	if closedAt != nil {
+		status = position.Closed
+	}
+	return position.Restore(position.Snapshot{
+		Project: proj, Pool: p, Address: address,
+		LowerPrice: values.NewPrice(p.Pair(), 1900), UpperPrice: values.NewPrice(p.Pair(), 2100),
+		InitialPrice: values.NewPrice(p.Pair(), 2000), CurrentPrice: values.NewPrice(p.Pair(), 2000),
+		LowerTick: -100, UpperTick: 100, Liquidity: values.NewAmount(base, 1000).Value(),
+		InBaseAmount: values.NewAmount(base, 100), InQuoteAmount: values.NewAmount(quote, 200),
+		OutBaseAmount: values.NewAmount(base, 0), OutQuoteAmount: values.NewAmount(quote, 0),
+		CurrentBaseAmount: values.NewAmount(base, 100), CurrentQuoteAmount: values.NewAmount(quote, 200),
+		CurrentBaseAccruedFees: values.NewAmount(base, 0), CurrentQuoteAccruedFees: values.NewAmount(quote, 0),
+		TransactionFee: values.NewAmount(base, 1), Status: status, CreatedAt: createdAt, ClosedAt: closedAt,
+	})
+}
+
+func saveProjects(t *testing.T, r Repositories, pp ...*project.Project) {
+	t.Helper()
+	for _, p := range pp {
+		if err := r.Projects.Save(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func savePositions(t *testing.T, r Repositories, pp ...*position.Position) {
+	t.Helper()
+	for _, p := range pp {
+		if err := r.Positions.Save(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
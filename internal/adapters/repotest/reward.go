@@ -0,0 +1,75 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/reward"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+func testRewards(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	f := newFixture(t, r)
+	eth := f.project("eth", f.main, f.ethPool, createdAt)
+	arb := f.project("arb", f.arb, f.arbPool, createdAt)
+	saveProjects(t, r, eth, arb)
+	// the positions of the different pools share the address
+	ethPosition := f.position(eth, "1", createdAt, nil)
+	arbPosition := f.position(arb, "1", createdAt, nil)
+	savePositions(t, r, ethPosition, arbPosition)
+
+	newReward := func(pos *position.Position, tk *token.Token, amount int, createdAt time.Time) *reward.Reward {
+		return reward.Restore(reward.Snapshot{ID: uuid.New(), Position: pos, Amount: values.NewAmount(tk, amount), CreatedAt: createdAt})
+	}
+	// the rewards are saved at once
+	err := r.Rewards.Save(ctx,
+		newReward(arbPosition, f.arbUSDC, 5, createdAt.Add(2*time.Hour)),
+		newReward(ethPosition, f.ethUSDC, 7, createdAt.Add(time.Hour)),
+		newReward(ethPosition, f.ethWETH, 3, createdAt),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	label := func(rw *reward.Reward) string { return rw.Amount().Value().String() }
+	checkFind(t, r.Rewards.Find, label, []findCase[reward.Filter]{
+		// the rewards are sorted by the creation time
+		{"all", reward.Filter{}, []string{"3", "7", "5"}},
+		{"position", reward.Filter{Positions: []*position.Position{ethPosition}}, []string{"3", "7"}},
+		{"position of other pool", reward.Filter{Positions: []*position.Position{arbPosition}}, []string{"5"}},
+		{"token", reward.Filter{Tokens: []*token.Token{f.ethUSDC}}, []string{"7"}},
+		{"tokens", reward.Filter{Tokens: []*token.Token{f.ethUSDC, f.arbUSDC}}, []string{"7", "5"}},
+		{"position and token", reward.Filter{Positions: []*position.Position{ethPosition}, Tokens: []*token.Token{f.arbUSDC}}, []string{}},
+		{"created", reward.Filter{CreatedFrom: createdAt.Add(time.Hour)}, []string{"7", "5"}},
+		{"created before", reward.Filter{CreatedTo: createdAt.Add(time.Hour)}, []string{"3"}},
+		{"by created", reward.Filter{OrderBy: reward.OrderByCreatedAt}, []string{"3", "7", "5"}},
+		{"by created desc", reward.Filter{OrderBy: reward.OrderByCreatedAtDesc}, []string{"5", "7", "3"}},
+		{"page", reward.Filter{Limit: 1, Offset: 1}, []string{"7"}},
+		{"sorted page", reward.Filter{OrderBy: reward.OrderByCreatedAtDesc, Limit: 2}, []string{"5", "7"}},
+		{"past the end", reward.Filter{Offset: 3}, []string{}},
+	})
+	checkInvalid(t, r.Rewards.Find, map[string]reward.Filter{
+		"negative limit":  {Limit: -1},
+		"negative offset": {Offset: -1},
+		"unsupported":     {OrderBy: "amount"},
+	})
+
+	stored, err := r.Rewards.FindOne(ctx, reward.Filter{Positions: []*position.Position{ethPosition}, OrderBy: reward.OrderByCreatedAtDesc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Amount().Token().Eq(f.ethUSDC) || stored.Amount().Value().Int64() != 7 || stored.Position().Address() != "1" ||
+		stored.Position().Pool().Network() != "eth" || !stored.CreatedAt().Equal(createdAt.Add(time.Hour)) {
+		t.Fatalf("unexpected stored reward %+v", stored.Snapshot())
+	}
+	if _, err = r.Rewards.FindOne(ctx, reward.Filter{Tokens: []*token.Token{f.arbWETH}}); !errors.Is(err, reward.ErrNotFound) {
+		t.Fatalf("expected the not found error, got %v", err)
+	}
+}
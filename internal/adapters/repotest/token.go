@@ -0,0 +1,52 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+)
+
+func testTokens(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	f := newFixture(t, r)
+
+	// the tokens are not versioned, saving a token again keeps a single copy of it
+	if err := r.Tokens.Save(ctx, f.ethUSDC); err != nil {
+		t.Fatal(err)
+	}
+
+	label := func(tk *token.Token) string { return tk.Network() + ":" + tk.Ticker().String() }
+	checkFind(t, r.Tokens.Find, label, []findCase[token.Filter]{
+		// the tokens are sorted by the network and then by the address
+		{"all", token.Filter{}, []string{"arb:WETH", "arb:USDC", "eth:USDC", "eth:WETH"}},
+		{"network", token.Filter{Networks: []string{"eth"}}, []string{"eth:USDC", "eth:WETH"}},
+		{"addresses", token.Filter{Addresses: []string{"0xc02a", "0xaf88"}}, []string{"arb:USDC", "eth:WETH"}},
+		{"ticker", token.Filter{Tickers: []token.Ticker{"USDC"}}, []string{"arb:USDC", "eth:USDC"}},
+		{"network and ticker", token.Filter{Networks: []string{"eth"}, Tickers: []token.Ticker{"WETH"}}, []string{"eth:WETH"}},
+		{"other network", token.Filter{Networks: []string{"base"}}, []string{}},
+		{"by network", token.Filter{OrderBy: token.OrderByNetwork}, []string{"arb:WETH", "arb:USDC", "eth:USDC", "eth:WETH"}},
+		{"by ticker", token.Filter{OrderBy: token.OrderByTicker}, []string{"arb:USDC", "eth:USDC", "arb:WETH", "eth:WETH"}},
+		{"page", token.Filter{Limit: 2, Offset: 1}, []string{"arb:USDC", "eth:USDC"}},
+		{"sorted page", token.Filter{OrderBy: token.OrderByTicker, Limit: 2, Offset: 1}, []string{"eth:USDC", "arb:WETH"}},
+		{"offset", token.Filter{Offset: 3}, []string{"eth:WETH"}},
+		{"past the end", token.Filter{Limit: 2, Offset: 4}, []string{}},
+	})
+	checkInvalid(t, r.Tokens.Find, map[string]token.Filter{
+		"negative limit":  {Limit: -1},
+		"negative offset": {Offset: -1},
+		"unsupported":     {OrderBy: "decimals"},
+	})
+
+	stored, err := r.Tokens.FindOne(ctx, token.Filter{Tickers: []token.Ticker{"WETH"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.Eq(f.arbWETH) || stored.Decimals() != 18 {
+		t.Fatalf("found %s:%s of %d decimals, want the first of the sorted tokens", stored.Network(), stored.Address(), stored.Decimals())
+	}
+	if _, err = r.Tokens.FindOne(ctx, token.Filter{Tickers: []token.Ticker{"DAI"}}); !errors.Is(err, token.ErrNotFound) {
+		t.Fatalf("expected the not found error, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+func testWallets(t *testing.T, r Repositories) {
+	ctx := context.Background()
+	f := newFixture(t, r)
+	spare := f.wallet("spare", "eth", "0xe2", f.ethWETH, createdAt.Add(time.Hour))
+	if err := r.Wallets.Save(ctx, spare); err != nil {
+		t.Fatal(err)
+	}
+
+	label := func(w *wallet.Wallet) string { return w.Name() }
+	checkFind(t, r.Wallets.Find, label, []findCase[wallet.Filter]{
+		// the wallets are sorted by the creation time
+		{"all", wallet.Filter{}, []string{"main", "spare", "arb"}},
+		{"network", wallet.Filter{Networks: []string{"eth"}}, []string{"main", "spare"}},
+		{"addresses", wallet.Filter{Addresses: []string{"0xa1", "0xe2"}}, []string{"spare", "arb"}},
+		{"network and address", wallet.Filter{Networks: []string{"arb"}, Addresses: []string{"0xe1"}}, []string{}},
+		{"created", wallet.Filter{CreatedFrom: createdAt.Add(time.Hour), CreatedTo: createdAt.Add(2 * time.Hour)}, []string{"spare"}},
+		{"created from", wallet.Filter{CreatedFrom: createdAt.Add(time.Hour)}, []string{"spare", "arb"}},
+		{"created before", wallet.Filter{CreatedTo: createdAt.Add(time.Hour)}, []string{"main"}},
+		{"by name", wallet.Filter{OrderBy: wallet.OrderByName}, []string{"arb", "main", "spare"}},
+		{"by created", wallet.Filter{OrderBy: wallet.OrderByCreatedAt}, []string{"main", "spare", "arb"}},
+		{"by created desc", wallet.Filter{OrderBy: wallet.OrderByCreatedAtDesc}, []string{"arb", "spare", "main"}},
+		{"page", wallet.Filter{Limit: 1, Offset: 1}, []string{"spare"}},
+		{"sorted page", wallet.Filter{OrderBy: wallet.OrderByCreatedAtDesc, Limit: 2}, []string{"arb", "spare"}},
+		{"past the end", wallet.Filter{Offset: 3}, []string{}},
+	})
+	checkInvalid(t, r.Wallets.Find, map[string]wallet.Filter{
+		"negative limit":  {Limit: -1},
+		"negative offset": {Offset: -1},
+		"unsupported":     {OrderBy: "address"},
+	})
+
+	stored, err := r.Wallets.FindOne(ctx, wallet.Filter{Networks: []string{"eth"}, OrderBy: wallet.OrderByCreatedAtDesc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Address() != "0xe2" || !stored.NativeToken().Eq(f.ethWETH) || !stored.CreatedAt().Equal(spare.CreatedAt()) {
+		t.Fatalf("unexpected stored wallet %+v", stored.Snapshot())
+	}
+	if _, err = r.Wallets.FindOne(ctx, wallet.Filter{Networks: []string{"base"}}); !errors.Is(err, wallet.ErrNotFound) {
+		t.Fatalf("expected the not found error, got %v", err)
+	}
+}
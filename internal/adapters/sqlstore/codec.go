@@ -0,0 +1,90 @@
+package sqlstore
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+// The amounts and the liquidity are kept as the exact decimal integers
+// and the prices as the shortest decimals restoring the same float
+
+func encodeInt(v *big.Int) string {
+	if v == nil {
+		return "0"
+	}
+	return v.String()
+}
+
+func encodeAmount(a values.Amount) string { return a.Value().String() }
+
+func encodePrice(p values.Price) string { return p.Float().Text('f', -1) }
+
+// encodeTime keeps the times in UTC, so the text based SQLite timestamps compare in the time order
+func encodeTime(t time.Time) time.Time { return t.UTC() }
+
+func encodeNullTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer: %q", s)
+	}
+	return v, nil
+}
+
+func decodeAmount(t *token.Token, s string) (values.Amount, error) {
+	v, err := decodeInt(s)
+	if err != nil {
+		return values.Amount{}, fmt.Errorf("%s amount: %w", t, err)
+	}
+	return values.NewAmount(t, v), nil
+}
+
+func decodePrice(pair *token.Pair, s string) (values.Price, error) {
+	v, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return values.Price{}, fmt.Errorf("invalid %s price: %q", pair, s)
+	}
+	return values.NewPrice(pair, v), nil
+}
+
+// decoder decodes the columns of a row keeping the first error
+type decoder struct {
+	err error
+}
+
+func (d *decoder) int(s string) *big.Int {
+	if d.err != nil {
+		return nil
+	}
+	var v *big.Int
+	v, d.err = decodeInt(s)
+	return v
+}
+
+func (d *decoder) amount(t *token.Token, s string) values.Amount {
+	if d.err != nil {
+		return values.Amount{}
+	}
+	var a values.Amount
+	a, d.err = decodeAmount(t, s)
+	return a
+}
+
+func (d *decoder) price(pair *token.Pair, s string) values.Price {
+	if d.err != nil {
+		return values.Price{}
+	}
+	var p values.Price
+	p, d.err = decodePrice(pair, s)
+	return p
+}
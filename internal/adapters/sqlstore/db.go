@@ -0,0 +1,309 @@
+// Package sqlstore implements the entity repositories on top of a SQL database
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+//go:embed migrations
+var migrations embed.FS
+
+// dialect holds the database specific parts of the queries
+type dialect struct {
+	name string
+	// lockMigrations serializes the concurrent migrations inside the migration transaction
+	lockMigrations string
+	// rebind converts the $n placeholders to the dialect ones
+	rebind func(query string) string
+	// noLimit is the LIMIT of the page with the offset only
+	noLimit string
+}
+
+// beforeMigrations and afterMigrations are the data migrations run inside the migration transaction
+// before and after the script of the version
+var (
+	beforeMigrations = map[int]func(db *DB, ctx context.Context, tx *sql.Tx) error{
+		3: (*DB).importPlainKeys,
+	}
+	afterMigrations = map[int]func(db *DB, ctx context.Context, tx *sql.Tx) error{
+		9: (*DB).restoreIdleFunds,
+	}
+)
+
+// DB is the database shared by the repositories
+type DB struct {
+	db      *sql.DB
+	dialect dialect
+	// keys imports the plain wallet keys of the databases created before the key store
+	keys wallet.KeyStore
+}
+
+func (db *DB) Close() error { return db.db.Close() }
+
+// open checks the connection and applies the pending migrations
+func open(ctx context.Context, sqlDB *sql.DB, d dialect, keys wallet.KeyStore) (*DB, error) {
+	if err := sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("ping %s: %w", d.name, err)
+	}
+
+	db := &DB{db: sqlDB, dialect: d, keys: keys}
+	if err := db.migrate(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	db.keys = nil
+	return db, nil
+}
+
+// migrate applies the versioned migrations of the dialect in the version order,
+// every migration is applied in its own transaction
+func (db *DB) migrate(ctx context.Context) error {
+	dir := "migrations/" + db.dialect.name
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return fmt.Errorf("read migrations: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	_, err = db.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL PRIMARY KEY)`)
+	if err != nil {
+		return fmt.Errorf("create migrations table: %w", err)
+	}
+
+	for _, entry := range entries {
+		version, err := strconv.Atoi(strings.SplitN(entry.Name(), "_", 2)[0])
+		if err != nil {
+			return fmt.Errorf("invalid migration name %s: %w", entry.Name(), err)
+		}
+		script, err := fs.ReadFile(migrations, dir+"/"+entry.Name())
+		if err != nil {
+			return fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+		if err = db.applyMigration(ctx, version, string(script)); err != nil {
+			return fmt.Errorf("apply migration %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (db *DB) applyMigration(ctx context.Context, version int, script string) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if db.dialect.lockMigrations != "" {
+		if _, err = tx.ExecContext(ctx, db.dialect.lockMigrations); err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+	}
+
+	var applied int
+	err = tx.QueryRowContext(ctx, db.dialect.rebind(`SELECT COUNT(*) FROM schema_migrations WHERE version = $1`), version).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if before, ok := beforeMigrations[version]; ok {
+		if err = before(db, ctx, tx); err != nil {
+			return err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if after, ok := afterMigrations[version]; ok {
+		if err = after(db, ctx, tx); err != nil {
+			return err
+		}
+	}
+	if _, err = tx.ExecContext(ctx, db.dialect.rebind(`INSERT INTO schema_migrations (version) VALUES ($1)`), version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// queryer runs the queries both on the database and inside a transaction
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func (db *DB) exec(ctx context.Context, q queryer, query string, args ...any) error {
+	_, err := q.ExecContext(ctx, db.dialect.rebind(query), args...)
+	return err
+}
+
+// execVersioned runs the upsert of a versioned entity writing the next version of the entity,
+// the upsert writes nothing when the stored row has moved past the version the entity was loaded at
+// and the conflict is returned then
+func (db *DB) execVersioned(ctx context.Context, q queryer, conflict error, query string, args ...any) error {
+	res, err := q.ExecContext(ctx, db.dialect.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return conflict
+	}
+	return nil
+}
+
+// inTx runs fn inside a transaction committing it when fn succeeds
+func (db *DB) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// selectRows runs the select with the filter conditions and the tail, i.e. the ordering and the page,
+// and scans every row by scan
+func (db *DB) selectRows(ctx context.Context, query string, w *where, tail string, scan func(*sql.Rows) error) error {
+	return db.queryRows(ctx, db.db, query+w.String()+tail, w.args, scan)
+}
+
+// queryRows scans the rows of the query one by one, both on the database and inside a transaction
+func (db *DB) queryRows(ctx context.Context, q queryer, query string, args []any, scan func(*sql.Rows) error) error {
+	rows, err := q.QueryContext(ctx, db.dialect.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// where builds the filter conditions with the $n placeholders
+type where struct {
+	conds []string
+	args  []any
+}
+
+func (w *where) placeholder(arg any) string {
+	w.args = append(w.args, arg)
+	return "$" + strconv.Itoa(len(w.args))
+}
+
+// in adds the column IN (...) condition, the empty values add no condition
+func (w *where) in(column string, values []any) {
+	if len(values) == 0 {
+		return
+	}
+	pp := make([]string, len(values))
+	for i, v := range values {
+		pp[i] = w.placeholder(v)
+	}
+	w.conds = append(w.conds, column+" IN ("+strings.Join(pp, ", ")+")")
+}
+
+// inTuples adds the condition matching the columns with any of the tuples, the empty tuples add no condition
+func (w *where) inTuples(columns []string, tuples [][]any) {
+	if len(tuples) == 0 {
+		return
+	}
+	alternatives := make([]string, len(tuples))
+	for i, tuple := range tuples {
+		eqs := make([]string, len(columns))
+		for j, column := range columns {
+			eqs[j] = column + " = " + w.placeholder(tuple[j])
+		}
+		alternatives[i] = "(" + strings.Join(eqs, " AND ") + ")"
+	}
+	w.conds = append(w.conds, "("+strings.Join(alternatives, " OR ")+")")
+}
+
+// between adds the from <= column < to condition, a zero bound adds no condition
+func (w *where) between(column string, from, to time.Time) {
+	if !from.IsZero() {
+		w.conds = append(w.conds, column+" >= "+w.placeholder(encodeTime(from)))
+	}
+	if !to.IsZero() {
+		w.conds = append(w.conds, column+" < "+w.placeholder(encodeTime(to)))
+	}
+}
+
+func (w *where) String() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+func args[T any](vv []T, f func(T) any) []any {
+	out := make([]any, len(vv))
+	for i, v := range vv {
+		out[i] = f(v)
+	}
+	return out
+}
+
+func tuples[T any](vv []T, f func(T) []any) [][]any {
+	out := make([][]any, len(vv))
+	for i, v := range vv {
+		out[i] = f(v)
+	}
+	return out
+}
+
+func asIs[T any](v T) any { return v }
+
+// orderClause maps the entity OrderBy to the SQL ordering, the key columns keep the order stable
+func orderClause[O ~string](orderBy O, columns map[O]string, defaultOrder O, keys string) (string, error) {
+	if orderBy == "" {
+		orderBy = defaultOrder
+	}
+	name, desc := strings.CutPrefix(string(orderBy), "-")
+	column, ok := columns[O(name)]
+	if !ok {
+		return "", fmt.Errorf("unsupported order by: %s", orderBy)
+	}
+	if desc {
+		column += " DESC"
+	}
+	return " ORDER BY " + column + ", " + keys, nil
+}
+
+// pageClause limits the selected rows, the zero limit means no limit
+func (db *DB) pageClause(limit, offset int) (string, error) {
+	switch {
+	case limit < 0 || offset < 0:
+		return "", errors.New("negative limit or offset")
+	case limit > 0:
+		return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset), nil
+	case offset > 0:
+		return fmt.Sprintf(" LIMIT %s OFFSET %d", db.dialect.noLimit, offset), nil
+	default:
+		return "", nil
+	}
+}
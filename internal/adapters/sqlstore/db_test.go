@@ -0,0 +1,106 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+// plainKeyStore imports the keys as the references to them
+type plainKeyStore struct {
+	imported map[string]string
+}
+
+func (s *plainKeyStore) Import(_ context.Context, network, privateKey string) (string, string, error) {
+	addr, ok := s.imported[privateKey]
+	if !ok {
+		return "", "", errors.New("unknown key")
+	}
+	return addr, "keystore:" + network + ":" + addr, nil
+}
+
+func (s *plainKeyStore) Address(context.Context, string, string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+// openV2 creates the SQLite database of the schema version 2 keeping a wallet with a plain key
+func openV2(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "epos.db")
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stmts := []string{`CREATE TABLE schema_migrations (version INTEGER NOT NULL PRIMARY KEY)`}
+	for _, name := range []string{"0001_init.sql", "0002_versions.sql"} {
+		script, err := migrations.ReadFile("migrations/sqlite/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stmts = append(stmts, string(script))
+	}
+	stmts = append(stmts,
+		`INSERT INTO schema_migrations (version) VALUES (1), (2)`,
+		`INSERT INTO tokens (network, address, ticker, decimals) VALUES ('eth', '0xeee', 'ETH', 18)`,
+		`INSERT INTO wallets (network, address, name, private_key, native_token_address, created_at)
+			VALUES ('eth', '0xabc', 'main', '0x4c0883a6', '0xeee', CURRENT_TIMESTAMP)`)
+	for _, stmt := range stmts {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestImportPlainKeys(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("no key store", func(t *testing.T) {
+		path := openV2(t)
+		if _, err := OpenSQLite(ctx, path, nil); err == nil || !strings.Contains(err.Error(), "plain private keys") {
+			t.Fatalf("expected the plain keys error, got %v", err)
+		}
+
+		// the failed migration leaves the database at the version 2
+		db, err := sql.Open("sqlite", "file:"+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		var key string
+		if err = db.QueryRow(`SELECT private_key FROM wallets`).Scan(&key); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("foreign key", func(t *testing.T) {
+		keys := &plainKeyStore{imported: map[string]string{"0x4c0883a6": "0xdef"}}
+		if _, err := OpenSQLite(ctx, openV2(t), keys); err == nil || !strings.Contains(err.Error(), "belongs to 0xdef") {
+			t.Fatalf("expected the foreign key error, got %v", err)
+		}
+	})
+
+	t.Run("imported", func(t *testing.T) {
+		keys := &plainKeyStore{imported: map[string]string{"0x4c0883a6": "0xABC"}}
+		db, err := OpenSQLite(ctx, openV2(t), keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		w, err := NewWalletRepository(db).FindOne(ctx, wallet.Filter{Networks: []string{"eth"}, Addresses: []string{"0xabc"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.KeyRef() != "keystore:eth:0xABC" {
+			t.Fatalf("key ref %q", w.KeyRef())
+		}
+	})
+}
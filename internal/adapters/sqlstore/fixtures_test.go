@@ -0,0 +1,141 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+// fixture holds the entities the tested entities refer to
+type fixture struct {
+	weth, usdc *token.Token
+	pair       *token.Pair
+	wallet     *wallet.Wallet
+	pool       *pool.Pool
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		weth: token.New("eth", "0xc02a", "WETH", 18),
+		usdc: token.New("eth", "0xa0b8", "USDC", 6),
+	}
+	f.pair = token.NewPair(f.weth, f.usdc)
+	f.wallet = wallet.Restore(wallet.Snapshot{
+		Name: "main", Network: "eth", Address: "0xw", KeyRef: "keystore:0xw", NativeToken: f.weth,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	})
+	f.pool = pool.Restore(pool.Snapshot{
+		Network: "eth", Protocol: "uniswap", Address: "0xp", Fee: pool.LowFee, Pair: f.pair,
+		LastPrice: values.NewPrice(f.pair, 2000),
+	})
+	return f
+}
+
+// project creates an active project investing the USDC amount
+func (f *fixture) project(name string, investments int, createdAt time.Time) *project.Project {
+	return project.Restore(project.Snapshot{
+		ID: uuid.New(), Wallet: f.wallet, Pool: f.pool, Name: name,
+		Investments:     values.NewAmount(f.usdc, investments),
+		CurrentValue:    values.NewAmount(f.usdc, investments),
+		PeakValue:       values.NewAmount(f.usdc, investments),
+		IdleBase:        values.NewAmount(f.weth, 0),
+		IdleQuote:       values.NewAmount(f.usdc, investments),
+		RangeVolatility: 0.05,
+		Slippage:        0.01,
+		ActivePositions: 1,
+		Status:          project.Active,
+		CreatedAt:       createdAt,
+	})
+}
+
+// position creates a position of the project put the amounts in and, when closed, got the amounts out
+func (f *fixture) position(proj *project.Project, address string, inBase, inQuote, outBase, outQuote int, closedAt *time.Time) *position.Position {
+	status := position.Open
+	if closedAt != nil {
+		status = position.Closed
+	}
+	return position.Restore(position.Snapshot{
+		Project: proj, Pool: f.pool, Address: address,
+		LowerPrice: values.NewPrice(f.pair, 1900), UpperPrice: values.NewPrice(f.pair, 2100),
+		InitialPrice: values.NewPrice(f.pair, 2000), CurrentPrice: values.NewPrice(f.pair, 2000),
+		LowerTick: -100, UpperTick: 100, Liquidity: values.NewAmount(f.weth, 1000).Value(),
+		InBaseAmount: values.NewAmount(f.weth, inBase), InQuoteAmount: values.NewAmount(f.usdc, inQuote),
+		OutBaseAmount: values.NewAmount(f.weth, outBase), OutQuoteAmount: values.NewAmount(f.usdc, outQuote),
+		CurrentBaseAmount: values.NewAmount(f.weth, inBase), CurrentQuoteAmount: values.NewAmount(f.usdc, inQuote),
+		CurrentBaseAccruedFees: values.NewAmount(f.weth, 0), CurrentQuoteAccruedFees: values.NewAmount(f.usdc, 0),
+		TransactionFee: values.NewAmount(f.weth, 1), Status: status,
+		CreatedAt: proj.CreatedAt().Add(time.Minute), ClosedAt: closedAt,
+	})
+}
+
+// openTestSQLite opens a new SQLite database in the test directory
+func openTestSQLite(t *testing.T) *DB {
+	t.Helper()
+	db, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "epos.db"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// postgresDSNEnv names the environment variable of the PostgreSQL DSN the tests run against, the tests
+// of PostgreSQL are skipped without it
+const postgresDSNEnv = "EPOS_TEST_POSTGRES_DSN"
+
+// openTestPostgres opens the PostgreSQL database in a new schema dropped after the test
+func openTestPostgres(t *testing.T) *DB {
+	t.Helper()
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDSNEnv)
+	}
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = admin.Close() })
+
+	schema := fmt.Sprintf("epos_test_%d", time.Now().UnixNano())
+	if _, err = admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+	// the search path is a run-time parameter of both the URL and the key-value DSNs
+	switch {
+	case !strings.Contains(dsn, "://"):
+		dsn += " search_path=" + schema
+	case strings.Contains(dsn, "?"):
+		dsn += "&search_path=" + schema
+	default:
+		dsn += "?search_path=" + schema
+	}
+	db, err := OpenPostgres(context.Background(), dsn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// forEachDialect runs the test on a new database of every dialect
+func forEachDialect(t *testing.T, test func(t *testing.T, db *DB)) {
+	t.Run(sqlite.name, func(t *testing.T) { test(t, openTestSQLite(t)) })
+	t.Run(postgres.name, func(t *testing.T) { test(t, openTestPostgres(t)) })
+}
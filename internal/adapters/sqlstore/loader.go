@@ -0,0 +1,48 @@
+package sqlstore
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+// loader loads the entities with their references, every referenced entity is loaded once per loader
+type loader struct {
+	db        *DB
+	tokens    map[string]*token.Token
+	wallets   map[string]*wallet.Wallet
+	pools     map[string]*pool.Pool
+	projects  map[uuid.UUID]*project.Project
+	positions map[string]*position.Position
+}
+
+func (db *DB) loader() *loader {
+	return &loader{
+		db:        db,
+		tokens:    make(map[string]*token.Token),
+		wallets:   make(map[string]*wallet.Wallet),
+		pools:     make(map[string]*pool.Pool),
+		projects:  make(map[uuid.UUID]*project.Project),
+		positions: make(map[string]*position.Position),
+	}
+}
+
+func tokenKey(network, address string) string {
+	return network + "/" + address
+}
+
+func walletKey(network, address string) string {
+	return network + "/" + address
+}
+
+func poolKey(network, protocol, address string) string {
+	return network + "/" + protocol + "/" + address
+}
+
+func positionKey(poolNetwork, poolProtocol, poolAddress, address string) string {
+	return poolKey(poolNetwork, poolProtocol, poolAddress) + "/" + address
+}
@@ -0,0 +1,133 @@
+CREATE TABLE tokens
+(
+    network  TEXT    NOT NULL,
+    address  TEXT    NOT NULL,
+    ticker   TEXT    NOT NULL,
+    decimals INTEGER NOT NULL,
+    PRIMARY KEY (network, address)
+);
+
+CREATE TABLE wallets
+(
+    network              TEXT        NOT NULL,
+    address              TEXT        NOT NULL,
+    name                 TEXT        NOT NULL,
+    private_key          TEXT        NOT NULL,
+    native_token_address TEXT        NOT NULL,
+    created_at           TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (network, address),
+    FOREIGN KEY (network, native_token_address) REFERENCES tokens (network, address)
+);
+
+CREATE TABLE pools
+(
+    network             TEXT             NOT NULL,
+    protocol            TEXT             NOT NULL,
+    address             TEXT             NOT NULL,
+    fee                 DOUBLE PRECISION NOT NULL,
+    base_token_address  TEXT             NOT NULL,
+    quote_token_address TEXT             NOT NULL,
+    last_price          NUMERIC          NOT NULL,
+    PRIMARY KEY (network, protocol, address),
+    FOREIGN KEY (network, base_token_address) REFERENCES tokens (network, address),
+    FOREIGN KEY (network, quote_token_address) REFERENCES tokens (network, address)
+);
+
+CREATE TABLE projects
+(
+    id                        UUID             NOT NULL PRIMARY KEY,
+    wallet_network            TEXT             NOT NULL,
+    wallet_address            TEXT             NOT NULL,
+    pool_network              TEXT             NOT NULL,
+    pool_protocol             TEXT             NOT NULL,
+    pool_address              TEXT             NOT NULL,
+    name                      TEXT             NOT NULL,
+    investments_token_address TEXT             NOT NULL,
+    investments               NUMERIC(78, 0)   NOT NULL,
+    take_profit               DOUBLE PRECISION NOT NULL,
+    stop_loss                 DOUBLE PRECISION NOT NULL,
+    range_volatility          DOUBLE PRECISION NOT NULL,
+    slippage                  DOUBLE PRECISION NOT NULL,
+    active_positions          INTEGER          NOT NULL,
+    current_value             NUMERIC(78, 0)   NOT NULL,
+    status                    TEXT             NOT NULL,
+    inactive_reason           TEXT             NOT NULL,
+    created_at                TIMESTAMPTZ      NOT NULL,
+    FOREIGN KEY (wallet_network, wallet_address) REFERENCES wallets (network, address),
+    FOREIGN KEY (pool_network, pool_protocol, pool_address) REFERENCES pools (network, protocol, address),
+    FOREIGN KEY (pool_network, investments_token_address) REFERENCES tokens (network, address)
+);
+
+CREATE INDEX projects_status_idx ON projects (status);
+
+CREATE TABLE positions
+(
+    pool_network               TEXT           NOT NULL,
+    pool_protocol              TEXT           NOT NULL,
+    pool_address               TEXT           NOT NULL,
+    address                    TEXT           NOT NULL,
+    project_id                 UUID           NOT NULL REFERENCES projects (id),
+    lower_price                NUMERIC        NOT NULL,
+    upper_price                NUMERIC        NOT NULL,
+    lower_tick                 INTEGER        NOT NULL,
+    upper_tick                 INTEGER        NOT NULL,
+    initial_price              NUMERIC        NOT NULL,
+    liquidity                  NUMERIC(78, 0) NOT NULL,
+    in_base_amount             NUMERIC(78, 0) NOT NULL,
+    in_quote_amount            NUMERIC(78, 0) NOT NULL,
+    out_base_amount            NUMERIC(78, 0) NOT NULL,
+    out_quote_amount           NUMERIC(78, 0) NOT NULL,
+    status                     TEXT           NOT NULL,
+    transaction_fee            NUMERIC(78, 0) NOT NULL,
+    created_at                 TIMESTAMPTZ    NOT NULL,
+    closed_at                  TIMESTAMPTZ,
+    current_price              NUMERIC        NOT NULL,
+    current_base_amount        NUMERIC(78, 0) NOT NULL,
+    current_quote_amount       NUMERIC(78, 0) NOT NULL,
+    current_base_accrued_fees  NUMERIC(78, 0) NOT NULL,
+    current_quote_accrued_fees NUMERIC(78, 0) NOT NULL,
+    PRIMARY KEY (pool_network, pool_protocol, pool_address, address),
+    FOREIGN KEY (pool_network, pool_protocol, pool_address) REFERENCES pools (network, protocol, address)
+);
+
+CREATE INDEX positions_project_id_status_idx ON positions (project_id, status);
+
+CREATE TABLE orders
+(
+    network         TEXT           NOT NULL,
+    address         TEXT           NOT NULL,
+    project_id      UUID           NOT NULL REFERENCES projects (id),
+    pool_protocol   TEXT           NOT NULL,
+    pool_address    TEXT           NOT NULL,
+    direction         TEXT           NOT NULL,
+    token_in_address  TEXT           NOT NULL,
+    amount_in         NUMERIC(78, 0) NOT NULL,
+    token_out_address TEXT           NOT NULL,
+    amount_out        NUMERIC(78, 0) NOT NULL,
+    filled_price    NUMERIC        NOT NULL,
+    transaction_fee NUMERIC(78, 0) NOT NULL,
+    created_at      TIMESTAMPTZ    NOT NULL,
+    PRIMARY KEY (network, address),
+    FOREIGN KEY (network, pool_protocol, pool_address) REFERENCES pools (network, protocol, address),
+    FOREIGN KEY (network, token_in_address) REFERENCES tokens (network, address),
+    FOREIGN KEY (network, token_out_address) REFERENCES tokens (network, address)
+);
+
+CREATE INDEX orders_project_id_idx ON orders (project_id);
+
+CREATE TABLE rewards
+(
+    id               UUID           NOT NULL PRIMARY KEY,
+    pool_network     TEXT           NOT NULL,
+    pool_protocol    TEXT           NOT NULL,
+    pool_address     TEXT           NOT NULL,
+    position_address TEXT           NOT NULL,
+    token_address    TEXT           NOT NULL,
+    amount           NUMERIC(78, 0) NOT NULL,
+    created_at       TIMESTAMPTZ    NOT NULL,
+    FOREIGN KEY (pool_network, pool_protocol, pool_address, position_address)
+        REFERENCES positions (pool_network, pool_protocol, pool_address, address),
+    FOREIGN KEY (pool_network, token_address) REFERENCES tokens (network, address)
+);
+
+CREATE INDEX rewards_position_idx ON rewards (pool_network, pool_protocol, pool_address, position_address);
@@ -0,0 +1,4 @@
+-- the wallets keep only the references to their keys in the key store,
+-- the plain keys of the existing wallets are moved into the key store and replaced by the references before the rename
+
+ALTER TABLE wallets RENAME COLUMN private_key TO key_ref;
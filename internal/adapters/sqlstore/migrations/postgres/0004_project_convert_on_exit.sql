@@ -0,0 +1,3 @@
+-- the projects leaving the pool by take-profit may convert their assets back into the investments token
+
+ALTER TABLE projects ADD COLUMN convert_on_exit BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,9 @@
+-- the exit rules tracking the peak project worth, the zero rules are disabled,
+-- max_holding_duration is in nanoseconds
+
+ALTER TABLE projects ADD COLUMN trailing_stop DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN max_drawdown DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN max_holding_duration BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN peak_value NUMERIC(78, 0) NOT NULL DEFAULT 0;
+
+UPDATE projects SET peak_value = GREATEST(investments, current_value);
@@ -0,0 +1,5 @@
+-- the pool tokens of the project held on the wallet outside of the positions,
+-- the idle funds of the existing projects are restored from their positions, orders and rewards after the script
+
+ALTER TABLE projects ADD COLUMN idle_base NUMERIC(78, 0) NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN idle_quote NUMERIC(78, 0) NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+-- the version of the row is advanced by every save, a save based on an older version is rejected
+
+ALTER TABLE wallets ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE pools ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE positions ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rewards ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- the time the project was deactivated, its positions are closed by then unless they could not be
+
+ALTER TABLE projects ADD COLUMN deactivated_at TIMESTAMP;
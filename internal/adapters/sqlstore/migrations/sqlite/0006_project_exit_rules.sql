@@ -0,0 +1,13 @@
+-- the exit rules tracking the peak project worth, the zero rules are disabled,
+-- max_holding_duration is in nanoseconds
+
+ALTER TABLE projects ADD COLUMN trailing_stop REAL NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN max_drawdown REAL NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN max_holding_duration INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN peak_value TEXT NOT NULL DEFAULT '0';
+
+-- the amounts are decimal text, compared as numbers they may lose precision, so the longer text wins first
+UPDATE projects SET peak_value = CASE
+    WHEN length(current_value) > length(investments) THEN current_value
+    WHEN length(current_value) = length(investments) AND current_value > investments THEN current_value
+    ELSE investments END;
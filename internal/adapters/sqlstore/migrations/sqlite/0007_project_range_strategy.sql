@@ -0,0 +1,5 @@
+-- the range strategy of the project positions and its JSON encoded parameters,
+-- the empty strategy is the fixed percent by range_volatility
+
+ALTER TABLE projects ADD COLUMN range_strategy TEXT NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN range_params TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+-- the rebalance policy of the project, the zero fields are disabled, the durations are in nanoseconds,
+-- and the time the position price left the buffered range
+
+ALTER TABLE projects ADD COLUMN rebalance_buffer REAL NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN rebalance_min_out_of_range INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN rebalance_cooldown INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN rebalance_max_per_day INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE positions ADD COLUMN out_of_range_since TIMESTAMP;
@@ -0,0 +1,139 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/order"
+	"github.com/r1der/epos/internal/domain/entity/project"
+)
+
+var orderOrder = map[order.OrderBy]string{
+	order.OrderByCreatedAt: "created_at",
+}
+
+type orderRepository struct {
+	db *DB
+}
+
+// NewOrderRepository creates an order repository
+func NewOrderRepository(db *DB) order.Repository {
+	return &orderRepository{db: db}
+}
+
+// FindOne finds the first order matching the filter
+func (r *orderRepository) FindOne(ctx context.Context, filter order.Filter) (*order.Order, error) {
+	oo, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(oo) == 0 {
+		return nil, order.ErrNotFound
+	}
+	return oo[0], nil
+}
+
+// Find finds the orders matching the filter
+func (r *orderRepository) Find(ctx context.Context, filter order.Filter) ([]*order.Order, error) {
+	return r.db.loader().findOrders(ctx, filter)
+}
+
+// Save saves the order unless the stored order was changed since the order was loaded
+func (r *orderRepository) Save(ctx context.Context, o *order.Order) error {
+	err := r.db.execVersioned(ctx, r.db.db, order.ErrConflict, `
+		INSERT INTO orders (network, address, project_id, pool_protocol, pool_address, direction,
+			token_in_address, amount_in, token_out_address, amount_out, filled_price, transaction_fee, created_at, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (network, address) DO UPDATE SET amount_in = excluded.amount_in,
+			amount_out = excluded.amount_out, filled_price = excluded.filled_price,
+			transaction_fee = excluded.transaction_fee, version = excluded.version
+		WHERE orders.version = excluded.version - 1`,
+		o.Pool().Network(), o.Address(), o.Project().ID(), o.Pool().Protocol(), o.Pool().Address(),
+		string(o.Direction()), o.AmountIn().Token().Address(), encodeAmount(o.AmountIn()),
+		o.AmountOut().Token().Address(), encodeAmount(o.AmountOut()),
+		encodePrice(o.FilledPrice()), encodeAmount(o.Fee()), encodeTime(o.CreatedAt()), o.Version()+1)
+	if err != nil {
+		return fmt.Errorf("save order %s: %w", o.Address(), err)
+	}
+	o.SetVersion(o.Version() + 1)
+	return nil
+}
+
+func (l *loader) findOrders(ctx context.Context, filter order.Filter) ([]*order.Order, error) {
+	w := &where{}
+	w.in("project_id", args(filter.Projects, func(p *project.Project) any { return p.ID() }))
+	w.inTuples([]string{"network", "pool_protocol", "pool_address"}, tuples(filter.Pools, poolTuple))
+	w.in("address", args(filter.Addresses, asIs[string]))
+	w.in("direction", args(filter.Directions, func(d order.Direction) any { return string(d) }))
+	w.inTuples([]string{"network", "token_in_address"}, tuples(filter.TokensIn, tokenTuple))
+	w.inTuples([]string{"network", "token_out_address"}, tuples(filter.TokensOut, tokenTuple))
+	w.between("created_at", filter.CreatedFrom, filter.CreatedTo)
+
+	orderBy, err := orderClause(filter.OrderBy, orderOrder, order.OrderByCreatedAt, "network, address")
+	if err != nil {
+		return nil, err
+	}
+	page, err := l.db.pageClause(filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	type row struct {
+		snap                                   order.Snapshot
+		network, poolProtocol, poolAddress     string
+		projectID                              uuid.UUID
+		tokenIn, amountIn, tokenOut, amountOut string
+		price, transactionFee                  string
+	}
+	var rows []row
+	err = l.db.selectRows(ctx, `
+		SELECT network, address, project_id, pool_protocol, pool_address, direction,
+			token_in_address, amount_in, token_out_address, amount_out, filled_price, transaction_fee, created_at, version
+		FROM orders`,
+		w, orderBy+page, func(rs *sql.Rows) error {
+			var r row
+			if err := rs.Scan(&r.network, &r.snap.Address, &r.projectID, &r.poolProtocol, &r.poolAddress,
+				&r.snap.Direction, &r.tokenIn, &r.amountIn, &r.tokenOut, &r.amountOut, &r.price, &r.transactionFee, &r.snap.CreatedAt,
+				&r.snap.Version); err != nil {
+				return err
+			}
+			rows = append(rows, r)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select orders: %w", err)
+	}
+
+	oo := make([]*order.Order, 0, len(rows))
+	for _, r := range rows {
+		if r.snap.Project, err = l.project(ctx, r.projectID); err != nil {
+			return nil, fmt.Errorf("order %s project: %w", r.snap.Address, err)
+		}
+		if r.snap.Pool, err = l.pool(ctx, r.network, r.poolProtocol, r.poolAddress); err != nil {
+			return nil, fmt.Errorf("order %s pool: %w", r.snap.Address, err)
+		}
+
+		tokenIn, err := l.token(ctx, r.network, r.tokenIn)
+		if err != nil {
+			return nil, fmt.Errorf("order %s token in: %w", r.snap.Address, err)
+		}
+		tokenOut, err := l.token(ctx, r.network, r.tokenOut)
+		if err != nil {
+			return nil, fmt.Errorf("order %s token out: %w", r.snap.Address, err)
+		}
+
+		d := &decoder{}
+		r.snap.AmountIn = d.amount(tokenIn, r.amountIn)
+		r.snap.AmountOut = d.amount(tokenOut, r.amountOut)
+		r.snap.FilledPrice = d.price(r.snap.Pool.Pair(), r.price)
+		r.snap.TransactionFee = d.amount(r.snap.Project.Wallet().NativeToken(), r.transactionFee)
+		if d.err != nil {
+			return nil, fmt.Errorf("order %s: %w", r.snap.Address, d.err)
+		}
+		oo = append(oo, order.Restore(r.snap))
+	}
+	return oo, nil
+}
@@ -0,0 +1,173 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+var poolOrder = map[pool.OrderBy]string{
+	pool.OrderByNetwork: "network",
+	pool.OrderByFee:     "fee",
+}
+
+type poolRepository struct {
+	db *DB
+}
+
+// NewPoolRepository creates a pool repository
+func NewPoolRepository(db *DB) pool.Repository {
+	return &poolRepository{db: db}
+}
+
+// FindOne finds the first pool matching the filter
+func (r *poolRepository) FindOne(ctx context.Context, filter pool.Filter) (*pool.Pool, error) {
+	pp, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(pp) == 0 {
+		return nil, pool.ErrNotFound
+	}
+	return pp[0], nil
+}
+
+// Find finds the pools matching the filter
+func (r *poolRepository) Find(ctx context.Context, filter pool.Filter) ([]*pool.Pool, error) {
+	return r.db.loader().findPools(ctx, filter)
+}
+
+// Save saves the pool unless the stored pool was changed since the pool was loaded
+func (r *poolRepository) Save(ctx context.Context, p *pool.Pool) error {
+	err := r.db.inTx(ctx, func(tx *sql.Tx) error {
+		return r.db.savePool(ctx, tx, p, true)
+	})
+	if err != nil {
+		return err
+	}
+	p.SetVersion(p.Version() + 1)
+	return nil
+}
+
+// savePool upserts the next version of the pool,
+// the referenced pool is only inserted at its current version unless it is already stored
+func (db *DB) savePool(ctx context.Context, q queryer, p *pool.Pool, update bool) error {
+	if err := db.ensureToken(ctx, q, p.Pair().BaseToken()); err != nil {
+		return err
+	}
+	if err := db.ensureToken(ctx, q, p.Pair().QuoteToken()); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO pools (network, protocol, address, fee, base_token_address, quote_token_address, last_price, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (network, protocol, address) `
+	params := []any{p.Network(), p.Protocol(), p.Address(), p.Fee().Value(),
+		p.Pair().BaseToken().Address(), p.Pair().QuoteToken().Address(), encodePrice(p.LastPrice()), p.Version()}
+
+	var err error
+	if update {
+		params[len(params)-1] = p.Version() + 1
+		err = db.execVersioned(ctx, q, pool.ErrConflict, query+`DO UPDATE SET fee = excluded.fee,
+			base_token_address = excluded.base_token_address, quote_token_address = excluded.quote_token_address,
+			last_price = excluded.last_price, version = excluded.version
+			WHERE pools.version = excluded.version - 1`, params...)
+	} else {
+		err = db.exec(ctx, q, query+`DO NOTHING`, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("save pool %s: %w", p.Address(), err)
+	}
+	return nil
+}
+
+func (l *loader) findPools(ctx context.Context, filter pool.Filter) ([]*pool.Pool, error) {
+	w := &where{}
+	w.in("network", args(filter.Networks, asIs[string]))
+	w.in("protocol", args(filter.Protocols, asIs[string]))
+	w.in("address", args(filter.Addresses, asIs[string]))
+	w.inTuples([]string{"network", "base_token_address"}, tuples(filter.BaseTokens, tokenTuple))
+	w.inTuples([]string{"network", "quote_token_address"}, tuples(filter.QuoteTokens, tokenTuple))
+	w.in("fee", args(filter.Fees, func(fee values.Percent) any { return fee.Value() }))
+
+	orderBy, err := orderClause(filter.OrderBy, poolOrder, pool.OrderByNetwork, "network, protocol, address")
+	if err != nil {
+		return nil, err
+	}
+	page, err := l.db.pageClause(filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	type row struct {
+		snap                                 pool.Snapshot
+		baseAddress, quoteAddress, lastPrice string
+	}
+	var rows []row
+	err = l.db.selectRows(ctx, `
+		SELECT network, protocol, address, fee, base_token_address, quote_token_address, last_price, version FROM pools`,
+		w, orderBy+page, func(rs *sql.Rows) error {
+			var r row
+			if err := rs.Scan(&r.snap.Network, &r.snap.Protocol, &r.snap.Address, &r.snap.Fee,
+				&r.baseAddress, &r.quoteAddress, &r.lastPrice, &r.snap.Version); err != nil {
+				return err
+			}
+			rows = append(rows, r)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select pools: %w", err)
+	}
+
+	pp := make([]*pool.Pool, 0, len(rows))
+	for _, r := range rows {
+		k := poolKey(r.snap.Network, r.snap.Protocol, r.snap.Address)
+		if _, ok := l.pools[k]; !ok {
+			base, err := l.token(ctx, r.snap.Network, r.baseAddress)
+			if err != nil {
+				return nil, fmt.Errorf("pool %s base token: %w", r.snap.Address, err)
+			}
+			quote, err := l.token(ctx, r.snap.Network, r.quoteAddress)
+			if err != nil {
+				return nil, fmt.Errorf("pool %s quote token: %w", r.snap.Address, err)
+			}
+
+			d := &decoder{}
+			r.snap.Pair = token.NewPair(base, quote)
+			r.snap.LastPrice = d.price(r.snap.Pair, r.lastPrice)
+			if d.err != nil {
+				return nil, fmt.Errorf("pool %s: %w", r.snap.Address, d.err)
+			}
+			l.pools[k] = pool.Restore(r.snap)
+		}
+		pp = append(pp, l.pools[k])
+	}
+	return pp, nil
+}
+
+func (l *loader) pool(ctx context.Context, network, protocol, address string) (*pool.Pool, error) {
+	if p, ok := l.pools[poolKey(network, protocol, address)]; ok {
+		return p, nil
+	}
+	pp, err := l.findPools(ctx, pool.Filter{
+		Networks:  []string{network},
+		Protocols: []string{protocol},
+		Addresses: []string{address},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pp) == 0 {
+		return nil, fmt.Errorf("%w: %s", pool.ErrNotFound, poolKey(network, protocol, address))
+	}
+	return pp[0], nil
+}
+
+func tokenTuple(t *token.Token) []any {
+	return []any{t.Network(), t.Address()}
+}
@@ -0,0 +1,200 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+)
+
+var positionOrder = map[position.OrderBy]string{
+	position.OrderByCreatedAt: "created_at",
+	// the open positions go last in both orders
+	position.OrderByClosedAt: "closed_at IS NULL, closed_at",
+}
+
+type positionRepository struct {
+	db *DB
+}
+
+// NewPositionRepository creates a position repository
+func NewPositionRepository(db *DB) position.Repository {
+	return &positionRepository{db: db}
+}
+
+// FindOne finds the first position matching the filter
+func (r *positionRepository) FindOne(ctx context.Context, filter position.Filter) (*position.Position, error) {
+	pp, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(pp) == 0 {
+		return nil, position.ErrNotFound
+	}
+	return pp[0], nil
+}
+
+// Find finds the positions matching the filter
+func (r *positionRepository) Find(ctx context.Context, filter position.Filter) ([]*position.Position, error) {
+	return r.db.loader().findPositions(ctx, filter)
+}
+
+// Save saves the position unless the stored position was changed since the position was loaded
+func (r *positionRepository) Save(ctx context.Context, p *position.Position) error {
+	err := r.db.execVersioned(ctx, r.db.db, position.ErrConflict, `
+		INSERT INTO positions (pool_network, pool_protocol, pool_address, address, project_id,
+			lower_price, upper_price, lower_tick, upper_tick, initial_price, liquidity,
+			in_base_amount, in_quote_amount, out_base_amount, out_quote_amount, status, transaction_fee,
+			created_at, closed_at, current_price, current_base_amount, current_quote_amount,
+			current_base_accrued_fees, current_quote_accrued_fees, out_of_range_since, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25,
+			$26)
+		ON CONFLICT (pool_network, pool_protocol, pool_address, address) DO UPDATE SET
+			liquidity = excluded.liquidity, out_base_amount = excluded.out_base_amount,
+			out_quote_amount = excluded.out_quote_amount, status = excluded.status,
+			transaction_fee = excluded.transaction_fee, closed_at = excluded.closed_at,
+			current_price = excluded.current_price, current_base_amount = excluded.current_base_amount,
+			current_quote_amount = excluded.current_quote_amount,
+			current_base_accrued_fees = excluded.current_base_accrued_fees,
+			current_quote_accrued_fees = excluded.current_quote_accrued_fees,
+			out_of_range_since = excluded.out_of_range_since, version = excluded.version
+		WHERE positions.version = excluded.version - 1`,
+		p.Pool().Network(), p.Pool().Protocol(), p.Pool().Address(), p.Address(), p.Project().ID(),
+		encodePrice(p.LowerPrice()), encodePrice(p.UpperPrice()), p.LowerTick(), p.UpperTick(),
+		encodePrice(p.InitialPrice()), encodeInt(p.Liquidity()),
+		encodeAmount(p.InputBaseAmount()), encodeAmount(p.InputQuoteAmount()),
+		encodeAmount(p.OutputBaseAmount()), encodeAmount(p.OutputQuoteAmount()),
+		string(p.Status()), encodeAmount(p.TransactionFee()), encodeTime(p.CreatedAt()), encodeNullTime(p.ClosedAt()),
+		encodePrice(p.CurrentPrice()), encodeAmount(p.CurrentBaseAmount()), encodeAmount(p.CurrentQuoteAmount()),
+		encodeAmount(p.CurrentBaseAccruedFees()), encodeAmount(p.CurrentQuoteAccruedFees()), encodeNullTime(p.OutOfRangeSince()),
+		p.Version()+1)
+	if err != nil {
+		return fmt.Errorf("save position %s: %w", p.Address(), err)
+	}
+	p.SetVersion(p.Version() + 1)
+	return nil
+}
+
+func (l *loader) findPositions(ctx context.Context, filter position.Filter) ([]*position.Position, error) {
+	w := &where{}
+	w.in("project_id", args(filter.Projects, func(p *project.Project) any { return p.ID() }))
+	w.inTuples([]string{"pool_network", "pool_protocol", "pool_address"}, tuples(filter.Pools, poolTuple))
+	w.in("address", args(filter.Addresses, asIs[string]))
+	w.in("status", args(filter.Statuses, func(s position.Status) any { return string(s) }))
+	w.between("created_at", filter.CreatedFrom, filter.CreatedTo)
+	w.between("closed_at", filter.ClosedFrom, filter.ClosedTo)
+
+	orderBy, err := orderClause(filter.OrderBy, positionOrder, position.OrderByCreatedAt, "pool_network, pool_protocol, pool_address, address")
+	if err != nil {
+		return nil, err
+	}
+	page, err := l.db.pageClause(filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	type row struct {
+		snap                                                 position.Snapshot
+		poolNetwork, poolProtocol, poolAddress               string
+		projectID                                            uuid.UUID
+		lowerPrice, upperPrice, initialPrice, currentPrice   string
+		liquidity, transactionFee                            string
+		inBase, inQuote, outBase, outQuote                   string
+		currentBase, currentQuote, baseAccrued, quoteAccrued string
+	}
+	var rows []row
+	err = l.db.selectRows(ctx, `
+		SELECT pool_network, pool_protocol, pool_address, address, project_id,
+			lower_price, upper_price, lower_tick, upper_tick, initial_price, liquidity,
+			in_base_amount, in_quote_amount, out_base_amount, out_quote_amount, status, transaction_fee,
+			created_at, closed_at, current_price, current_base_amount, current_quote_amount,
+			current_base_accrued_fees, current_quote_accrued_fees, out_of_range_since, version
+		FROM positions`,
+		w, orderBy+page, func(rs *sql.Rows) error {
+			var (
+				r               row
+				closedAt        sql.NullTime
+				outOfRangeSince sql.NullTime
+			)
+			if err := rs.Scan(&r.poolNetwork, &r.poolProtocol, &r.poolAddress, &r.snap.Address, &r.projectID,
+				&r.lowerPrice, &r.upperPrice, &r.snap.LowerTick, &r.snap.UpperTick, &r.initialPrice, &r.liquidity,
+				&r.inBase, &r.inQuote, &r.outBase, &r.outQuote, &r.snap.Status, &r.transactionFee,
+				&r.snap.CreatedAt, &closedAt, &r.currentPrice, &r.currentBase, &r.currentQuote,
+				&r.baseAccrued, &r.quoteAccrued, &outOfRangeSince, &r.snap.Version); err != nil {
+				return err
+			}
+			if closedAt.Valid {
+				r.snap.ClosedAt = &closedAt.Time
+			}
+			if outOfRangeSince.Valid {
+				r.snap.OutOfRangeSince = &outOfRangeSince.Time
+			}
+			rows = append(rows, r)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select positions: %w", err)
+	}
+
+	pp := make([]*position.Position, 0, len(rows))
+	for _, r := range rows {
+		k := positionKey(r.poolNetwork, r.poolProtocol, r.poolAddress, r.snap.Address)
+		if _, ok := l.positions[k]; !ok {
+			if r.snap.Project, err = l.project(ctx, r.projectID); err != nil {
+				return nil, fmt.Errorf("position %s project: %w", r.snap.Address, err)
+			}
+			if r.snap.Pool, err = l.pool(ctx, r.poolNetwork, r.poolProtocol, r.poolAddress); err != nil {
+				return nil, fmt.Errorf("position %s pool: %w", r.snap.Address, err)
+			}
+
+			pair := r.snap.Pool.Pair()
+			base, quote, native := pair.BaseToken(), pair.QuoteToken(), r.snap.Project.Wallet().NativeToken()
+
+			d := &decoder{}
+			r.snap.LowerPrice = d.price(pair, r.lowerPrice)
+			r.snap.UpperPrice = d.price(pair, r.upperPrice)
+			r.snap.InitialPrice = d.price(pair, r.initialPrice)
+			r.snap.CurrentPrice = d.price(pair, r.currentPrice)
+			r.snap.Liquidity = d.int(r.liquidity)
+			r.snap.InBaseAmount = d.amount(base, r.inBase)
+			r.snap.InQuoteAmount = d.amount(quote, r.inQuote)
+			r.snap.OutBaseAmount = d.amount(base, r.outBase)
+			r.snap.OutQuoteAmount = d.amount(quote, r.outQuote)
+			r.snap.TransactionFee = d.amount(native, r.transactionFee)
+			r.snap.CurrentBaseAmount = d.amount(base, r.currentBase)
+			r.snap.CurrentQuoteAmount = d.amount(quote, r.currentQuote)
+			r.snap.CurrentBaseAccruedFees = d.amount(base, r.baseAccrued)
+			r.snap.CurrentQuoteAccruedFees = d.amount(quote, r.quoteAccrued)
+			if d.err != nil {
+				return nil, fmt.Errorf("position %s: %w", r.snap.Address, d.err)
+			}
+			l.positions[k] = position.Restore(r.snap)
+		}
+		pp = append(pp, l.positions[k])
+	}
+	return pp, nil
+}
+
+func (l *loader) position(ctx context.Context, poolNetwork, poolProtocol, poolAddress, address string) (*position.Position, error) {
+	k := positionKey(poolNetwork, poolProtocol, poolAddress, address)
+	if p, ok := l.positions[k]; ok {
+		return p, nil
+	}
+	p, err := l.pool(ctx, poolNetwork, poolProtocol, poolAddress)
+	if err != nil {
+		return nil, err
+	}
+	pp, err := l.findPositions(ctx, position.Filter{Pools: []*pool.Pool{p}, Addresses: []string{address}})
+	if err != nil {
+		return nil, err
+	}
+	if len(pp) == 0 {
+		return nil, fmt.Errorf("%w: %s", position.ErrNotFound, k)
+	}
+	return pp[0], nil
+}
@@ -0,0 +1,110 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+)
+
+// positionAddresses lists the addresses of the positions in their order
+func positionAddresses(pp []*position.Position) []string {
+	addresses := make([]string, len(pp))
+	for i, p := range pp {
+		addresses[i] = p.Address()
+	}
+	return addresses
+}
+
+func TestPositionRepository(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *DB) {
+		ctx := context.Background()
+		f := newFixture()
+		repo := NewPositionRepository(db)
+
+		createdAt := time.Now().UTC().Truncate(time.Second)
+		proj := f.project("eth/usdc", 1000, createdAt)
+		idle := f.project("idle", 1000, createdAt)
+		for _, p := range []*project.Project{proj, idle} {
+			if err := NewProjectRepository(db).Save(ctx, p); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		closedAt1, closedAt2 := createdAt.Add(time.Hour), createdAt.Add(2*time.Hour)
+		open := f.position(proj, "1", 0, 200, 0, 0, nil)
+		early := f.position(proj, "2", 100, 300, 50, 420, &closedAt1)
+		late := f.position(proj, "3", 10, 30, 5, 42, &closedAt2)
+		for _, p := range []*position.Position{late, open, early} {
+			if err := repo.Save(ctx, p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Version() != 1 {
+				t.Fatalf("position %s saved at version %d", p.Address(), p.Version())
+			}
+		}
+
+		stored, err := repo.FindOne(ctx, position.Filter{Addresses: []string{"2"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status() != position.Closed || stored.InputQuoteAmount().Cmp(early.InputQuoteAmount()) != 0 ||
+			stored.OutputQuoteAmount().Cmp(early.OutputQuoteAmount()) != 0 || stored.Liquidity().Cmp(early.Liquidity()) != 0 ||
+			stored.ClosedAt() == nil || !stored.ClosedAt().Equal(closedAt1) || stored.LowerTick() != -100 ||
+			stored.Project().ID() != proj.ID() || stored.Version() != 1 {
+			t.Fatalf("unexpected stored position %+v", stored.Snapshot())
+		}
+		if _, err = repo.FindOne(ctx, position.Filter{Addresses: []string{"4"}}); !errors.Is(err, position.ErrNotFound) {
+			t.Fatalf("expected the not found error, got %v", err)
+		}
+
+		tests := []struct {
+			name   string
+			filter position.Filter
+			want   []string
+		}{
+			{"all", position.Filter{}, []string{"1", "2", "3"}},
+			{"status", position.Filter{Statuses: []position.Status{position.Closed}}, []string{"2", "3"}},
+			{"addresses", position.Filter{Addresses: []string{"3", "1"}}, []string{"1", "3"}},
+			{"project", position.Filter{Projects: []*project.Project{proj}}, []string{"1", "2", "3"}},
+			{"other project", position.Filter{Projects: []*project.Project{idle}}, []string{}},
+			{"closed", position.Filter{ClosedFrom: closedAt2}, []string{"3"}},
+			{"closed before", position.Filter{ClosedTo: closedAt2}, []string{"2"}},
+			{"by closed desc", position.Filter{Statuses: []position.Status{position.Closed}, OrderBy: position.OrderByClosedAtDesc}, []string{"3", "2"}},
+			{"page", position.Filter{Limit: 1, Offset: 1}, []string{"2"}},
+			{"offset", position.Filter{Offset: 1}, []string{"2", "3"}},
+		}
+		for _, tt := range tests {
+			pp, err := repo.Find(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if got := positionAddresses(pp); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+		}
+
+		// the position loaded before a concurrent save is not saved over it
+		first, err := repo.FindOne(ctx, position.Filter{Addresses: []string{"1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := repo.FindOne(ctx, position.Filter{Addresses: []string{"1"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = repo.Save(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+		if err = repo.Save(ctx, second); !errors.Is(err, position.ErrConflict) {
+			t.Fatalf("expected the conflict, got %v", err)
+		}
+		if err = repo.Save(ctx, first); err != nil || first.Version() != 3 {
+			t.Fatalf("save after the conflict: version %d, %v", first.Version(), err)
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+var postgres = dialect{
+	name:           "postgres",
+	lockMigrations: `LOCK TABLE schema_migrations IN EXCLUSIVE MODE`,
+	rebind:         func(query string) string { return query },
+	noLimit:        "ALL",
+}
+
+// OpenPostgres connects to the PostgreSQL database by the DSN and applies the pending migrations,
+// the key store imports the plain wallet keys of the databases created before the key store and may be nil otherwise
+func OpenPostgres(ctx context.Context, dsn string, keys wallet.KeyStore) (*DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+	return open(ctx, db, postgres, keys)
+}
@@ -0,0 +1,349 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+var projectOrder = map[project.OrderBy]string{
+	project.OrderByName:      "name",
+	project.OrderByCreatedAt: "created_at",
+}
+
+type projectRepository struct {
+	db *DB
+}
+
+// NewProjectRepository creates a project repository
+func NewProjectRepository(db *DB) project.Repository {
+	return &projectRepository{db: db}
+}
+
+// FindOne finds the first project matching the filter
+func (r *projectRepository) FindOne(ctx context.Context, filter project.Filter) (*project.Project, error) {
+	pp, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(pp) == 0 {
+		return nil, project.ErrNotFound
+	}
+	return pp[0], nil
+}
+
+// Find finds the projects matching the filter
+func (r *projectRepository) Find(ctx context.Context, filter project.Filter) ([]*project.Project, error) {
+	return r.db.loader().findProjects(ctx, filter)
+}
+
+// Save saves the project unless the stored project was changed since the project was loaded,
+// the wallet and the pool of the project are inserted unless they are already stored
+func (r *projectRepository) Save(ctx context.Context, p *project.Project) error {
+	err := r.db.inTx(ctx, func(tx *sql.Tx) error {
+		if err := r.db.saveWallet(ctx, tx, p.Wallet(), false); err != nil {
+			return err
+		}
+		if err := r.db.savePool(ctx, tx, p.Pool(), false); err != nil {
+			return err
+		}
+		if err := r.db.ensureToken(ctx, tx, p.Investments().Token()); err != nil {
+			return err
+		}
+
+		rangeKind, rangeParams, err := encodeRangeStrategy(p)
+		if err != nil {
+			return err
+		}
+
+		err = r.db.execVersioned(ctx, tx, project.ErrConflict, `
+			INSERT INTO projects (id, wallet_network, wallet_address, pool_network, pool_protocol, pool_address, name,
+				investments_token_address, investments, take_profit, stop_loss, range_volatility, slippage,
+				active_positions, convert_on_exit, current_value, trailing_stop, max_drawdown, max_holding_duration,
+				peak_value, status, inactive_reason, created_at, deactivated_at, range_strategy, range_params,
+				rebalance_buffer, rebalance_min_out_of_range, rebalance_cooldown, rebalance_max_per_day, idle_base,
+				idle_quote, version)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
+				$22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33)
+			ON CONFLICT (id) DO UPDATE SET name = excluded.name,
+				take_profit = excluded.take_profit, stop_loss = excluded.stop_loss,
+				range_volatility = excluded.range_volatility, slippage = excluded.slippage,
+				active_positions = excluded.active_positions, convert_on_exit = excluded.convert_on_exit,
+				current_value = excluded.current_value, trailing_stop = excluded.trailing_stop,
+				max_drawdown = excluded.max_drawdown, max_holding_duration = excluded.max_holding_duration,
+				peak_value = excluded.peak_value, status = excluded.status, inactive_reason = excluded.inactive_reason,
+				deactivated_at = excluded.deactivated_at, range_strategy = excluded.range_strategy,
+				range_params = excluded.range_params, rebalance_buffer = excluded.rebalance_buffer,
+				rebalance_min_out_of_range = excluded.rebalance_min_out_of_range,
+				rebalance_cooldown = excluded.rebalance_cooldown, rebalance_max_per_day = excluded.rebalance_max_per_day,
+				idle_base = excluded.idle_base, idle_quote = excluded.idle_quote, version = excluded.version
+			WHERE projects.version = excluded.version - 1`,
+			p.ID(), p.Wallet().NetworkId(), p.Wallet().Address(), p.Pool().Network(), p.Pool().Protocol(), p.Pool().Address(),
+			p.Name(), p.Investments().Token().Address(), encodeAmount(p.Investments()), p.TakeProfit().Value(),
+			p.StopLoss().Value(), p.RangeVolatility().Value(), p.Slippage().Value(), p.ActivePositions(), p.ConvertOnExit(),
+			encodeAmount(p.CurrentValue()), p.TrailingStop().Value(), p.MaxDrawdown().Value(),
+			int64(p.MaxHoldingDuration()), encodeAmount(p.PeakValue()), string(p.Status()), string(p.InactiveReason()),
+			encodeTime(p.CreatedAt()), encodeNullTime(p.DeactivatedAt()), rangeKind, rangeParams,
+			p.RebalancePolicy().Buffer.Value(), int64(p.RebalancePolicy().MinOutOfRange),
+			int64(p.RebalancePolicy().Cooldown), p.RebalancePolicy().MaxPerDay, encodeAmount(p.IdleBase()),
+			encodeAmount(p.IdleQuote()), p.Version()+1)
+		if err != nil {
+			return fmt.Errorf("save project %s: %w", p.ID(), err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	p.SetVersion(p.Version() + 1)
+	return nil
+}
+
+func (l *loader) findProjects(ctx context.Context, filter project.Filter) ([]*project.Project, error) {
+	w := &where{}
+	w.in("id", args(filter.Ids, asIs[uuid.UUID]))
+	w.inTuples([]string{"wallet_network", "wallet_address"}, tuples(filter.Wallets, func(wa *wallet.Wallet) []any {
+		return []any{wa.NetworkId(), wa.Address()}
+	}))
+	w.inTuples([]string{"pool_network", "pool_protocol", "pool_address"}, tuples(filter.Pools, poolTuple))
+	w.in("status", args(filter.Statuses, func(s project.Status) any { return string(s) }))
+	w.in("inactive_reason", args(filter.Reasons, func(r project.InactiveReason) any { return string(r) }))
+	w.between("created_at", filter.CreatedFrom, filter.CreatedTo)
+
+	orderBy, err := orderClause(filter.OrderBy, projectOrder, project.OrderByCreatedAt, "id")
+	if err != nil {
+		return nil, err
+	}
+	page, err := l.db.pageClause(filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	type row struct {
+		snap                                   project.Snapshot
+		walletNetwork, walletAddress           string
+		poolNetwork, poolProtocol, poolAddress string
+		tokenAddress, investments, value, peak string
+		rangeKind, rangeParams                 string
+		idleBase, idleQuote                    string
+	}
+	var rows []row
+	err = l.db.selectRows(ctx, `
+		SELECT id, wallet_network, wallet_address, pool_network, pool_protocol, pool_address, name,
+			investments_token_address, investments, take_profit, stop_loss, range_volatility, slippage,
+			active_positions, convert_on_exit, current_value, trailing_stop, max_drawdown, max_holding_duration,
+			peak_value, status, inactive_reason, created_at, deactivated_at, range_strategy, range_params,
+			rebalance_buffer, rebalance_min_out_of_range, rebalance_cooldown, rebalance_max_per_day, idle_base,
+			idle_quote, version
+		FROM projects`,
+		w, orderBy+page, func(rs *sql.Rows) error {
+			var (
+				r             row
+				deactivatedAt sql.NullTime
+			)
+			if err := rs.Scan(&r.snap.ID, &r.walletNetwork, &r.walletAddress, &r.poolNetwork, &r.poolProtocol,
+				&r.poolAddress, &r.snap.Name, &r.tokenAddress, &r.investments, &r.snap.TakeProfit, &r.snap.StopLoss,
+				&r.snap.RangeVolatility, &r.snap.Slippage, &r.snap.ActivePositions, &r.snap.ConvertOnExit, &r.value,
+				&r.snap.TrailingStop, &r.snap.MaxDrawdown, &r.snap.MaxHoldingDuration, &r.peak, &r.snap.Status,
+				&r.snap.InactiveReason, &r.snap.CreatedAt, &deactivatedAt, &r.rangeKind, &r.rangeParams,
+				&r.snap.RebalancePolicy.Buffer, &r.snap.RebalancePolicy.MinOutOfRange, &r.snap.RebalancePolicy.Cooldown,
+				&r.snap.RebalancePolicy.MaxPerDay, &r.idleBase, &r.idleQuote, &r.snap.Version); err != nil {
+				return err
+			}
+			if deactivatedAt.Valid {
+				r.snap.DeactivatedAt = &deactivatedAt.Time
+			}
+			rows = append(rows, r)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select projects: %w", err)
+	}
+
+	pp := make([]*project.Project, 0, len(rows))
+	for _, r := range rows {
+		if _, ok := l.projects[r.snap.ID]; !ok {
+			if r.snap.Wallet, err = l.wallet(ctx, r.walletNetwork, r.walletAddress); err != nil {
+				return nil, fmt.Errorf("project %s wallet: %w", r.snap.ID, err)
+			}
+			if r.snap.Pool, err = l.pool(ctx, r.poolNetwork, r.poolProtocol, r.poolAddress); err != nil {
+				return nil, fmt.Errorf("project %s pool: %w", r.snap.ID, err)
+			}
+			t, err := l.token(ctx, r.poolNetwork, r.tokenAddress)
+			if err != nil {
+				return nil, fmt.Errorf("project %s investments token: %w", r.snap.ID, err)
+			}
+
+			d := &decoder{}
+			r.snap.Investments = d.amount(t, r.investments)
+			r.snap.CurrentValue = d.amount(t, r.value)
+			r.snap.PeakValue = d.amount(t, r.peak)
+			r.snap.IdleBase = d.amount(r.snap.Pool.Pair().BaseToken(), r.idleBase)
+			r.snap.IdleQuote = d.amount(r.snap.Pool.Pair().QuoteToken(), r.idleQuote)
+			if d.err != nil {
+				return nil, fmt.Errorf("project %s: %w", r.snap.ID, d.err)
+			}
+			if r.rangeKind != "" {
+				r.snap.RangeStrategy, err = pool.UnmarshalRangeStrategy(pool.RangeKind(r.rangeKind), []byte(r.rangeParams),
+					r.snap.Pool.Pair())
+				if err != nil {
+					return nil, fmt.Errorf("project %s: %w", r.snap.ID, err)
+				}
+			}
+			l.projects[r.snap.ID] = project.Restore(r.snap)
+		}
+		pp = append(pp, l.projects[r.snap.ID])
+	}
+	return pp, nil
+}
+
+func (l *loader) project(ctx context.Context, id uuid.UUID) (*project.Project, error) {
+	if p, ok := l.projects[id]; ok {
+		return p, nil
+	}
+	pp, err := l.findProjects(ctx, project.Filter{Ids: []uuid.UUID{id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(pp) == 0 {
+		return nil, fmt.Errorf("%w: %s", project.ErrNotFound, id)
+	}
+	return pp[0], nil
+}
+
+// encodeRangeStrategy encodes the range strategy the project was created with, the empty kind is no strategy
+func encodeRangeStrategy(p *project.Project) (string, string, error) {
+	s := p.Snapshot().RangeStrategy
+	if s == nil {
+		return "", "", nil
+	}
+	params, err := pool.MarshalRangeStrategy(s)
+	if err != nil {
+		return "", "", fmt.Errorf("encode project %s range strategy: %w", p.ID(), err)
+	}
+	return string(s.Kind()), string(params), nil
+}
+
+func poolTuple(p *pool.Pool) []any {
+	return []any{p.Network(), p.Protocol(), p.Address()}
+}
+
+// idleFunds is the ledger of the pool tokens of a project held on the wallet outside of the positions
+type idleFunds struct {
+	base, quote             string
+	baseAmount, quoteAmount *big.Int
+}
+
+// add adds the amount of the token to the ledger, the sign of the amount is taken
+func (f *idleFunds) add(tokenAddress, amount string, sign int) error {
+	v, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %q", amount)
+	}
+	if sign < 0 {
+		v.Neg(v)
+	}
+	switch tokenAddress {
+	case f.base:
+		f.baseAmount.Add(f.baseAmount, v)
+	case f.quote:
+		f.quoteAmount.Add(f.quoteAmount, v)
+	}
+	return nil
+}
+
+// restoreIdleFunds restores the idle funds of the existing projects: the investments plus the swapped
+// and the collected amounts and the amounts returned by the closed positions minus the amounts put into
+// the positions and swapped, a negative balance of a token is spent elsewhere and becomes zero
+func (db *DB) restoreIdleFunds(ctx context.Context, tx *sql.Tx) error {
+	funds := make(map[string]*idleFunds)
+	err := db.queryRows(ctx, tx, `
+		SELECT p.id, p.investments_token_address, p.investments, pl.base_token_address, pl.quote_token_address
+		FROM projects p
+		JOIN pools pl ON pl.network = p.pool_network AND pl.protocol = p.pool_protocol AND pl.address = p.pool_address`,
+		nil, func(rs *sql.Rows) error {
+			var id, investmentsToken, investments string
+			f := &idleFunds{baseAmount: new(big.Int), quoteAmount: new(big.Int)}
+			if err := rs.Scan(&id, &investmentsToken, &investments, &f.base, &f.quote); err != nil {
+				return err
+			}
+			funds[id] = f
+			return f.add(investmentsToken, investments, 1)
+		})
+	if err != nil {
+		return fmt.Errorf("select projects: %w", err)
+	}
+	if len(funds) == 0 {
+		return nil
+	}
+
+	err = db.queryRows(ctx, tx, `
+		SELECT project_id, status, in_base_amount, in_quote_amount, out_base_amount, out_quote_amount FROM positions`,
+		nil, func(rs *sql.Rows) error {
+			var id, status, inBase, inQuote, outBase, outQuote string
+			if err := rs.Scan(&id, &status, &inBase, &inQuote, &outBase, &outQuote); err != nil {
+				return err
+			}
+			f := funds[id]
+			err := errors.Join(f.add(f.base, inBase, -1), f.add(f.quote, inQuote, -1))
+			if err == nil && status == string(position.Closed) {
+				err = errors.Join(f.add(f.base, outBase, 1), f.add(f.quote, outQuote, 1))
+			}
+			return err
+		})
+	if err != nil {
+		return fmt.Errorf("select positions: %w", err)
+	}
+
+	err = db.queryRows(ctx, tx, `SELECT project_id, token_in_address, amount_in, token_out_address, amount_out FROM orders`,
+		nil, func(rs *sql.Rows) error {
+			var id, tokenIn, amountIn, tokenOut, amountOut string
+			if err := rs.Scan(&id, &tokenIn, &amountIn, &tokenOut, &amountOut); err != nil {
+				return err
+			}
+			f := funds[id]
+			return errors.Join(f.add(tokenIn, amountIn, -1), f.add(tokenOut, amountOut, 1))
+		})
+	if err != nil {
+		return fmt.Errorf("select orders: %w", err)
+	}
+
+	err = db.queryRows(ctx, tx, `
+		SELECT ps.project_id, r.token_address, r.amount
+		FROM rewards r
+		JOIN positions ps ON ps.pool_network = r.pool_network AND ps.pool_protocol = r.pool_protocol
+			AND ps.pool_address = r.pool_address AND ps.address = r.position_address`,
+		nil, func(rs *sql.Rows) error {
+			var id, tokenAddress, amount string
+			if err := rs.Scan(&id, &tokenAddress, &amount); err != nil {
+				return err
+			}
+			return funds[id].add(tokenAddress, amount, 1)
+		})
+	if err != nil {
+		return fmt.Errorf("select rewards: %w", err)
+	}
+
+	for id, f := range funds {
+		for _, v := range []*big.Int{f.baseAmount, f.quoteAmount} {
+			if v.Sign() < 0 {
+				v.SetInt64(0)
+			}
+		}
+		err = db.exec(ctx, tx, `UPDATE projects SET idle_base = $1, idle_quote = $2 WHERE id = $3`,
+			f.baseAmount.String(), f.quoteAmount.String(), id)
+		if err != nil {
+			return fmt.Errorf("update project %s idle funds: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/order"
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/reward"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+func TestRestoreIdleFunds(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *DB) {
+		ctx := context.Background()
+		f := newFixture()
+
+		proj := f.project("eth/usdc", 1000, time.Now().UTC())
+		if err := NewProjectRepository(db).Save(ctx, proj); err != nil {
+			t.Fatal(err)
+		}
+		ord := order.Restore(order.Snapshot{
+			Project: proj, Pool: f.pool, Address: "0xo", Direction: order.Buy,
+			AmountIn: values.NewAmount(f.usdc, 300), AmountOut: values.NewAmount(f.weth, 100),
+			FilledPrice: values.NewPrice(f.pair, 2000), TransactionFee: values.NewAmount(f.weth, 1), CreatedAt: time.Now(),
+		})
+		if err := NewOrderRepository(db).Save(ctx, ord); err != nil {
+			t.Fatal(err)
+		}
+		closedAt := time.Now().UTC()
+		closed := f.position(proj, "1", 100, 300, 50, 420, &closedAt)
+		open := f.position(proj, "2", 0, 200, 0, 0, nil)
+		for _, pos := range []*position.Position{closed, open} {
+			if err := NewPositionRepository(db).Save(ctx, pos); err != nil {
+				t.Fatal(err)
+			}
+		}
+		rw := reward.Restore(reward.Snapshot{ID: uuid.New(), Position: closed, Amount: values.NewAmount(f.usdc, 7), CreatedAt: time.Now()})
+		if err := NewRewardRepository(db).Save(ctx, rw); err != nil {
+			t.Fatal(err)
+		}
+
+		err := db.inTx(ctx, func(tx *sql.Tx) error { return db.restoreIdleFunds(ctx, tx) })
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err := NewProjectRepository(db).FindOne(ctx, project.Filter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the base: 100 bought - 100 put into the positions + 50 returned,
+		// the quote: 1000 invested - 300 swapped - 500 put into the positions + 420 returned + 7 collected
+		if stored.IdleBase().Value().Int64() != 50 || stored.IdleQuote().Value().Int64() != 627 {
+			t.Fatalf("idle funds %s, %s", stored.IdleBase().Value(), stored.IdleQuote().Value())
+		}
+	})
+}
+
+// projectNames lists the names of the projects in their order
+func projectNames(pp []*project.Project) []string {
+	names := make([]string, len(pp))
+	for i, p := range pp {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+func TestProjectRepository(t *testing.T) {
+	forEachDialect(t, func(t *testing.T, db *DB) {
+		ctx := context.Background()
+		f := newFixture()
+		repo := NewProjectRepository(db)
+
+		createdAt := time.Now().UTC().Truncate(time.Second)
+		b := f.project("b", 1000, createdAt)
+		a := f.project("a", 2000, createdAt.Add(time.Hour))
+		snap := f.project("c", 3000, createdAt.Add(2*time.Hour)).Snapshot()
+		snap.Status = project.Inactive
+		c := project.Restore(snap)
+		for _, p := range []*project.Project{b, a, c} {
+			if err := repo.Save(ctx, p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Version() != 1 {
+				t.Fatalf("project %s saved at version %d", p.Name(), p.Version())
+			}
+		}
+
+		stored, err := repo.FindOne(ctx, project.Filter{Ids: []uuid.UUID{a.ID()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Name() != "a" || stored.Investments().Cmp(a.Investments()) != 0 || stored.IdleQuote().Cmp(a.IdleQuote()) != 0 ||
+			stored.Status() != project.Active || !stored.CreatedAt().Equal(a.CreatedAt()) || stored.Version() != 1 ||
+			stored.Pool().Address() != f.pool.Address() || stored.Wallet().Address() != f.wallet.Address() {
+			t.Fatalf("unexpected stored project %+v", stored.Snapshot())
+		}
+		if _, err = repo.FindOne(ctx, project.Filter{Ids: []uuid.UUID{uuid.New()}}); !errors.Is(err, project.ErrNotFound) {
+			t.Fatalf("expected the not found error, got %v", err)
+		}
+
+		other := pool.Restore(pool.Snapshot{Network: "eth", Protocol: "uniswap", Address: "0xq", Fee: pool.LowFee, Pair: f.pair})
+		tests := []struct {
+			name   string
+			filter project.Filter
+			want   []string
+		}{
+			{"all", project.Filter{}, []string{"b", "a", "c"}},
+			{"status", project.Filter{Statuses: []project.Status{project.Inactive}}, []string{"c"}},
+			{"created", project.Filter{CreatedFrom: createdAt.Add(time.Hour), CreatedTo: createdAt.Add(2 * time.Hour)}, []string{"a"}},
+			{"ids", project.Filter{Ids: []uuid.UUID{c.ID(), b.ID()}}, []string{"b", "c"}},
+			{"wallet", project.Filter{Wallets: []*wallet.Wallet{f.wallet}}, []string{"b", "a", "c"}},
+			{"other pool", project.Filter{Pools: []*pool.Pool{other}}, []string{}},
+			{"by name", project.Filter{OrderBy: project.OrderByName}, []string{"a", "b", "c"}},
+			{"by created desc", project.Filter{OrderBy: project.OrderByCreatedAtDesc}, []string{"c", "a", "b"}},
+			{"page", project.Filter{Limit: 2, Offset: 1}, []string{"a", "c"}},
+			{"offset", project.Filter{Offset: 2}, []string{"c"}},
+			{"past the end", project.Filter{Limit: 2, Offset: 3}, []string{}},
+		}
+		for _, tt := range tests {
+			pp, err := repo.Find(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if got := projectNames(pp); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+		}
+		if _, err = repo.Find(ctx, project.Filter{Limit: -1}); err == nil {
+			t.Error("expected the negative limit error")
+		}
+		if _, err = repo.Find(ctx, project.Filter{OrderBy: "status"}); err == nil {
+			t.Error("expected the unsupported order error")
+		}
+
+		// the project loaded before a concurrent save is not saved over it
+		first, err := repo.FindOne(ctx, project.Filter{Ids: []uuid.UUID{b.ID()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := repo.FindOne(ctx, project.Filter{Ids: []uuid.UUID{b.ID()}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = repo.Save(ctx, first); err != nil {
+			t.Fatal(err)
+		}
+		if err = repo.Save(ctx, second); !errors.Is(err, project.ErrConflict) {
+			t.Fatalf("expected the conflict, got %v", err)
+		}
+		if second.Version() != 1 {
+			t.Fatalf("the conflicting project moved to version %d", second.Version())
+		}
+		if err = repo.Save(ctx, first); err != nil || first.Version() != 3 {
+			t.Fatalf("save after the conflict: version %d, %v", first.Version(), err)
+		}
+	})
+}
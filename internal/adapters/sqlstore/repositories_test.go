@@ -0,0 +1,29 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/r1der/epos/internal/adapters/repotest"
+)
+
+// repositories opens the repositories of the database
+func repositories(db *DB) repotest.Repositories {
+	return repotest.Repositories{
+		Tokens:    NewTokenRepository(db),
+		Wallets:   NewWalletRepository(db),
+		Pools:     NewPoolRepository(db),
+		Projects:  NewProjectRepository(db),
+		Positions: NewPositionRepository(db),
+		Orders:    NewOrderRepository(db),
+		Rewards:   NewRewardRepository(db),
+	}
+}
+
+func TestRepositories(t *testing.T) {
+	t.Run(sqlite.name, func(t *testing.T) {
+		repotest.Run(t, func(t *testing.T) repotest.Repositories { return repositories(openTestSQLite(t)) })
+	})
+	t.Run(postgres.name, func(t *testing.T) {
+		repotest.Run(t, func(t *testing.T) repotest.Repositories { return repositories(openTestPostgres(t)) })
+	})
+}
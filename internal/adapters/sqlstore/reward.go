@@ -0,0 +1,134 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/reward"
+)
+
+var rewardOrder = map[reward.OrderBy]string{
+	reward.OrderByCreatedAt: "created_at",
+}
+
+type rewardRepository struct {
+	db *DB
+}
+
+// NewRewardRepository creates a reward repository
+func NewRewardRepository(db *DB) reward.Repository {
+	return &rewardRepository{db: db}
+}
+
+// FindOne finds the first reward matching the filter
+func (r *rewardRepository) FindOne(ctx context.Context, filter reward.Filter) (*reward.Reward, error) {
+	rr, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(rr) == 0 {
+		return nil, reward.ErrNotFound
+	}
+	return rr[0], nil
+}
+
+// Find finds the rewards matching the filter
+func (r *rewardRepository) Find(ctx context.Context, filter reward.Filter) ([]*reward.Reward, error) {
+	return r.db.loader().findRewards(ctx, filter)
+}
+
+// Save saves the rewards in one transaction, none of them is saved when one of them conflicts
+func (r *rewardRepository) Save(ctx context.Context, rewards ...*reward.Reward) error {
+	err := r.db.inTx(ctx, func(tx *sql.Tx) error {
+		for _, rw := range rewards {
+			if err := r.db.ensureToken(ctx, tx, rw.Amount().Token()); err != nil {
+				return err
+			}
+
+			pos := rw.Position()
+			err := r.db.execVersioned(ctx, tx, reward.ErrConflict, `
+				INSERT INTO rewards (id, pool_network, pool_protocol, pool_address, position_address,
+					token_address, amount, created_at, version)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+				ON CONFLICT (id) DO UPDATE SET amount = excluded.amount, version = excluded.version
+				WHERE rewards.version = excluded.version - 1`,
+				rw.ID(), pos.Pool().Network(), pos.Pool().Protocol(), pos.Pool().Address(), pos.Address(),
+				rw.Amount().Token().Address(), encodeAmount(rw.Amount()), encodeTime(rw.CreatedAt()), rw.Version()+1)
+			if err != nil {
+				return fmt.Errorf("save reward %s: %w", rw.ID(), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, rw := range rewards {
+		rw.SetVersion(rw.Version() + 1)
+	}
+	return nil
+}
+
+func (l *loader) findRewards(ctx context.Context, filter reward.Filter) ([]*reward.Reward, error) {
+	w := &where{}
+	w.inTuples([]string{"pool_network", "pool_protocol", "pool_address", "position_address"},
+		tuples(filter.Positions, func(p *position.Position) []any {
+			return []any{p.Pool().Network(), p.Pool().Protocol(), p.Pool().Address(), p.Address()}
+		}))
+	w.inTuples([]string{"pool_network", "token_address"}, tuples(filter.Tokens, tokenTuple))
+	w.between("created_at", filter.CreatedFrom, filter.CreatedTo)
+
+	orderBy, err := orderClause(filter.OrderBy, rewardOrder, reward.OrderByCreatedAt, "id")
+	if err != nil {
+		return nil, err
+	}
+	page, err := l.db.pageClause(filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	type row struct {
+		snap                                                    reward.Snapshot
+		poolNetwork, poolProtocol, poolAddress, positionAddress string
+		tokenAddress, amount                                    string
+	}
+	var rows []row
+	err = l.db.selectRows(ctx, `
+		SELECT id, pool_network, pool_protocol, pool_address, position_address, token_address, amount, created_at,
+			version
+		FROM rewards`,
+		w, orderBy+page, func(rs *sql.Rows) error {
+			var r row
+			if err := rs.Scan(&r.snap.ID, &r.poolNetwork, &r.poolProtocol, &r.poolAddress, &r.positionAddress,
+				&r.tokenAddress, &r.amount, &r.snap.CreatedAt, &r.snap.Version); err != nil {
+				return err
+			}
+			rows = append(rows, r)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select rewards: %w", err)
+	}
+
+	rr := make([]*reward.Reward, 0, len(rows))
+	for _, r := range rows {
+		r.snap.Position, err = l.position(ctx, r.poolNetwork, r.poolProtocol, r.poolAddress, r.positionAddress)
+		if err != nil {
+			return nil, fmt.Errorf("reward %s position: %w", r.snap.ID, err)
+		}
+		t, err := l.token(ctx, r.poolNetwork, r.tokenAddress)
+		if err != nil {
+			return nil, fmt.Errorf("reward %s token: %w", r.snap.ID, err)
+		}
+
+		d := &decoder{}
+		r.snap.Amount = d.amount(t, r.amount)
+		if d.err != nil {
+			return nil, fmt.Errorf("reward %s: %w", r.snap.ID, d.err)
+		}
+		rr = append(rr, reward.Restore(r.snap))
+	}
+	return rr, nil
+}
@@ -0,0 +1,41 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+var placeholder = regexp.MustCompile(`\$(\d+)`)
+
+// sqlite needs no migrations lock, the immediate transactions already serialize the writers
+var sqlite = dialect{
+	name:    "sqlite",
+	rebind:  func(query string) string { return placeholder.ReplaceAllString(query, "?$1") },
+	noLimit: "-1",
+}
+
+// OpenSQLite opens the single file SQLite database creating it when missing and applies the pending migrations.
+// The database runs in the WAL mode, so the readers of several processes do not block the writer,
+// and the writers wait for each other up to the busy timeout instead of failing.
+// The key store imports the plain wallet keys of the databases created before the key store and may be nil otherwise
+func OpenSQLite(ctx context.Context, path string, keys wallet.KeyStore) (*DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "busy_timeout(10000)")
+	params.Add("_pragma", "foreign_keys(ON)")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	params.Set("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	return open(ctx, db, sqlite, keys)
+}
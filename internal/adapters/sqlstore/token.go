@@ -0,0 +1,116 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+)
+
+var tokenOrder = map[token.OrderBy]string{
+	token.OrderByNetwork: "network",
+	token.OrderByTicker:  "ticker",
+}
+
+type tokenRepository struct {
+	db *DB
+}
+
+// NewTokenRepository creates a token repository
+func NewTokenRepository(db *DB) token.Repository {
+	return &tokenRepository{db: db}
+}
+
+// FindOne finds the first token matching the filter
+func (r *tokenRepository) FindOne(ctx context.Context, filter token.Filter) (*token.Token, error) {
+	tt, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(tt) == 0 {
+		return nil, token.ErrNotFound
+	}
+	return tt[0], nil
+}
+
+// Find finds the tokens matching the filter
+func (r *tokenRepository) Find(ctx context.Context, filter token.Filter) ([]*token.Token, error) {
+	return r.db.loader().findTokens(ctx, filter)
+}
+
+// Save saves the token
+func (r *tokenRepository) Save(ctx context.Context, t *token.Token) error {
+	err := r.db.exec(ctx, r.db.db, `
+		INSERT INTO tokens (network, address, ticker, decimals) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (network, address) DO UPDATE SET ticker = excluded.ticker, decimals = excluded.decimals`,
+		t.Network(), t.Address(), t.Ticker().String(), t.Decimals())
+	if err != nil {
+		return fmt.Errorf("save token %s: %w", t, err)
+	}
+	return nil
+}
+
+// ensureToken inserts the referenced token unless it is already stored
+func (db *DB) ensureToken(ctx context.Context, q queryer, t *token.Token) error {
+	err := db.exec(ctx, q, `
+		INSERT INTO tokens (network, address, ticker, decimals) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (network, address) DO NOTHING`,
+		t.Network(), t.Address(), t.Ticker().String(), t.Decimals())
+	if err != nil {
+		return fmt.Errorf("save token %s: %w", t, err)
+	}
+	return nil
+}
+
+func (l *loader) findTokens(ctx context.Context, filter token.Filter) ([]*token.Token, error) {
+	w := &where{}
+	w.in("network", args(filter.Networks, asIs[string]))
+	w.in("address", args(filter.Addresses, asIs[string]))
+	w.in("ticker", args(filter.Tickers, func(t token.Ticker) any { return t.String() }))
+
+	orderBy, err := orderClause(filter.OrderBy, tokenOrder, token.OrderByNetwork, "network, address")
+	if err != nil {
+		return nil, err
+	}
+	page, err := l.db.pageClause(filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	var tt []*token.Token
+	err = l.db.selectRows(ctx, `SELECT network, address, ticker, decimals FROM tokens`, w, orderBy+page, func(rows *sql.Rows) error {
+		var (
+			network, address, ticker string
+			decimals                 int
+		)
+		if err := rows.Scan(&network, &address, &ticker, &decimals); err != nil {
+			return err
+		}
+
+		k := tokenKey(network, address)
+		if _, ok := l.tokens[k]; !ok {
+			l.tokens[k] = token.New(network, address, token.Ticker(ticker), decimals)
+		}
+		tt = append(tt, l.tokens[k])
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("select tokens: %w", err)
+	}
+	return tt, nil
+}
+
+func (l *loader) token(ctx context.Context, network, address string) (*token.Token, error) {
+	if t, ok := l.tokens[tokenKey(network, address)]; ok {
+		return t, nil
+	}
+	tt, err := l.findTokens(ctx, token.Filter{Networks: []string{network}, Addresses: []string{address}})
+	if err != nil {
+		return nil, err
+	}
+	if len(tt) == 0 {
+		return nil, fmt.Errorf("%w: %s", token.ErrNotFound, tokenKey(network, address))
+	}
+	return tt[0], nil
+}
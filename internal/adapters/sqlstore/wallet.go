@@ -0,0 +1,186 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+)
+
+var walletOrder = map[wallet.OrderBy]string{
+	wallet.OrderByName:      "name",
+	wallet.OrderByCreatedAt: "created_at",
+}
+
+type walletRepository struct {
+	db *DB
+}
+
+// NewWalletRepository creates a wallet repository
+func NewWalletRepository(db *DB) wallet.Repository {
+	return &walletRepository{db: db}
+}
+
+// FindOne finds the first wallet matching the filter
+func (r *walletRepository) FindOne(ctx context.Context, filter wallet.Filter) (*wallet.Wallet, error) {
+	ww, err := r.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(ww) == 0 {
+		return nil, wallet.ErrNotFound
+	}
+	return ww[0], nil
+}
+
+// Find finds the wallets matching the filter
+func (r *walletRepository) Find(ctx context.Context, filter wallet.Filter) ([]*wallet.Wallet, error) {
+	return r.db.loader().findWallets(ctx, filter)
+}
+
+// Save saves the wallet unless the stored wallet was changed since the wallet was loaded
+func (r *walletRepository) Save(ctx context.Context, w *wallet.Wallet) error {
+	err := r.db.inTx(ctx, func(tx *sql.Tx) error {
+		return r.db.saveWallet(ctx, tx, w, true)
+	})
+	if err != nil {
+		return err
+	}
+	w.SetVersion(w.Version() + 1)
+	return nil
+}
+
+// saveWallet upserts the next version of the wallet,
+// the referenced wallet is only inserted at its current version unless it is already stored
+func (db *DB) saveWallet(ctx context.Context, q queryer, w *wallet.Wallet, update bool) error {
+	if err := db.ensureToken(ctx, q, w.NativeToken()); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO wallets (network, address, name, key_ref, native_token_address, created_at, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (network, address) `
+	params := []any{w.NetworkId(), w.Address(), w.Name(), w.KeyRef(), w.NativeToken().Address(),
+		encodeTime(w.CreatedAt()), w.Version()}
+
+	var err error
+	if update {
+		params[len(params)-1] = w.Version() + 1
+		err = db.execVersioned(ctx, q, wallet.ErrConflict, query+`DO UPDATE SET name = excluded.name,
+			key_ref = excluded.key_ref, native_token_address = excluded.native_token_address,
+			created_at = excluded.created_at, version = excluded.version
+			WHERE wallets.version = excluded.version - 1`, params...)
+	} else {
+		err = db.exec(ctx, q, query+`DO NOTHING`, params...)
+	}
+	if err != nil {
+		return fmt.Errorf("save wallet %s: %w", w.Address(), err)
+	}
+	return nil
+}
+
+func (l *loader) findWallets(ctx context.Context, filter wallet.Filter) ([]*wallet.Wallet, error) {
+	w := &where{}
+	w.in("network", args(filter.Networks, asIs[string]))
+	w.in("address", args(filter.Addresses, asIs[string]))
+	w.between("created_at", filter.CreatedFrom, filter.CreatedTo)
+
+	orderBy, err := orderClause(filter.OrderBy, walletOrder, wallet.OrderByCreatedAt, "network, address")
+	if err != nil {
+		return nil, err
+	}
+	page, err := l.db.pageClause(filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	type row struct {
+		snap               wallet.Snapshot
+		nativeTokenAddress string
+	}
+	var rows []row
+	err = l.db.selectRows(ctx, `SELECT network, address, name, key_ref, native_token_address, created_at, version FROM wallets`,
+		w, orderBy+page, func(rs *sql.Rows) error {
+			var r row
+			if err := rs.Scan(&r.snap.Network, &r.snap.Address, &r.snap.Name, &r.snap.KeyRef,
+				&r.nativeTokenAddress, &r.snap.CreatedAt, &r.snap.Version); err != nil {
+				return err
+			}
+			rows = append(rows, r)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("select wallets: %w", err)
+	}
+
+	ww := make([]*wallet.Wallet, 0, len(rows))
+	for _, r := range rows {
+		k := walletKey(r.snap.Network, r.snap.Address)
+		if _, ok := l.wallets[k]; !ok {
+			if r.snap.NativeToken, err = l.token(ctx, r.snap.Network, r.nativeTokenAddress); err != nil {
+				return nil, fmt.Errorf("wallet %s native token: %w", r.snap.Address, err)
+			}
+			l.wallets[k] = wallet.Restore(r.snap)
+		}
+		ww = append(ww, l.wallets[k])
+	}
+	return ww, nil
+}
+
+func (l *loader) wallet(ctx context.Context, network, address string) (*wallet.Wallet, error) {
+	if w, ok := l.wallets[walletKey(network, address)]; ok {
+		return w, nil
+	}
+	ww, err := l.findWallets(ctx, wallet.Filter{Networks: []string{network}, Addresses: []string{address}})
+	if err != nil {
+		return nil, err
+	}
+	if len(ww) == 0 {
+		return nil, fmt.Errorf("%w: %s", wallet.ErrNotFound, walletKey(network, address))
+	}
+	return ww[0], nil
+}
+
+// importPlainKeys moves the plain private keys of the wallets into the key store replacing them by the key references
+// before the private_key column becomes key_ref, the migration fails when plain keys are stored and no key store is given
+func (db *DB) importPlainKeys(ctx context.Context, tx *sql.Tx) error {
+	type plainKey struct {
+		network, address, key string
+	}
+	var keys []plainKey
+	err := db.queryRows(ctx, tx, `SELECT network, address, private_key FROM wallets`, nil, func(rs *sql.Rows) error {
+		var k plainKey
+		if err := rs.Scan(&k.network, &k.address, &k.key); err != nil {
+			return err
+		}
+		keys = append(keys, k)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("select wallet keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if db.keys == nil {
+		return fmt.Errorf("%d wallets keep plain private keys, open the database with the key store to import them", len(keys))
+	}
+
+	for _, k := range keys {
+		addr, keyRef, err := db.keys.Import(ctx, k.network, k.key)
+		if err != nil {
+			return fmt.Errorf("import wallet %s key: %w", k.address, err)
+		}
+		if !strings.EqualFold(addr, k.address) {
+			return fmt.Errorf("import wallet %s key: the key belongs to %s", k.address, addr)
+		}
+		err = db.exec(ctx, tx, `UPDATE wallets SET private_key = $1 WHERE network = $2 AND address = $3`, keyRef, k.network, k.address)
+		if err != nil {
+			return fmt.Errorf("replace wallet %s key: %w", k.address, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package starknet keeps the Starknet Stark curve wallet keys encrypted at rest and signs the Starknet
+// transaction hashes, the keys are encrypted with scrypt by the passphrase supplied at the startup like the EVM key store
+package starknet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fr"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/r1der/epos/internal/domain/entity/network"
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+// RefPrefix prefixes the key references of the keys kept by the key store, e.g. "starknet:0x4a1b...".
+const RefPrefix = "starknet:"
+
+var (
+	ErrUnknownKey         = errors.New("unknown key")
+	ErrUnsupportedNetwork = errors.New("unsupported network")
+
+	// contractAddressPrefix is "STARKNET_CONTRACT_ADDRESS" prefixing the hashed contract address data
+	contractAddressPrefix = new(big.Int).SetBytes([]byte("STARKNET_CONTRACT_ADDRESS"))
+	// addressBound is the upper bound of the contract addresses, 2^251 - 256
+	addressBound = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 251), big.NewInt(256))
+)
+
+// keyFile is the stored key, the private key is encrypted in the Web3 Secret Storage format
+type keyFile struct {
+	Address string              `json:"address"`
+	Crypto  keystore.CryptoJSON `json:"crypto"`
+}
+
+// KeyStore keeps the keys in the directory, one encrypted key file per key
+type KeyStore struct {
+	dir        string
+	passphrase string
+	classHash  *big.Int
+
+	mu   sync.RWMutex
+	keys map[string]*big.Int
+}
+
+// Open opens the key store directory creating it when missing and decrypts all its keys with the passphrase,
+// the addresses are of the accounts of the class deployed with the public key as the salt and
+// the only constructor argument, e.g. the OpenZeppelin account
+func Open(dir, passphrase, accountClassHash string) (*KeyStore, error) {
+	classHash, err := hexutil.DecodeBig(accountClassHash)
+	if err != nil {
+		return nil, fmt.Errorf("parse account class hash: %w", err)
+	}
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create key store: %w", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &KeyStore{dir: dir, passphrase: passphrase, classHash: classHash, keys: make(map[string]*big.Int, len(files))}
+	for _, f := range files {
+		raw, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("read key %s: %w", f, err)
+		}
+		var kf keyFile
+		if err = json.Unmarshal(raw, &kf); err != nil {
+			return nil, fmt.Errorf("decode key %s: %w", f, err)
+		}
+		data, err := keystore.DecryptDataV3(kf.Crypto, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt key %s: %w", kf.Address, err)
+		}
+		key := new(big.Int).SetBytes(data)
+		addr, err := ks.address(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kf.Address, err)
+		}
+		if addr != kf.Address {
+			return nil, fmt.Errorf("key %s belongs to %s", kf.Address, addr)
+		}
+		ks.keys[kf.Address] = key
+	}
+	return ks, nil
+}
+
+// Address returns the account address of the public key, the address is the Pedersen hash of
+// the "STARKNET_CONTRACT_ADDRESS" prefix, the zero deployer, the public key salt, the class hash
+// and the hash of the constructor calldata
+func Address(classHash, publicKey *big.Int) (string, error) {
+	calldata, err := pedersenArray(publicKey)
+	if err != nil {
+		return "", err
+	}
+	h, err := pedersenArray(contractAddressPrefix, new(big.Int), publicKey, classHash, calldata)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("0x%064x", h.Mod(h, addressBound)), nil
+}
+
+func (k *KeyStore) address(key *big.Int) (string, error) {
+	if key.Sign() <= 0 || key.Cmp(fr.Modulus()) >= 0 {
+		return "", errors.New("private key out of the curve order")
+	}
+	return Address(k.classHash, publicKey(key))
+}
+
+// Import encrypts the hex encoded private key into the key store,
+// importing a key which is already stored returns the reference to it
+func (k *KeyStore) Import(_ context.Context, net, privateKey string) (string, string, error) {
+	if net != network.Starknet {
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedNetwork, net)
+	}
+
+	key, ok := new(big.Int).SetString(strings.TrimPrefix(privateKey, "0x"), 16)
+	if !ok {
+		return "", "", errors.New("parse private key: hex Stark curve key expected")
+	}
+	addr, err := k.address(key)
+	if err != nil {
+		return "", "", fmt.Errorf("parse private key: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok = k.keys[addr]; ok {
+		return addr, RefPrefix + addr, nil
+	}
+
+	enc, err := keystore.EncryptDataV3(key.FillBytes(make([]byte, 32)), []byte(k.passphrase), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return "", "", fmt.Errorf("encrypt key: %w", err)
+	}
+	data, err := json.Marshal(keyFile{Address: addr, Crypto: enc})
+	if err != nil {
+		return "", "", err
+	}
+	if err = writeFile(filepath.Join(k.dir, addr+".json"), data); err != nil {
+		return "", "", fmt.Errorf("store key: %w", err)
+	}
+	k.keys[addr] = key
+
+	return addr, RefPrefix + addr, nil
+}
+
+// writeFile writes the file through a temporary file so a crash never leaves a partial key file
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".key-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Address returns the address of the key referenced by the key reference
+func (k *KeyStore) Address(_ context.Context, net, keyRef string) (string, error) {
+	if net != network.Starknet {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedNetwork, net)
+	}
+	addr, _, err := k.key(keyRef)
+	return addr, err
+}
+
+// Signer fails, the Starknet keys sign no EVM transactions, see TxSigner
+func (k *KeyStore) Signer(context.Context, string) (uniswap.Signer, error) {
+	return nil, fmt.Errorf("%w: the Starknet keys sign by TxSigner", ErrUnsupportedNetwork)
+}
+
+// TxSigner returns the Starknet transaction signer of the key referenced by the key reference
+func (k *KeyStore) TxSigner(_ context.Context, keyRef string) (*TxSigner, error) {
+	addr, key, err := k.key(keyRef)
+	if err != nil {
+		return nil, err
+	}
+	return &TxSigner{address: addr, key: key}, nil
+}
+
+func (k *KeyStore) key(keyRef string) (string, *big.Int, error) {
+	addr, ok := strings.CutPrefix(keyRef, RefPrefix)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: invalid key reference %q", ErrUnknownKey, keyRef)
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[strings.ToLower(addr)]
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %s", ErrUnknownKey, addr)
+	}
+	return strings.ToLower(addr), key, nil
+}
@@ -0,0 +1,77 @@
+package starknet
+
+import (
+	"errors"
+	"math/big"
+
+	starkcurve "github.com/consensys/gnark-crypto/ecc/stark-curve"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+var errNotFieldElement = errors.New("value is not a field element")
+
+// the Pedersen hash constant points, the x coordinates are the 76 digits chunks of pi
+var (
+	shiftPoint = point(
+		"0x49ee3eba8c1600700ee1b87eb599f16716b0b1022947733551fde4050ca6804",
+		"0x3ca0cfe4b3bc6ddf346d49d06ea0ed34e621062c0e056c1d0405d266e10268a")
+	hashPoints = [4]starkcurve.G1Affine{
+		point(
+			"0x234287dcbaffe7f969c748655fca9e58fa8120b6d56eb0c1080d17957ebe47b",
+			"0x3b056f100f96fb21e889527d41f4e39940135dd7a6c94cc6ed0268ee89e5615"),
+		point(
+			"0x4fa56f376c83db33f9dab2656558f3399099ec1de5e3018b7a6932dba8aa378",
+			"0x3fa0984c931c9e38113e0c0e47e4401562761f92a7a23b45168f4e80ff5b54d"),
+		point(
+			"0x4ba4cc166be8dec764910f75b45f74b40c690c74709e90f3aa372f0bd2d6997",
+			"0x40301cf5c1751f4b971e46c4ede85fcac5c59a5ce5ae7c48151f27b24b219c"),
+		point(
+			"0x54302dcb0e6cc1c6e44cca8f61a63bb2ca65048d53fb325d36ff12c49a58202",
+			"0x1b77b3e37d13504b348046268d8ae25ce98ad783c25561a879dcc77e99c2426"),
+	}
+	// lowBitsMask selects the low 248 bits hashed by the first point of the element
+	lowBitsMask = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 248), big.NewInt(1))
+)
+
+func point(x, y string) starkcurve.G1Affine {
+	var p starkcurve.G1Affine
+	if _, err := p.X.SetString(x); err != nil {
+		panic(err)
+	}
+	if _, err := p.Y.SetString(y); err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// pedersen is the Starknet Pedersen hash of the two field elements
+func pedersen(a, b *big.Int) (*big.Int, error) {
+	var acc starkcurve.G1Jac
+	acc.FromAffine(&shiftPoint)
+	for i, v := range []*big.Int{a, b} {
+		if v.Sign() < 0 || v.Cmp(fp.Modulus()) >= 0 {
+			return nil, errNotFieldElement
+		}
+		var low, high starkcurve.G1Affine
+		low.ScalarMultiplication(&hashPoints[2*i], new(big.Int).And(v, lowBitsMask))
+		high.ScalarMultiplication(&hashPoints[2*i+1], new(big.Int).Rsh(v, 248))
+		acc.AddMixed(&low)
+		acc.AddMixed(&high)
+	}
+
+	var res starkcurve.G1Affine
+	res.FromJacobian(&acc)
+	return res.X.BigInt(new(big.Int)), nil
+}
+
+// pedersenArray is the hash of the elements chained from zero and finished by the number of the elements
+func pedersenArray(elems ...*big.Int) (*big.Int, error) {
+	h := new(big.Int)
+	var err error
+	for _, e := range elems {
+		if h, err = pedersen(h, e); err != nil {
+			return nil, err
+		}
+	}
+	return pedersen(h, big.NewInt(int64(len(elems))))
+}
@@ -0,0 +1,117 @@
+package starknet
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	starkcurve "github.com/consensys/gnark-crypto/ecc/stark-curve"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fr"
+)
+
+// elementBound bounds the signed hashes and the signature values, 2^251
+var elementBound = new(big.Int).Lsh(big.NewInt(1), 251)
+
+// Signature is the Stark curve ECDSA signature of the transaction hash
+type Signature struct {
+	R, S *big.Int
+}
+
+// TxSigner signs the Starknet transaction hashes by the Stark curve key
+type TxSigner struct {
+	address string
+	key     *big.Int
+}
+
+func (s *TxSigner) Address() string     { return s.address }
+func (s *TxSigner) PublicKey() *big.Int { return publicKey(s.key) }
+
+// SignTransaction signs the transaction hash computed by the transaction builder, the hash must be below 2^251
+func (s *TxSigner) SignTransaction(txHash *big.Int) (Signature, error) {
+	if txHash.Sign() < 0 || txHash.Cmp(elementBound) >= 0 {
+		return Signature{}, errors.New("transaction hash out of 2^251")
+	}
+
+	n := fr.Modulus()
+	for {
+		k, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(1)))
+		if err != nil {
+			return Signature{}, err
+		}
+		k.Add(k, big.NewInt(1))
+
+		var kG starkcurve.G1Affine
+		kG.ScalarMultiplicationBase(k)
+		r := kG.X.BigInt(new(big.Int))
+		if r.Sign() == 0 || r.Cmp(elementBound) >= 0 {
+			continue
+		}
+
+		// s = (hash + r * key) / k, the verifiers check w = 1 / s below 2^251
+		sum := new(big.Int).Mul(r, s.key)
+		sum.Add(sum, txHash).Mod(sum, n)
+		if sum.Sign() == 0 {
+			continue
+		}
+		w := new(big.Int).Mul(k, new(big.Int).ModInverse(sum, n))
+		w.Mod(w, n)
+		if w.Sign() == 0 || w.Cmp(elementBound) >= 0 {
+			continue
+		}
+		return Signature{R: r, S: new(big.Int).ModInverse(w, n)}, nil
+	}
+}
+
+// Verify checks the signature of the hash by the public key, the x coordinate of the key point
+func Verify(publicKey, hash *big.Int, sig Signature) bool {
+	n := fr.Modulus()
+	if hash.Sign() < 0 || hash.Cmp(elementBound) >= 0 || sig.R.Sign() <= 0 || sig.R.Cmp(elementBound) >= 0 ||
+		sig.S.Sign() <= 0 || sig.S.Cmp(n) >= 0 {
+		return false
+	}
+	w := new(big.Int).ModInverse(sig.S, n)
+	if w == nil || w.Cmp(elementBound) >= 0 {
+		return false
+	}
+	q, ok := keyPoint(publicKey)
+	if !ok {
+		return false
+	}
+
+	// the key point is known up to its sign, so both (hash * w) G ± (r * w) Q are checked
+	var zG, rQ starkcurve.G1Affine
+	zG.ScalarMultiplicationBase(new(big.Int).Mod(new(big.Int).Mul(hash, w), n))
+	rQ.ScalarMultiplication(&q, new(big.Int).Mod(new(big.Int).Mul(sig.R, w), n))
+	for _, p := range []*starkcurve.G1Affine{new(starkcurve.G1Affine).Add(&zG, &rQ), new(starkcurve.G1Affine).Sub(&zG, &rQ)} {
+		if p.X.BigInt(new(big.Int)).Cmp(sig.R) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// publicKey is the x coordinate of the key point, the Starknet public key
+func publicKey(key *big.Int) *big.Int {
+	var p starkcurve.G1Affine
+	p.ScalarMultiplicationBase(key)
+	return p.X.BigInt(new(big.Int))
+}
+
+// keyPoint restores a key point of the public key
+func keyPoint(publicKey *big.Int) (starkcurve.G1Affine, bool) {
+	var p starkcurve.G1Affine
+	if publicKey.Sign() <= 0 || publicKey.Cmp(fp.Modulus()) >= 0 {
+		return p, false
+	}
+	p.X.SetBigInt(publicKey)
+
+	// y^2 = x^3 + a x + b
+	a, b := starkcurve.CurveCoefficients()
+	var rhs fp.Element
+	rhs.Square(&p.X).Add(&rhs, &a).Mul(&rhs, &p.X).Add(&rhs, &b)
+	if p.Y.Sqrt(&rhs) == nil {
+		return p, false
+	}
+	return p, true
+}
@@ -0,0 +1,117 @@
+package starknet
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/r1der/epos/internal/domain/entity/network"
+)
+
+const testClassHash = "0x61dac032f228abef9c6626f995015233097ae253a7f72d68552db02f2971b8f"
+
+func TestPedersen(t *testing.T) {
+	tests := []struct {
+		a, b, want string
+	}{
+		{
+			a:    "0x3d937c035c878245caf64531a5756109c53068da139362728feb561405371cb",
+			b:    "0x208a0a10250e382e1e4bbe2880906c2791bf6275695e02fbbc6aeff9cd8b31a",
+			want: "0x30e480bed5fe53fa909cc0f8c4d99b8f9f2c016be4c41e13a4848797979c662",
+		},
+		{
+			a:    "0x58f580910a6ca59b28927c08fe6c43e2e303ca384badc365795fc645d479d45",
+			b:    "0x78734f65a067be9bdb39de18434d71e79f7b6466a4b66bbd979ab9e7515fe0b",
+			want: "0x68cc0b76cddd1dd4ed2301ada9b7c872b23875d5ff837b3a87993e0d9996b87",
+		},
+	}
+	for _, tt := range tests {
+		got, err := pedersen(hexutil.MustDecodeBig(tt.a), hexutil.MustDecodeBig(tt.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hexutil.EncodeBig(got) != tt.want {
+			t.Errorf("pedersen(%s, %s) = %s, want %s", tt.a, tt.b, hexutil.EncodeBig(got), tt.want)
+		}
+	}
+}
+
+func TestPublicKey(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		// the generator point
+		{"0x1", "0x1ef15c18599971b7beced415a40f0c7deacfd9b0d1819e03d723d8bc943cfca"},
+		{"0x3c1e9550e66958296d11b60f8e8e7a7ad990d07fa65d5f7652c4a6c87d4e3cc", "0x77a3b314db07c45076d11f62b6f9e748a39790441823307743cf00d6597ea43"},
+	}
+	for _, tt := range tests {
+		if got := hexutil.EncodeBig(publicKey(hexutil.MustDecodeBig(tt.key))); got != tt.want {
+			t.Errorf("publicKey(%s) = %s, want %s", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSignTransaction(t *testing.T) {
+	s := &TxSigner{key: hexutil.MustDecodeBig("0x3c1e9550e66958296d11b60f8e8e7a7ad990d07fa65d5f7652c4a6c87d4e3cc")}
+	hash := hexutil.MustDecodeBig("0x6fea80189363a786037ed3e7ba546dad0ef7de49fccae0e31eb658b7dd4ea76")
+
+	sig, err := s.SignTransaction(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(s.PublicKey(), hash, sig) {
+		t.Fatal("signature not verified by the signer public key")
+	}
+	if Verify(s.PublicKey(), new(big.Int).Add(hash, big.NewInt(1)), sig) {
+		t.Fatal("signature verified for another hash")
+	}
+	if _, err = s.SignTransaction(elementBound); err == nil {
+		t.Fatal("hash out of 2^251 signed")
+	}
+}
+
+func TestKeyStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	ks, err := Open(dir, "secret", testClassHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = ks.Import(ctx, network.Ethereum, "0x1"); err == nil {
+		t.Fatal("imported a key of another network")
+	}
+	addr, ref, err := ks.Import(ctx, network.Starknet, "0x3c1e9550e66958296d11b60f8e8e7a7ad990d07fa65d5f7652c4a6c87d4e3cc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref != RefPrefix+addr || len(addr) != 66 {
+		t.Fatalf("unexpected address %s and reference %s", addr, ref)
+	}
+
+	// the reopened store decrypts the key and derives the same address
+	ks, err = Open(dir, "secret", testClassHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ks.Address(ctx, network.Starknet, ref); err != nil || got != addr {
+		t.Fatalf("Address() = %s, %v, want %s", got, err, addr)
+	}
+	signer, err := ks.TxSigner(ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.SignTransaction(big.NewInt(42))
+	if err != nil || !Verify(signer.PublicKey(), big.NewInt(42), sig) {
+		t.Fatalf("sign by the stored key: %v", err)
+	}
+
+	if _, err = Open(dir, "wrong", testClassHash); err == nil {
+		t.Fatal("opened by a wrong passphrase")
+	}
+	if _, err = Open(dir, "secret", "0x1"); err == nil {
+		t.Fatal("opened the keys of another account class")
+	}
+}
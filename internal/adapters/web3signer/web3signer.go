@@ -0,0 +1,213 @@
+// Package web3signer signs the wallet transactions by an external signer with the Web3Signer compatible
+// eth1 JSON-RPC API, so the wallet keys never enter the bot process
+package web3signer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/r1der/epos/pkg/uniswap"
+)
+
+// RefPrefix prefixes the key references of the keys kept by the remote signer, e.g. "web3signer:0x5aAe...".
+const RefPrefix = "web3signer:"
+
+// DefaultTimeout limits a signer request when the config sets no timeout
+const DefaultTimeout = 10 * time.Second
+
+var (
+	ErrUnknownKey        = errors.New("unknown key")
+	ErrImportUnsupported = errors.New("remote signer does not import keys")
+	ErrInvalidSignature  = errors.New("invalid remote signature")
+)
+
+// Config configures the connection to the remote signer
+type Config struct {
+	// URL is the signer endpoint, e.g. "https://signer.internal:9000"
+	URL string
+	// Timeout limits every signer request
+	Timeout time.Duration
+	// CAFile is the PEM bundle of the CAs trusted for the signer certificate, the system ones when empty
+	CAFile string
+	// CertFile and KeyFile are the PEM client certificate and key for the mutual TLS
+	CertFile string
+	KeyFile  string
+}
+
+// Client is the remote signer client
+type Client struct {
+	rpc     *rpc.Client
+	timeout time.Duration
+}
+
+// New connects to the remote signer
+func New(ctx context.Context, cfg Config) (*Client, error) {
+	tlsConfig, err := tlsConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	httpClient := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+	cli, err := rpc.DialOptions(ctx, cfg.URL, rpc.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("dial remote signer: %w", err)
+	}
+	return &Client{rpc: cli, timeout: timeout}, nil
+}
+
+func tlsConfig(cfg Config) (*tls.Config, error) {
+	c := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read signer CA: %w", err)
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in signer CA %s", cfg.CAFile)
+		}
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load signer client certificate: %w", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
+
+func (c *Client) Close() { c.rpc.Close() }
+
+// Import is not supported, the keys are provisioned into the remote signer by its operators
+func (c *Client) Import(context.Context, string, string) (string, string, error) {
+	return "", "", ErrImportUnsupported
+}
+
+// Address returns the address of the referenced key, the key must be known to the signer
+func (c *Client) Address(ctx context.Context, _, keyRef string) (string, error) {
+	address, err := c.account(ctx, keyRef)
+	if err != nil {
+		return "", err
+	}
+	return address.Hex(), nil
+}
+
+// Signer returns the signer of the referenced key, the key must be known to the signer
+func (c *Client) Signer(ctx context.Context, keyRef string) (uniswap.Signer, error) {
+	address, err := c.account(ctx, keyRef)
+	if err != nil {
+		return nil, err
+	}
+	return &signer{client: c, address: address}, nil
+}
+
+func (c *Client) account(ctx context.Context, keyRef string) (common.Address, error) {
+	hex, ok := strings.CutPrefix(keyRef, RefPrefix)
+	if !ok || !common.IsHexAddress(hex) {
+		return common.Address{}, fmt.Errorf("%w: invalid key reference %q", ErrUnknownKey, keyRef)
+	}
+	address := common.HexToAddress(hex)
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var accounts []common.Address
+	if err := c.rpc.CallContext(ctx, &accounts, "eth_accounts"); err != nil {
+		return common.Address{}, fmt.Errorf("remote signer: eth_accounts: %w", err)
+	}
+	if !slices.Contains(accounts, address) {
+		return common.Address{}, fmt.Errorf("%w: %s", ErrUnknownKey, address)
+	}
+	return address, nil
+}
+
+// signTxArgs are the eth_signTransaction parameters, the signer chain id is configured on the signer side
+type signTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big    `json:"value"`
+	Data                 hexutil.Bytes   `json:"data"`
+}
+
+type signer struct {
+	client  *Client
+	address common.Address
+}
+
+func (s *signer) Address() common.Address { return s.address }
+
+// SignTx signs the transaction by the remote signer and checks the signed transaction
+// is the requested one signed by the key for the chain
+func (s *signer) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := signTxArgs{
+		From:  s.address,
+		To:    tx.To(),
+		Nonce: hexutil.Uint64(tx.Nonce()),
+		Gas:   hexutil.Uint64(tx.Gas()),
+		Value: (*hexutil.Big)(tx.Value()),
+		Data:  tx.Data(),
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.client.timeout)
+	defer cancel()
+
+	var raw hexutil.Bytes
+	if err := s.client.rpc.CallContext(ctx, &raw, "eth_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("remote signer: eth_signTransaction: %w", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("%w: decode signed transaction: %w", ErrInvalidSignature, err)
+	}
+
+	txSigner := types.LatestSignerForChainID(chainID)
+	if txSigner.Hash(signed) != txSigner.Hash(tx) {
+		return nil, fmt.Errorf("%w: the signed transaction differs from the requested one", ErrInvalidSignature)
+	}
+	from, err := types.Sender(txSigner, signed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	if from != s.address {
+		return nil, fmt.Errorf("%w: signed by %s instead of %s", ErrInvalidSignature, from, s.address)
+	}
+	return signed, nil
+}
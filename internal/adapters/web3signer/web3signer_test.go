@@ -0,0 +1,277 @@
+package web3signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var chainID = big.NewInt(1337)
+
+// fakeSigner stands in for the remote signer serving the eth1 JSON-RPC API by the key
+type fakeSigner struct {
+	key *ecdsa.PrivateKey
+	// signKey signs the transactions instead of the key when set
+	signKey *ecdsa.PrivateKey
+	// nonceShift changes the nonce of the signed transactions
+	nonceShift uint64
+	// status fails the requests with the HTTP status when set
+	status int
+	// hold holds the replies until closed when set
+	hold chan struct{}
+}
+
+func newFakeSigner(t *testing.T) *fakeSigner {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeSigner{key: key}
+}
+
+func (s *fakeSigner) address() common.Address { return crypto.PubkeyToAddress(s.key.PublicKey) }
+
+func (s *fakeSigner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.hold != nil {
+		select {
+		case <-s.hold:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if s.status != 0 {
+		http.Error(w, http.StatusText(s.status), s.status)
+		return
+	}
+
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+	result, err := s.handle(req.Method, req.Params)
+	if err != nil {
+		resp["error"] = map[string]any{"code": -32000, "message": err.Error()}
+	} else {
+		resp["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *fakeSigner) handle(method string, params []json.RawMessage) (any, error) {
+	switch method {
+	case "eth_accounts":
+		return []common.Address{s.address()}, nil
+	case "eth_signTransaction":
+		var args signTxArgs
+		if len(params) != 1 {
+			return nil, errors.New("one transaction expected")
+		}
+		if err := json.Unmarshal(params[0], &args); err != nil {
+			return nil, err
+		}
+		if args.From != s.address() {
+			return nil, errors.New("unknown account")
+		}
+
+		var data types.TxData
+		nonce := uint64(args.Nonce) + s.nonceShift
+		if args.MaxFeePerGas != nil {
+			data = &types.DynamicFeeTx{
+				ChainID: chainID, Nonce: nonce, GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+				GasFeeCap: args.MaxFeePerGas.ToInt(), Gas: uint64(args.Gas), To: args.To, Value: args.Value.ToInt(), Data: args.Data,
+			}
+		} else {
+			data = &types.LegacyTx{
+				Nonce: nonce, GasPrice: args.GasPrice.ToInt(), Gas: uint64(args.Gas), To: args.To,
+				Value: args.Value.ToInt(), Data: args.Data,
+			}
+		}
+		key := s.key
+		if s.signKey != nil {
+			key = s.signKey
+		}
+		signed, err := types.SignNewTx(key, types.LatestSignerForChainID(chainID), data)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := signed.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Bytes(raw), nil
+	default:
+		return nil, errors.New("method not found")
+	}
+}
+
+// newTestClient connects the client to the stand-in signer
+func newTestClient(t *testing.T, s *fakeSigner, timeout time.Duration) *Client {
+	t.Helper()
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+
+	c, err := New(context.Background(), Config{URL: srv.URL, Timeout: timeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestAddress(t *testing.T) {
+	ctx := context.Background()
+	s := newFakeSigner(t)
+	c := newTestClient(t, s, 0)
+
+	address, err := c.Address(ctx, "eth", RefPrefix+strings.ToLower(s.address().Hex()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != s.address().Hex() {
+		t.Fatalf("address %s, want %s", address, s.address().Hex())
+	}
+
+	for _, ref := range []string{
+		RefPrefix + common.HexToAddress("0x1").Hex(),
+		"keystore:" + s.address().Hex(),
+		RefPrefix + "0xnot-an-address",
+	} {
+		if _, err = c.Address(ctx, "eth", ref); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("%s: expected the unknown key error, got %v", ref, err)
+		}
+	}
+
+	if _, _, err = c.Import(ctx, "eth", "0x4c0883a6"); !errors.Is(err, ErrImportUnsupported) {
+		t.Errorf("expected the unsupported import error, got %v", err)
+	}
+}
+
+func TestSignTx(t *testing.T) {
+	ctx := context.Background()
+	to := common.HexToAddress("0x5000000000000000000000000000000000000001")
+	txs := map[string]*types.Transaction{
+		"legacy": types.NewTx(&types.LegacyTx{
+			Nonce: 7, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1), Data: []byte{1, 2},
+		}),
+		"dynamic fee": types.NewTx(&types.DynamicFeeTx{
+			ChainID: chainID, Nonce: 8, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2e9), Gas: 50000, To: &to,
+			Value: new(big.Int), Data: []byte{3},
+		}),
+	}
+
+	s := newFakeSigner(t)
+	c := newTestClient(t, s, 0)
+	remote, err := c.Signer(ctx, RefPrefix+s.address().Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote.Address() != s.address() {
+		t.Fatalf("signer address %s, want %s", remote.Address(), s.address())
+	}
+
+	for name, tx := range txs {
+		signed, err := remote.SignTx(ctx, tx, chainID)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		if err != nil || from != s.address() {
+			t.Errorf("%s: signed by %s, %v", name, from, err)
+		}
+		if signed.Nonce() != tx.Nonce() || signed.Type() != tx.Type() {
+			t.Errorf("%s: signed %d transaction of nonce %d", name, signed.Type(), signed.Nonce())
+		}
+	}
+
+	// the signed transactions are checked against the requested ones
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.signKey = other
+	if _, err = remote.SignTx(ctx, txs["dynamic fee"], chainID); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("signed by another key: expected the invalid signature error, got %v", err)
+	}
+	s.signKey, s.nonceShift = nil, 1
+	if _, err = remote.SignTx(ctx, txs["legacy"], chainID); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("changed nonce: expected the invalid signature error, got %v", err)
+	}
+	s.nonceShift = 0
+	if _, err = remote.SignTx(ctx, txs["legacy"], big.NewInt(1)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("other chain: expected the invalid signature error, got %v", err)
+	}
+
+	blob := types.NewTx(&types.BlobTx{})
+	if _, err = remote.SignTx(ctx, blob, chainID); err == nil || !strings.Contains(err.Error(), "unsupported transaction type") {
+		t.Errorf("expected the unsupported type error, got %v", err)
+	}
+}
+
+func TestSignerErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("status", func(t *testing.T) {
+		s := newFakeSigner(t)
+		s.status = http.StatusServiceUnavailable
+		c := newTestClient(t, s, 0)
+
+		_, err := c.Address(ctx, "eth", RefPrefix+s.address().Hex())
+		var httpErr rpc.HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("expected the HTTP status error, got %v", err)
+		}
+	})
+
+	t.Run("rpc error", func(t *testing.T) {
+		s := newFakeSigner(t)
+		c := newTestClient(t, s, 0)
+		remote, err := c.Signer(ctx, RefPrefix+s.address().Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the signer refuses to sign for a key it does not keep
+		remote.(*signer).address = common.HexToAddress("0x1")
+		_, err = remote.SignTx(ctx, types.NewTx(&types.LegacyTx{GasPrice: new(big.Int), Value: new(big.Int)}), chainID)
+		if err == nil || !strings.Contains(err.Error(), "unknown account") {
+			t.Fatalf("expected the signer error, got %v", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		s := newFakeSigner(t)
+		s.hold = make(chan struct{})
+		c := newTestClient(t, s, 50*time.Millisecond)
+		t.Cleanup(func() { close(s.hold) })
+
+		start := time.Now()
+		if _, err := c.Address(ctx, "eth", RefPrefix+s.address().Hex()); err == nil {
+			t.Fatal("expected the timeout error")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("the request took %s despite the timeout", elapsed)
+		}
+	})
+}
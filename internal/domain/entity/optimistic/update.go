@@ -0,0 +1,40 @@
+// Package optimistic applies the changes of the versioned entities saved with the optimistic concurrency control
+package optimistic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MaxSaveAttempts limits the saves of a change conflicting with the concurrent changes of the entity
+const MaxSaveAttempts = 3
+
+// Update applies the change to the entity and saves it. When the stored entity was changed concurrently
+// it is reloaded into the entity and the change is applied to the reloaded state again, so the change
+// checks its preconditions on the state it gets and returns an error to abort the update.
+// The save fails with the conflict error of the entity on the concurrent changes
+func Update[T any](
+	ctx context.Context,
+	entity *T,
+	conflict error,
+	save func(context.Context, *T) error,
+	reload func(context.Context) (*T, error),
+	change func(*T) error,
+) error {
+	for attempt := 1; ; attempt++ {
+		if err := change(entity); err != nil {
+			return err
+		}
+		err := save(ctx, entity)
+		if !errors.Is(err, conflict) || attempt == MaxSaveAttempts {
+			return err
+		}
+
+		stored, err := reload(ctx)
+		if err != nil {
+			return fmt.Errorf("reload after conflict: %w", err)
+		}
+		*entity = *stored
+	}
+}
@@ -0,0 +1,101 @@
+package optimistic
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+var errConflict = errors.New("conflict")
+
+type entity struct {
+	version int
+	active  bool
+	value   int
+}
+
+// repo stores the entity and lets the concurrent changes happen before the saves
+type repo struct {
+	stored     entity
+	concurrent []func(*entity)
+	saves      int
+}
+
+func (r *repo) save(_ context.Context, e *entity) error {
+	r.saves++
+	if len(r.concurrent) > 0 {
+		r.concurrent[0](&r.stored)
+		r.stored.version++
+		r.concurrent = r.concurrent[1:]
+	}
+	if e.version != r.stored.version {
+		return errConflict
+	}
+	e.version++
+	r.stored = *e
+	return nil
+}
+
+func (r *repo) reload(context.Context) (*entity, error) {
+	e := r.stored
+	return &e, nil
+}
+
+func add(n int) func(*entity) error {
+	return func(e *entity) error {
+		if !e.active {
+			return errors.New("inactive")
+		}
+		e.value += n
+		return nil
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("saved", func(t *testing.T) {
+		r := &repo{stored: entity{active: true}}
+		e := r.stored
+		if err := Update(ctx, &e, errConflict, r.save, r.reload, add(1)); err != nil {
+			t.Fatal(err)
+		}
+		if r.stored.value != 1 || r.saves != 1 {
+			t.Fatalf("stored %+v after %d saves", r.stored, r.saves)
+		}
+	})
+
+	t.Run("reapplied on the reloaded state", func(t *testing.T) {
+		r := &repo{stored: entity{active: true}, concurrent: []func(*entity){func(e *entity) { e.value = 10 }}}
+		e := r.stored
+		if err := Update(ctx, &e, errConflict, r.save, r.reload, add(1)); err != nil {
+			t.Fatal(err)
+		}
+		if r.stored.value != 11 || e != r.stored || r.saves != 2 {
+			t.Fatalf("stored %+v, entity %+v after %d saves", r.stored, e, r.saves)
+		}
+	})
+
+	t.Run("aborted by the precondition", func(t *testing.T) {
+		r := &repo{stored: entity{active: true}, concurrent: []func(*entity){func(e *entity) { e.active = false }}}
+		e := r.stored
+		if err := Update(ctx, &e, errConflict, r.save, r.reload, add(1)); err == nil || err.Error() != "inactive" {
+			t.Fatalf("expected the precondition error, got %v", err)
+		}
+		if r.stored.value != 0 || r.saves != 1 {
+			t.Fatalf("stored %+v after %d saves", r.stored, r.saves)
+		}
+	})
+
+	t.Run("attempts exhausted", func(t *testing.T) {
+		noop := func(*entity) {}
+		r := &repo{stored: entity{active: true}, concurrent: []func(*entity){noop, noop, noop, noop}}
+		e := r.stored
+		if err := Update(ctx, &e, errConflict, r.save, r.reload, add(1)); !errors.Is(err, errConflict) {
+			t.Fatalf("expected the conflict, got %v", err)
+		}
+		if r.saves != MaxSaveAttempts {
+			t.Fatalf("%d saves", r.saves)
+		}
+	})
+}
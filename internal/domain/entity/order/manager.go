@@ -3,7 +3,6 @@ package order
 import (
 	"context"
 	"fmt"
-	"math/big"
 	"time"
 
 	"github.com/r1der/epos/internal/domain/entity/project"
@@ -29,9 +28,10 @@ func NewManager(repo Repository, router ports.Router) Manager {
 
 type NewOrderInput struct {
 	Project   *project.Project
+	Mode      ports.SwapMode
 	AmountIn  values.Amount
 	AmountOut values.Amount
-	Price     *big.Float
+	Price     values.Price
 }
 
 func (svc *manager) New(ctx context.Context, in *NewOrderInput) (*Order, error) {
@@ -40,6 +40,9 @@ func (svc *manager) New(ctx context.Context, in *NewOrderInput) (*Order, error)
 		Protocol:    in.Project.Pool().Protocol(),
 		PoolAddress: in.Project.Pool().Address(),
 		Fee:         in.Project.Pool().Fee(),
+		Pair:        in.Project.Pool().Pair(),
+		Wallet:      in.Project.Wallet(),
+		Mode:        in.Mode,
 		AmountIn:    in.AmountIn,
 		AmountOut:   in.AmountOut,
 	})
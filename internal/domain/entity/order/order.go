@@ -1,7 +1,6 @@
 package order
 
 import (
-	"math/big"
 	"time"
 
 	"github.com/r1der/epos/internal/domain/entity/pool"
@@ -23,9 +22,12 @@ type Order struct {
 	direction      Direction
 	amountIn       values.Amount
 	amountOut      values.Amount
-	price          *big.Float
+	price          values.Price
 	transactionFee values.Amount
 	createdAt      time.Time
+
+	// version is the stored version the order was loaded at, zero for an unsaved order
+	version int
 }
 
 func (ord *Order) Project() *project.Project { return ord.project }
@@ -36,6 +38,58 @@ func (ord *Order) IsBuy() bool               { return ord.direction == Buy }
 func (ord *Order) IsSell() bool              { return ord.direction == Sell }
 func (ord *Order) AmountIn() values.Amount   { return ord.amountIn }
 func (ord *Order) AmountOut() values.Amount  { return ord.amountOut }
-func (ord *Order) FilledPrice() *big.Float   { return ord.price }
+func (ord *Order) FilledPrice() values.Price { return ord.price }
 func (ord *Order) Fee() values.Amount        { return ord.transactionFee }
 func (ord *Order) CreatedAt() time.Time      { return ord.createdAt }
+
+// Version is the version of the stored order this order is based on
+func (ord *Order) Version() int { return ord.version }
+
+// SetVersion is called by the repositories after the order is saved with the new version
+func (ord *Order) SetVersion(version int) { ord.version = version }
+
+// Snapshot holds the persisted state of the order
+type Snapshot struct {
+	Project        *project.Project
+	Pool           *pool.Pool
+	Address        string
+	Direction      Direction
+	AmountIn       values.Amount
+	AmountOut      values.Amount
+	FilledPrice    values.Price
+	TransactionFee values.Amount
+	CreatedAt      time.Time
+	Version        int
+}
+
+// Restore rebuilds the order from its persisted state
+func Restore(s Snapshot) *Order {
+	return &Order{
+		project:        s.Project,
+		pool:           s.Pool,
+		address:        s.Address,
+		direction:      s.Direction,
+		amountIn:       s.AmountIn,
+		amountOut:      s.AmountOut,
+		price:          s.FilledPrice,
+		transactionFee: s.TransactionFee,
+		createdAt:      s.CreatedAt,
+		version:        s.Version,
+	}
+}
+
+// Snapshot returns the persisted state of the order, Restore of the snapshot rebuilds the same order
+func (ord *Order) Snapshot() Snapshot {
+	return Snapshot{
+		Project:        ord.project,
+		Pool:           ord.pool,
+		Address:        ord.address,
+		Direction:      ord.direction,
+		AmountIn:       ord.amountIn,
+		AmountOut:      ord.amountOut,
+		FilledPrice:    ord.price,
+		TransactionFee: ord.transactionFee,
+		CreatedAt:      ord.createdAt,
+		Version:        ord.version,
+	}
+}
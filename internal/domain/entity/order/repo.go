@@ -3,6 +3,7 @@ package order
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/r1der/epos/internal/domain/entity/pool"
 	"github.com/r1der/epos/internal/domain/entity/project"
@@ -11,6 +12,8 @@ import (
 
 var (
 	ErrNotFound = errors.New("order not found")
+	// ErrConflict is returned by Save when the stored order was changed since it was loaded
+	ErrConflict = errors.New("order was changed concurrently")
 )
 
 type Repository interface {
@@ -26,6 +29,19 @@ type Filter struct {
 	Directions []Direction
 	TokensIn   []*token.Token
 	TokensOut  []*token.Token
+	// CreatedFrom and CreatedTo select the entities created in [CreatedFrom, CreatedTo), a zero time is unbounded
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	OrderBy     OrderBy
+	// Limit limits the number of the found entities, zero means no limit
+	Limit  int
+	Offset int
 }
 
-type SortBy string
+// OrderBy sorts by the field, the "-" prefix sorts in the descending order
+type OrderBy string
+
+const (
+	OrderByCreatedAt     OrderBy = "created_at"
+	OrderByCreatedAtDesc OrderBy = "-created_at"
+)
@@ -5,15 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
+	"github.com/r1der/epos/internal/domain/entity/optimistic"
 	"github.com/r1der/epos/internal/domain/entity/token"
 	"github.com/r1der/epos/internal/domain/ports"
 	"github.com/r1der/epos/internal/domain/values"
+	"github.com/r1der/epos/pkg/clmath"
 )
 
 type Manager interface {
 	Get(ctx context.Context, network, protocol string, pair *token.Pair, fee values.Percent) (*Pool, error)
-	CalculatePositionRange(ctx context.Context, p *Pool, volatility values.Percent) (*Range, error)
+	CalculatePositionRange(ctx context.Context, p *Pool, strategy RangeStrategy) (*Range, error)
 	CalculatePositionAmounts(ctx context.Context, pricesRange *Range, baseAmount, quoteAmount values.Amount) (*Amounts, error)
 }
 
@@ -45,13 +48,12 @@ func (svc *manager) Get(ctx context.Context, network, protocol string, pair *tok
 	var data *ports.Pool
 
 	if p != nil {
-		data, err = svc.factory.GetPool(ctx, p.network, p.protocol, p.address)
+		data, err = svc.factory.GetPool(ctx, p.network, p.protocol, p.pair, p.address)
 		if err != nil {
 			return nil, fmt.Errorf("factory: get pool: %w", err)
 		}
 
-		p.updatePrice(data.LastPrice)
-		if err = svc.repo.Save(ctx, p); err != nil {
+		if err = svc.updatePrice(ctx, p, data.LastPrice); err != nil {
 			return nil, fmt.Errorf("save pool after update price: %w", err)
 		}
 		return p, nil
@@ -77,29 +79,123 @@ func (svc *manager) Get(ctx context.Context, network, protocol string, pair *tok
 	return p, nil
 }
 
+// updatePrice saves the price read from the chain, when the pool was changed concurrently
+// it is reloaded and the price is saved again, the fresh price holds on any stored pool state
+func (svc *manager) updatePrice(ctx context.Context, p *Pool, price values.Price) error {
+	reload := func(ctx context.Context) (*Pool, error) {
+		return svc.repo.FindOne(ctx, Filter{
+			Networks:  []string{p.network},
+			Protocols: []string{p.protocol},
+			Addresses: []string{p.address},
+		})
+	}
+	return optimistic.Update(ctx, p, ErrConflict, svc.repo.Save, reload, func(p *Pool) error {
+		p.updatePrice(price)
+		return nil
+	})
+}
+
 type Range struct {
-	InitialPrice *big.Float
-	LowerPrice   *big.Float
-	UpperPrice   *big.Float
+	InitialPrice values.Price
+	LowerPrice   values.Price
+	UpperPrice   values.Price
+	LowerTick    int
+	UpperTick    int
 }
 
-// CalculatePositionRange calculates the price range for a position based on volatility
-func (svc *manager) CalculatePositionRange(ctx context.Context, p *Pool, volatility values.Percent) (*Range, error) {
+// CalculatePositionRange calculates the price range for a position by the strategy,
+// the range is snapped to the ticks usable with the pool tick spacing
+func (svc *manager) CalculatePositionRange(ctx context.Context, p *Pool, strategy RangeStrategy) (*Range, error) {
+	r, err := strategy.Range(ctx, svc, p)
+	if err != nil {
+		return nil, fmt.Errorf("%s range: %w", strategy.Kind(), err)
+	}
+	return r, nil
+}
+
+// PercentRange calculates the range by the percents below and above the current pool price
+func (svc *manager) PercentRange(ctx context.Context, p *Pool, below, above values.Percent) (*Range, error) {
 	data, err := svc.factory.CalculateRange(ctx, &ports.CalculateRangeInput{
 		Network:         p.network,
 		Protocol:        p.protocol,
 		PoolAddress:     p.address,
-		BaseVolatility:  volatility,
-		QuoteVolatility: volatility,
+		Pair:            p.pair,
+		BaseVolatility:  below,
+		QuoteVolatility: above,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("factory: calculate range: %w", err)
 	}
 	p.lastPrice = data.LastPrice
+
+	return snapRange(data.LastPrice, data.LowerPrice, data.UpperPrice, data.TickSpacing)
+}
+
+// PriceRange calculates the range between the prices, the current pool price must be within them
+func (svc *manager) PriceRange(ctx context.Context, p *Pool, lower, upper values.Price) (*Range, error) {
+	data, err := svc.factory.GetPool(ctx, p.network, p.protocol, p.pair, p.address)
+	if err != nil {
+		return nil, fmt.Errorf("factory: get pool: %w", err)
+	}
+	p.lastPrice = data.LastPrice
+
+	if data.LastPrice.Cmp(lower) < 0 || data.LastPrice.Cmp(upper) > 0 {
+		return nil, fmt.Errorf("%w: %f not in [%f, %f]", ErrPriceOutOfBounds, data.LastPrice, lower, upper)
+	}
+	return snapRange(data.LastPrice, lower, upper, data.TickSpacing)
+}
+
+// PriceHistory loads the average pool prices of the last periods of the interval length from the oldest one
+func (svc *manager) PriceHistory(ctx context.Context, p *Pool, interval time.Duration, periods int) ([]values.Price, error) {
+	prices, err := svc.factory.PriceHistory(ctx, &ports.PriceHistoryInput{
+		Network:     p.network,
+		Protocol:    p.protocol,
+		PoolAddress: p.address,
+		Pair:        p.pair,
+		Interval:    interval,
+		Periods:     periods,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("factory: price history: %w", err)
+	}
+	return prices, nil
+}
+
+// snapRange builds the range of the ticks aligned to the tick spacing and the prices at those ticks
+func snapRange(initialPrice, lowerPrice, upperPrice values.Price, tickSpacing int) (*Range, error) {
+	lowerTick, err := lowerPrice.Tick()
+	if err != nil {
+		return nil, fmt.Errorf("lower price tick: %w", err)
+	}
+	upperTick, err := upperPrice.Tick()
+	if err != nil {
+		return nil, fmt.Errorf("upper price tick: %w", err)
+	}
+
+	lowerTick, upperTick, err = SnapTicks(lowerTick, upperTick, tickSpacing)
+	if err != nil {
+		return nil, err
+	}
+
+	lower, err := values.NewPriceAtTick(initialPrice.Pair(), lowerTick)
+	if err != nil {
+		return nil, fmt.Errorf("lower tick price: %w", err)
+	}
+	upper, err := values.NewPriceAtTick(initialPrice.Pair(), upperTick)
+	if err != nil {
+		return nil, fmt.Errorf("upper tick price: %w", err)
+	}
+	// when the base token is token1 the lower tick is the higher price
+	if lower.Cmp(upper) > 0 {
+		lower, upper = upper, lower
+	}
+
 	return &Range{
-		InitialPrice: data.LastPrice,
-		LowerPrice:   data.LowerPrice,
-		UpperPrice:   data.UpperPrice,
+		InitialPrice: initialPrice,
+		LowerPrice:   lower,
+		UpperPrice:   upper,
+		LowerTick:    lowerTick,
+		UpperTick:    upperTick,
 	}, nil
 }
 
@@ -109,21 +205,40 @@ type Amounts struct {
 	QuoteAmount values.Amount
 }
 
-// CalculatePositionAmounts calculates the asset amounts for a position based on prices range
-func (svc *manager) CalculatePositionAmounts(ctx context.Context, pricesRange *Range, baseAmount, quoteAmount values.Amount) (*Amounts, error) {
-	data, err := svc.factory.CalculateAmounts(ctx, &ports.CalculateAmountsInput{
-		InitialPrice: pricesRange.InitialPrice,
-		LowerPrice:   pricesRange.LowerPrice,
-		UpperPrice:   pricesRange.UpperPrice,
-		BaseAmount:   baseAmount,
-		QuoteAmount:  quoteAmount,
-	})
+// CalculatePositionAmounts calculates the asset amounts for a position based on prices range,
+// the liquidity math is done offline and matches the on-chain values
+func (svc *manager) CalculatePositionAmounts(_ context.Context, pricesRange *Range, baseAmount, quoteAmount values.Amount) (*Amounts, error) {
+	pair := pricesRange.InitialPrice.Pair()
+
+	sqrtPriceX96, err := pricesRange.InitialPrice.SqrtPriceX96()
 	if err != nil {
-		return nil, fmt.Errorf("factory: calculate amounts: %w", err)
+		return nil, fmt.Errorf("initial price: %w", err)
 	}
+	sqrtRatioAX96, err := clmath.GetSqrtRatioAtTick(pricesRange.LowerTick)
+	if err != nil {
+		return nil, fmt.Errorf("lower tick: %w", err)
+	}
+	sqrtRatioBX96, err := clmath.GetSqrtRatioAtTick(pricesRange.UpperTick)
+	if err != nil {
+		return nil, fmt.Errorf("upper tick: %w", err)
+	}
+
+	amount0, amount1 := poolAmounts(pair, baseAmount.Value(), quoteAmount.Value())
+
+	liquidity, err := clmath.GetLiquidityForAmounts(sqrtPriceX96, sqrtRatioAX96, sqrtRatioBX96, amount0, amount1)
+	if err != nil {
+		return nil, fmt.Errorf("calculate liquidity: %w", err)
+	}
+	amount0, amount1, err = clmath.GetAmountsForLiquidity(sqrtPriceX96, sqrtRatioAX96, sqrtRatioBX96, liquidity)
+	if err != nil {
+		return nil, fmt.Errorf("calculate amounts: %w", err)
+	}
+
+	base, quote := baseQuoteAmounts(pair, amount0, amount1)
+
 	return &Amounts{
-		Liquidity:   data.Liquidity,
-		BaseAmount:  data.BaseAmount,
-		QuoteAmount: data.QuoteAmount,
+		Liquidity:   liquidity,
+		BaseAmount:  values.NewAmount(baseAmount.Token(), base),
+		QuoteAmount: values.NewAmount(quoteAmount.Token(), quote),
 	}, nil
 }
@@ -0,0 +1,59 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/pkg/clmath"
+)
+
+// ErrEmptyTicksRange is returned when the range has no tick spacing between its ticks after the snapping
+var ErrEmptyTicksRange = errors.New("empty ticks range")
+
+// SnapTicks aligns the ticks range to the tick spacing widening it to the nearest usable ticks,
+// the range whose ticks collapse into the same usable tick is an error
+func SnapTicks(lowerTick, upperTick, tickSpacing int) (int, int, error) {
+	if tickSpacing <= 0 {
+		return 0, 0, fmt.Errorf("invalid tick spacing: %d", tickSpacing)
+	}
+	if lowerTick > upperTick {
+		lowerTick, upperTick = upperTick, lowerTick
+	}
+
+	minTick, maxTick := clmath.MinUsableTick(tickSpacing), clmath.MaxUsableTick(tickSpacing)
+
+	lowerTick = max(floorDiv(lowerTick, tickSpacing)*tickSpacing, minTick)
+	upperTick = min(-floorDiv(-upperTick, tickSpacing)*tickSpacing, maxTick)
+
+	if lowerTick >= upperTick {
+		return 0, 0, fmt.Errorf("%w: [%d, %d] for tick spacing %d", ErrEmptyTicksRange, lowerTick, upperTick, tickSpacing)
+	}
+
+	return lowerTick, upperTick, nil
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// poolAmounts orders the base and quote amounts as the pool token0 and token1 amounts
+func poolAmounts(pair *token.Pair, baseAmount, quoteAmount *big.Int) (*big.Int, *big.Int) {
+	if pair.IsBaseToken0() {
+		return baseAmount, quoteAmount
+	}
+	return quoteAmount, baseAmount
+}
+
+// baseQuoteAmounts converts the pool token0 and token1 amounts to the base and quote amounts
+func baseQuoteAmounts(pair *token.Pair, amount0, amount1 *big.Int) (*big.Int, *big.Int) {
+	if pair.IsBaseToken0() {
+		return amount0, amount1
+	}
+	return amount1, amount0
+}
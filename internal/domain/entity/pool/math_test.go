@@ -0,0 +1,66 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/r1der/epos/pkg/clmath"
+)
+
+func TestSnapTicks(t *testing.T) {
+	tests := []struct {
+		name              string
+		lower, upper      int
+		spacing           int
+		wantLower, wantUp int
+	}{
+		{"aligned", -120, 180, 60, -120, 180},
+		{"aligned spacing 1", -5, 5, 1, -5, 5},
+		{"widened", 61, 119, 60, 60, 120},
+		{"negative widened", -61, -1, 60, -120, 0},
+		{"across zero", -59, 59, 60, -60, 60},
+		{"within a spacing", 1, 2, 60, 0, 60},
+		{"within a negative spacing", -2, -1, 10, -10, 0},
+		{"same unaligned tick", 7, 7, 60, 0, 60},
+		{"reversed", 180, -120, 60, -120, 180},
+		{"min clamp", clmath.MinTick, 0, 60, -887220, 0},
+		{"max clamp", 0, clmath.MaxTick, 60, 0, 887220},
+		{"full range", clmath.MinTick, clmath.MaxTick, 200, -887200, 887200},
+		{"next to max", 887210, clmath.MaxTick, 60, 887160, 887220},
+	}
+	for _, tt := range tests {
+		lower, upper, err := SnapTicks(tt.lower, tt.upper, tt.spacing)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if lower != tt.wantLower || upper != tt.wantUp {
+			t.Errorf("%s: got [%d, %d], want [%d, %d]", tt.name, lower, upper, tt.wantLower, tt.wantUp)
+		}
+		if lower%tt.spacing != 0 || upper%tt.spacing != 0 || lower >= upper {
+			t.Errorf("%s: unusable range [%d, %d]", tt.name, lower, upper)
+		}
+	}
+
+	// the ranges collapsing into a single usable tick are not widened
+	for _, tt := range []struct {
+		name         string
+		lower, upper int
+		spacing      int
+	}{
+		{"same aligned tick", 60, 60, 60},
+		{"same negative aligned tick", -60, -60, 10},
+		{"beyond max", clmath.MaxTick, clmath.MaxTick, 60},
+		{"beyond min", clmath.MinTick, clmath.MinTick, 60},
+		{"above max usable", 887221, clmath.MaxTick, 60},
+	} {
+		if lower, upper, err := SnapTicks(tt.lower, tt.upper, tt.spacing); !errors.Is(err, ErrEmptyTicksRange) {
+			t.Errorf("%s: expected the empty range error, got [%d, %d], %v", tt.name, lower, upper, err)
+		}
+	}
+
+	for _, spacing := range []int{0, -60} {
+		if _, _, err := SnapTicks(-60, 60, spacing); err == nil {
+			t.Errorf("tick spacing %d: expected the invalid tick spacing error", spacing)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package pool
 
 import (
 	"fmt"
-	"math/big"
 
 	"github.com/r1der/epos/internal/domain/entity/token"
 	"github.com/r1der/epos/internal/domain/values"
@@ -21,7 +20,10 @@ type Pool struct {
 	address   string
 	fee       values.Percent
 	pair      *token.Pair
-	lastPrice *big.Float
+	lastPrice values.Price
+
+	// version is the stored version the pool was loaded at, zero for an unsaved pool
+	version int
 }
 
 func (p *Pool) Name() string {
@@ -29,13 +31,56 @@ func (p *Pool) Name() string {
 		p.network, p.protocol, p.pair.BaseToken(), p.pair.QuoteToken(), p.fee)
 }
 
-func (p *Pool) Network() string       { return p.network }
-func (p *Pool) Protocol() string      { return p.protocol }
-func (p *Pool) Address() string       { return p.address }
-func (p *Pool) Fee() values.Percent   { return p.fee }
-func (p *Pool) Pair() *token.Pair     { return p.pair }
-func (p *Pool) LastPrice() *big.Float { return p.lastPrice }
+func (p *Pool) Network() string         { return p.network }
+func (p *Pool) Protocol() string        { return p.protocol }
+func (p *Pool) Address() string         { return p.address }
+func (p *Pool) Fee() values.Percent     { return p.fee }
+func (p *Pool) Pair() *token.Pair       { return p.pair }
+func (p *Pool) LastPrice() values.Price { return p.lastPrice }
 
-func (p *Pool) updatePrice(price *big.Float) {
+func (p *Pool) updatePrice(price values.Price) {
 	p.lastPrice = price
 }
+
+// Version is the version of the stored pool this pool is based on
+func (p *Pool) Version() int { return p.version }
+
+// SetVersion is called by the repositories after the pool is saved with the new version
+func (p *Pool) SetVersion(version int) { p.version = version }
+
+// Snapshot holds the persisted state of the pool
+type Snapshot struct {
+	Network   string
+	Protocol  string
+	Address   string
+	Fee       values.Percent
+	Pair      *token.Pair
+	LastPrice values.Price
+	Version   int
+}
+
+// Restore rebuilds the pool from its persisted state
+func Restore(s Snapshot) *Pool {
+	return &Pool{
+		network:   s.Network,
+		protocol:  s.Protocol,
+		address:   s.Address,
+		fee:       s.Fee,
+		pair:      s.Pair,
+		lastPrice: s.LastPrice,
+		version:   s.Version,
+	}
+}
+
+// Snapshot returns the persisted state of the pool, Restore of the snapshot rebuilds the same pool
+func (p *Pool) Snapshot() Snapshot {
+	return Snapshot{
+		Network:   p.network,
+		Protocol:  p.protocol,
+		Address:   p.address,
+		Fee:       p.fee,
+		Pair:      p.pair,
+		LastPrice: p.lastPrice,
+		Version:   p.version,
+	}
+}
@@ -10,6 +10,8 @@ import (
 
 var (
 	ErrNotFound = errors.New("pool not found")
+	// ErrConflict is returned by Save when the stored pool was changed since it was loaded
+	ErrConflict = errors.New("pool was changed concurrently")
 )
 
 type Repository interface {
@@ -25,6 +27,17 @@ type Filter struct {
 	BaseTokens  []*token.Token
 	QuoteTokens []*token.Token
 	Fees        []values.Percent
+	OrderBy     OrderBy
+	// Limit limits the number of the found entities, zero means no limit
+	Limit  int
+	Offset int
 }
 
+// OrderBy sorts by the field, the "-" prefix sorts in the descending order
 type OrderBy string
+
+const (
+	OrderByNetwork OrderBy = "network"
+	OrderByFee     OrderBy = "fee"
+	OrderByFeeDesc OrderBy = "-fee"
+)
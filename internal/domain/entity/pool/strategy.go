@@ -0,0 +1,285 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+var (
+	ErrUnknownRangeStrategy = errors.New("unknown range strategy")
+	ErrInvalidRangeStrategy = errors.New("invalid range strategy")
+	ErrPriceOutOfBounds     = errors.New("current price is out of the range bounds")
+)
+
+type RangeKind string
+
+const (
+	FixedPercentRange      RangeKind = "fixed-percent"
+	AsymmetricPercentRange RangeKind = "asymmetric-percent"
+	VolatilityRange        RangeKind = "volatility"
+	AbsoluteRange          RangeKind = "absolute"
+)
+
+// maxVolatilityPercent caps the measured volatility ranges, the lower price of the range stays positive
+const maxVolatilityPercent = 0.99
+
+// RangeStrategy calculates the price range of a new position in the pool
+type RangeStrategy interface {
+	Kind() RangeKind
+	// Validate checks the strategy parameters before the strategy is assigned to a project
+	Validate() error
+	Range(ctx context.Context, src RangeSource, p *Pool) (*Range, error)
+}
+
+// RangeSource provides the strategies with the pool data, the ranges are snapped to the pool ticks
+type RangeSource interface {
+	// PercentRange is the range by the percents below and above the current pool price
+	PercentRange(ctx context.Context, p *Pool, below, above values.Percent) (*Range, error)
+	// PriceRange is the range between the prices, the current pool price must be within them
+	PriceRange(ctx context.Context, p *Pool, lower, upper values.Price) (*Range, error)
+	// PriceHistory is the average prices of the last periods of the interval length from the oldest one
+	PriceHistory(ctx context.Context, p *Pool, interval time.Duration, periods int) ([]values.Price, error)
+}
+
+// FixedPercent is the symmetric range by the percent around the current price
+type FixedPercent struct {
+	Percent values.Percent `json:"percent"`
+}
+
+func (s FixedPercent) Kind() RangeKind { return FixedPercentRange }
+
+func (s FixedPercent) Validate() error {
+	if s.Percent <= 0 || s.Percent >= 1 {
+		return fmt.Errorf("%w: percent %f not in (0, 1)", ErrInvalidRangeStrategy, s.Percent)
+	}
+	return nil
+}
+
+func (s FixedPercent) Range(ctx context.Context, src RangeSource, p *Pool) (*Range, error) {
+	return src.PercentRange(ctx, p, s.Percent, s.Percent)
+}
+
+// AsymmetricPercent is the range by the Below percent below and the Above percent above the current price
+type AsymmetricPercent struct {
+	Below values.Percent `json:"below"`
+	Above values.Percent `json:"above"`
+}
+
+func (s AsymmetricPercent) Kind() RangeKind { return AsymmetricPercentRange }
+
+func (s AsymmetricPercent) Validate() error {
+	if s.Below <= 0 || s.Below >= 1 || s.Above <= 0 {
+		return fmt.Errorf("%w: below %f not in (0, 1) or above %f not positive", ErrInvalidRangeStrategy, s.Below, s.Above)
+	}
+	return nil
+}
+
+func (s AsymmetricPercent) Range(ctx context.Context, src RangeSource, p *Pool) (*Range, error) {
+	return src.PercentRange(ctx, p, s.Below, s.Above)
+}
+
+// VolatilityMeasure measures the price volatility of the periods
+type VolatilityMeasure string
+
+const (
+	// MeanAbsChange is the mean absolute change of the average price between the periods
+	MeanAbsChange VolatilityMeasure = "mean-abs-change"
+	// StdDev is the standard deviation of the period prices
+	StdDev VolatilityMeasure = "stddev"
+)
+
+// Volatility is the symmetric range by the Multiplier volatilities of the price history around the current price,
+// the volatility is measured over the last Periods periods of the Interval length and capped below 100%
+type Volatility struct {
+	Measure    VolatilityMeasure `json:"measure"`
+	Interval   time.Duration     `json:"interval"`
+	Periods    int               `json:"periods"`
+	Multiplier float64           `json:"multiplier"`
+}
+
+func (s Volatility) Kind() RangeKind { return VolatilityRange }
+
+func (s Volatility) Validate() error {
+	if s.Measure != MeanAbsChange && s.Measure != StdDev {
+		return fmt.Errorf("%w: unknown volatility measure %q", ErrInvalidRangeStrategy, s.Measure)
+	}
+	if s.Periods < 2 || s.Multiplier <= 0 || s.Interval < time.Second {
+		return fmt.Errorf("%w: %d periods of %s, %f multiplier", ErrInvalidRangeStrategy, s.Periods, s.Interval, s.Multiplier)
+	}
+	// the pool oracle looks back by uint32 seconds
+	if s.Interval > time.Duration(math.MaxUint32)*time.Second/time.Duration(s.Periods) {
+		return fmt.Errorf("%w: %d periods of %s exceed the oracle lookback", ErrInvalidRangeStrategy, s.Periods, s.Interval)
+	}
+	return nil
+}
+
+func (s Volatility) Range(ctx context.Context, src RangeSource, p *Pool) (*Range, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	history, err := src.PriceHistory(ctx, p, s.Interval, s.Periods)
+	if err != nil {
+		return nil, fmt.Errorf("price history: %w", err)
+	}
+	// изменение цены измеряется между периодами, одного периода недостаточно
+	if len(history) < 2 {
+		return nil, fmt.Errorf("price history of %d periods, at least 2 are required", len(history))
+	}
+	prices := make([]float64, len(history))
+	for i, price := range history {
+		prices[i], _ = price.Float().Float64()
+	}
+
+	last := prices[len(prices)-1]
+	if last <= 0 {
+		return nil, fmt.Errorf("invalid last average price %f", last)
+	}
+
+	volatility := meanAbsChange(prices)
+	if s.Measure == StdDev {
+		volatility = stdDev(prices)
+	}
+
+	// волатильность в процентах от последней цены, нижняя граница диапазона должна остаться положительной
+	percent := values.NewPercent(math.Min(s.Multiplier*volatility/last, maxVolatilityPercent))
+	return src.PercentRange(ctx, p, percent, percent)
+}
+
+// meanAbsChange is the mean absolute change of the price between the periods
+func meanAbsChange(prices []float64) float64 {
+	var sum float64
+	for i := 1; i < len(prices); i++ {
+		sum += math.Abs(prices[i] - prices[i-1])
+	}
+	return sum / float64(len(prices)-1)
+}
+
+func stdDev(prices []float64) float64 {
+	var mean float64
+	for _, p := range prices {
+		mean += p
+	}
+	mean /= float64(len(prices))
+
+	var variance float64
+	for _, p := range prices {
+		variance += (p - mean) * (p - mean)
+	}
+	return math.Sqrt(variance / float64(len(prices)-1))
+}
+
+// AbsoluteBounds is the range between the fixed prices of one pair, the bounds of the reversed pool pair
+// are inverted into the prices of the pool pair
+type AbsoluteBounds struct {
+	Lower values.Price
+	Upper values.Price
+}
+
+// absoluteBoundsParams are the persisted bounds, the prices of the pool pair in the decimal text
+type absoluteBoundsParams struct {
+	Lower string `json:"lower"`
+	Upper string `json:"upper"`
+}
+
+func (s AbsoluteBounds) Kind() RangeKind { return AbsoluteRange }
+
+func (s AbsoluteBounds) Validate() error {
+	if s.Lower.Pair() == nil || s.Upper.Pair() == nil {
+		return fmt.Errorf("%w: bounds without the pair", ErrInvalidRangeStrategy)
+	}
+	if !samePair(s.Lower.Pair(), s.Upper.Pair()) {
+		return fmt.Errorf("%w: bounds of the %s and %s pairs", ErrInvalidRangeStrategy, s.Lower.Pair(), s.Upper.Pair())
+	}
+	if s.Lower.Float().Sign() <= 0 || s.Upper.Cmp(s.Lower) <= 0 {
+		return fmt.Errorf("%w: bounds [%f, %f]", ErrInvalidRangeStrategy, s.Lower, s.Upper)
+	}
+	return nil
+}
+
+func (s AbsoluteBounds) Range(ctx context.Context, src RangeSource, p *Pool) (*Range, error) {
+	bounds, err := s.ForPair(p.Pair())
+	if err != nil {
+		return nil, err
+	}
+	return src.PriceRange(ctx, p, bounds.Lower, bounds.Upper)
+}
+
+// ForPair returns the valid bounds in the prices of the pair, the inverted bounds of the reversed pair
+// swap the lower and the upper ones
+func (s AbsoluteBounds) ForPair(pair *token.Pair) (AbsoluteBounds, error) {
+	if err := s.Validate(); err != nil {
+		return AbsoluteBounds{}, err
+	}
+	switch reversed := token.NewPair(pair.QuoteToken(), pair.BaseToken()); {
+	case samePair(s.Lower.Pair(), pair):
+		return s, nil
+	case samePair(s.Lower.Pair(), reversed):
+		return AbsoluteBounds{Lower: s.Upper.Invert(), Upper: s.Lower.Invert()}, nil
+	default:
+		return AbsoluteBounds{}, fmt.Errorf("%w: bounds of the %s pair for the %s pool", ErrInvalidRangeStrategy, s.Lower.Pair(), pair)
+	}
+}
+
+// MarshalJSON encodes the valid bounds, the prices are persisted without the pair,
+// so the bounds are brought to the pool pair by ForPair before they are persisted
+func (s AbsoluteBounds) MarshalJSON() ([]byte, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(absoluteBoundsParams{Lower: s.Lower.Float().Text('f', -1), Upper: s.Upper.Float().Text('f', -1)})
+}
+
+// MarshalRangeStrategy encodes the strategy parameters to persist them along with the strategy kind
+func MarshalRangeStrategy(s RangeStrategy) ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// UnmarshalRangeStrategy restores the strategy of the kind from its persisted parameters,
+// the prices in the parameters are of the pool pair
+func UnmarshalRangeStrategy(kind RangeKind, params []byte, pair *token.Pair) (RangeStrategy, error) {
+	switch kind {
+	case FixedPercentRange:
+		return unmarshalRangeStrategy[FixedPercent](kind, params)
+	case AsymmetricPercentRange:
+		return unmarshalRangeStrategy[AsymmetricPercent](kind, params)
+	case VolatilityRange:
+		return unmarshalRangeStrategy[Volatility](kind, params)
+	case AbsoluteRange:
+		var bp absoluteBoundsParams
+		if err := json.Unmarshal(params, &bp); err != nil {
+			return nil, fmt.Errorf("%s range strategy: %w", kind, err)
+		}
+		lower, ok := new(big.Rat).SetString(bp.Lower)
+		if !ok {
+			return nil, fmt.Errorf("%s range strategy: invalid lower price %q", kind, bp.Lower)
+		}
+		upper, ok := new(big.Rat).SetString(bp.Upper)
+		if !ok {
+			return nil, fmt.Errorf("%s range strategy: invalid upper price %q", kind, bp.Upper)
+		}
+		return AbsoluteBounds{Lower: values.NewPrice(pair, lower), Upper: values.NewPrice(pair, upper)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownRangeStrategy, kind)
+	}
+}
+
+func samePair(a, b *token.Pair) bool {
+	return a.BaseToken().Eq(b.BaseToken()) && a.QuoteToken().Eq(b.QuoteToken())
+}
+
+func unmarshalRangeStrategy[S RangeStrategy](kind RangeKind, params []byte) (RangeStrategy, error) {
+	var s S
+	if err := json.Unmarshal(params, &s); err != nil {
+		return nil, fmt.Errorf("%s range strategy: %w", kind, err)
+	}
+	return s, nil
+}
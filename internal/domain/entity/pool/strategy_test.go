@@ -0,0 +1,241 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+// stubSource returns the price history and records the requested ranges
+type stubSource struct {
+	history      []values.Price
+	below, above values.Percent
+	lower, upper values.Price
+}
+
+func (s *stubSource) PercentRange(_ context.Context, _ *Pool, below, above values.Percent) (*Range, error) {
+	s.below, s.above = below, above
+	return &Range{}, nil
+}
+
+func (s *stubSource) PriceRange(_ context.Context, _ *Pool, lower, upper values.Price) (*Range, error) {
+	s.lower, s.upper = lower, upper
+	return &Range{}, nil
+}
+
+func (s *stubSource) PriceHistory(context.Context, *Pool, time.Duration, int) ([]values.Price, error) {
+	return s.history, nil
+}
+
+func testPool() *Pool {
+	weth := token.New("ethereum", "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "WETH", 18)
+	usdc := token.New("ethereum", "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "USDC", 6)
+	return Restore(Snapshot{Pair: token.NewPair(weth, usdc)})
+}
+
+func TestVolatilityRange(t *testing.T) {
+	p := testPool()
+	prices := func(vv ...float64) []values.Price {
+		pp := make([]values.Price, len(vv))
+		for i, v := range vv {
+			pp[i] = values.NewPrice(p.Pair(), v)
+		}
+		return pp
+	}
+
+	tests := []struct {
+		name     string
+		strategy Volatility
+		history  []values.Price
+		want     values.Percent
+	}{
+		{
+			name:     "mean absolute change",
+			strategy: Volatility{Measure: MeanAbsChange, Interval: time.Hour, Periods: 3, Multiplier: 2},
+			history:  prices(100, 102, 100),
+			want:     0.04,
+		},
+		{
+			name:     "standard deviation",
+			strategy: Volatility{Measure: StdDev, Interval: time.Hour, Periods: 3, Multiplier: 1},
+			history:  prices(90, 100, 110),
+			want:     0.09090909090909091,
+		},
+		{
+			name:     "capped below 100%",
+			strategy: Volatility{Measure: MeanAbsChange, Interval: time.Hour, Periods: 2, Multiplier: 10},
+			history:  prices(100, 50),
+			want:     maxVolatilityPercent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &stubSource{history: tt.history}
+			if _, err := tt.strategy.Range(context.Background(), src, p); err != nil {
+				t.Fatal(err)
+			}
+			if src.below != tt.want || src.above != tt.want {
+				t.Errorf("range percents = %v, %v, want %v", src.below, src.above, tt.want)
+			}
+		})
+	}
+}
+
+func TestVolatilityRangeShortHistory(t *testing.T) {
+	p := testPool()
+	strategy := Volatility{Measure: StdDev, Interval: time.Hour, Periods: 24, Multiplier: 2}
+	// the source may return fewer periods than requested, e.g. for a new pool
+	for _, history := range [][]values.Price{nil, {values.NewPrice(p.Pair(), 2000)}} {
+		src := &stubSource{history: history}
+		if _, err := strategy.Range(context.Background(), src, p); err == nil {
+			t.Errorf("range of %d periods calculated", len(history))
+		}
+		if src.below != 0 || src.above != 0 {
+			t.Errorf("range of %d periods requested", len(history))
+		}
+	}
+}
+
+func TestAbsoluteBoundsRange(t *testing.T) {
+	p := testPool()
+	reversed := token.NewPair(p.Pair().QuoteToken(), p.Pair().BaseToken())
+	other := token.NewPair(p.Pair().BaseToken(), token.New("ethereum", "0xdAC17F958D2ee523a2206206994597C13D831ec7", "USDT", 6))
+
+	tests := []struct {
+		name         string
+		bounds       AbsoluteBounds
+		lower, upper float64
+	}{
+		{"pool pair", AbsoluteBounds{Lower: values.NewPrice(p.Pair(), 1500), Upper: values.NewPrice(p.Pair(), 2500)}, 1500, 2500},
+		// 0.0004 and 0.0008 WETH per USDC are 2500 and 1250 USDC per WETH
+		{"reversed pair", AbsoluteBounds{Lower: values.NewPrice(reversed, 0.0004), Upper: values.NewPrice(reversed, 0.0008)}, 1250, 2500},
+	}
+	for _, tt := range tests {
+		src := &stubSource{}
+		if _, err := tt.bounds.Range(context.Background(), src, p); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !samePair(src.lower.Pair(), p.Pair()) || !samePair(src.upper.Pair(), p.Pair()) ||
+			src.lower.Cmp(values.NewPrice(p.Pair(), tt.lower)) != 0 || src.upper.Cmp(values.NewPrice(p.Pair(), tt.upper)) != 0 {
+			t.Errorf("%s: range [%s, %s], want [%v, %v]", tt.name, src.lower, src.upper, tt.lower, tt.upper)
+		}
+	}
+
+	for name, bounds := range map[string]AbsoluteBounds{
+		"other pair":      {Lower: values.NewPrice(other, 0.9), Upper: values.NewPrice(other, 1.1)},
+		"different pairs": {Lower: values.NewPrice(p.Pair(), 1500), Upper: values.NewPrice(reversed, 0.0004)},
+	} {
+		src := &stubSource{}
+		if _, err := bounds.Range(context.Background(), src, p); !errors.Is(err, ErrInvalidRangeStrategy) {
+			t.Errorf("%s: expected the invalid strategy error, got %v", name, err)
+		}
+		if src.lower.Pair() != nil {
+			t.Errorf("%s: range requested", name)
+		}
+	}
+}
+
+func TestRangeStrategyValidate(t *testing.T) {
+	p := testPool()
+	reversed := token.NewPair(p.Pair().QuoteToken(), p.Pair().BaseToken())
+	tests := []struct {
+		name     string
+		strategy RangeStrategy
+		valid    bool
+	}{
+		{"fixed percent", FixedPercent{Percent: 0.05}, true},
+		{"fixed percent of 100%", FixedPercent{Percent: 1}, false},
+		{"asymmetric percent", AsymmetricPercent{Below: 0.02, Above: 0.1}, true},
+		{"asymmetric percent below 100%", AsymmetricPercent{Below: 1.5, Above: 0.1}, false},
+		{"volatility", Volatility{Measure: StdDev, Interval: time.Hour, Periods: 24, Multiplier: 2}, true},
+		{"unknown measure", Volatility{Measure: "atr", Interval: time.Hour, Periods: 24, Multiplier: 2}, false},
+		{"volatility beyond the oracle lookback", Volatility{Measure: StdDev, Interval: 24 * time.Hour, Periods: 50000, Multiplier: 2}, false},
+		{"absolute bounds", AbsoluteBounds{Lower: values.NewPrice(p.Pair(), 1500), Upper: values.NewPrice(p.Pair(), 2500)}, true},
+		{"inverted absolute bounds", AbsoluteBounds{Lower: values.NewPrice(p.Pair(), 2500), Upper: values.NewPrice(p.Pair(), 1500)}, false},
+		{"absolute bounds of the reversed pair", AbsoluteBounds{Lower: values.NewPrice(reversed, 0.0004), Upper: values.NewPrice(reversed, 0.0008)}, true},
+		// the upper bound of 0.0004 WETH per USDC is 2500 USDC per WETH, still the bounds of one pair are required
+		{"absolute bounds of different pairs", AbsoluteBounds{Lower: values.NewPrice(p.Pair(), 1500), Upper: values.NewPrice(reversed, 0.0004)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.strategy.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidRangeStrategy) {
+				t.Errorf("Validate() = %v, want %v", err, ErrInvalidRangeStrategy)
+			}
+		})
+	}
+}
+
+func TestRangeStrategyMarshal(t *testing.T) {
+	p := testPool()
+	reversed := token.NewPair(p.Pair().QuoteToken(), p.Pair().BaseToken())
+
+	// the strategies are restored the same for the pool of either pair, the bounds are in the prices of the pool pair
+	for _, pair := range []*token.Pair{p.Pair(), reversed} {
+		strategies := []RangeStrategy{
+			FixedPercent{Percent: 0.05},
+			AsymmetricPercent{Below: 0.02, Above: 0.1},
+			Volatility{Measure: MeanAbsChange, Interval: time.Hour, Periods: 24, Multiplier: 2},
+			Volatility{Measure: StdDev, Interval: 15 * time.Minute, Periods: 96, Multiplier: 1.5},
+			AbsoluteBounds{Lower: values.NewPrice(pair, 1500.25), Upper: values.NewPrice(pair, 2500)},
+		}
+		for _, s := range strategies {
+			params, err := MarshalRangeStrategy(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := UnmarshalRangeStrategy(s.Kind(), params, pair)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Kind() != s.Kind() {
+				t.Errorf("%s restored as %s", s.Kind(), got.Kind())
+			}
+			if b, ok := s.(AbsoluteBounds); ok {
+				gb := got.(AbsoluteBounds)
+				if !samePair(gb.Lower.Pair(), pair) || !samePair(gb.Upper.Pair(), pair) ||
+					gb.Lower.Cmp(b.Lower) != 0 || gb.Upper.Cmp(b.Upper) != 0 {
+					t.Errorf("%s of %s restored as %s", params, pair, got)
+				}
+				continue
+			}
+			if got != s {
+				t.Errorf("%s of %s restored as %v", params, pair, got)
+			}
+		}
+	}
+
+	// the bounds of the reversed pair are brought to the pool pair to be persisted
+	bounds, err := AbsoluteBounds{Lower: values.NewPrice(reversed, 0.0004), Upper: values.NewPrice(reversed, 0.0008)}.ForPair(p.Pair())
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := MarshalRangeStrategy(bounds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(params) != `{"lower":"1250","upper":"2500"}` {
+		t.Errorf("reversed bounds persisted as %s", params)
+	}
+
+	for name, s := range map[string]AbsoluteBounds{
+		"no pair":         {},
+		"different pairs": {Lower: values.NewPrice(p.Pair(), 1500), Upper: values.NewPrice(reversed, 0.0004)},
+		"inverted":        {Lower: values.NewPrice(p.Pair(), 2500), Upper: values.NewPrice(p.Pair(), 1500)},
+	} {
+		if _, err := MarshalRangeStrategy(s); !errors.Is(err, ErrInvalidRangeStrategy) {
+			t.Errorf("%s bounds persisted: %v", name, err)
+		}
+	}
+
+	if _, err := UnmarshalRangeStrategy("unknown", []byte("{}"), p.Pair()); !errors.Is(err, ErrUnknownRangeStrategy) {
+		t.Errorf("unknown strategy restored: %v", err)
+	}
+}
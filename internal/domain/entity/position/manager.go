@@ -2,17 +2,21 @@ package position
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/big"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/r1der/epos/internal/domain/entity/optimistic"
+	"github.com/r1der/epos/internal/domain/entity/pool"
 	"github.com/r1der/epos/internal/domain/entity/project"
 	"github.com/r1der/epos/internal/domain/ports"
 	"github.com/r1der/epos/internal/domain/values"
 )
 
+var ErrNotOpen = errors.New("position is not open")
+
 type Manager interface {
 	Open(ctx context.Context, in *OpenPositionInput) (*Position, error)
 	Close(ctx context.Context, pos *Position) error
@@ -20,6 +24,7 @@ type Manager interface {
 	CollectRewards(ctx context.Context, pos *Position) ([]values.Amount, error)
 
 	GetOpenPositions(ctx context.Context, proj *project.Project) ([]*Position, error)
+	GetClosedPositions(ctx context.Context, proj *project.Project, since time.Time) ([]*Position, error)
 }
 
 type manager struct {
@@ -34,20 +39,40 @@ func NewManager(repo Repository, liquidityManager ports.LiquidityManager) Manage
 	}
 }
 
-// GetOpenPositions gets open positions for selected project
+// GetOpenPositions gets open positions for selected project, the positions refer to the project instance
 func (svc *manager) GetOpenPositions(ctx context.Context, proj *project.Project) ([]*Position, error) {
 	pp, err := svc.repo.Find(ctx, Filter{Projects: []*project.Project{proj}, Statuses: []Status{Open}})
 	if err != nil {
 		return nil, fmt.Errorf("find open positions in repo: %w", err)
 	}
-	return pp, nil
+	return attach(pp, proj), nil
+}
+
+// GetClosedPositions gets the positions of the project closed since the time
+func (svc *manager) GetClosedPositions(ctx context.Context, proj *project.Project, since time.Time) ([]*Position, error) {
+	pp, err := svc.repo.Find(ctx, Filter{Projects: []*project.Project{proj}, Statuses: []Status{Closed}, ClosedFrom: since})
+	if err != nil {
+		return nil, fmt.Errorf("find closed positions in repo: %w", err)
+	}
+	return attach(pp, proj), nil
+}
+
+// attach makes the positions loaded by the project refer to the project instance and its pool,
+// so the changes of the project made through the positions are seen by the project holder
+func attach(pp []*Position, proj *project.Project) []*Position {
+	for _, p := range pp {
+		p.project, p.pool = proj, proj.Pool()
+	}
+	return pp
 }
 
 type OpenPositionInput struct {
 	Project     *project.Project
-	InitPrice   *big.Float
-	LowerPrice  *big.Float
-	UpperPrice  *big.Float
+	InitPrice   values.Price
+	LowerPrice  values.Price
+	UpperPrice  values.Price
+	LowerTick   int
+	UpperTick   int
 	BaseAmount  values.Amount
 	QuoteAmount values.Amount
 }
@@ -56,17 +81,26 @@ type OpenPositionInput struct {
 func (svc *manager) Open(ctx context.Context, in *OpenPositionInput) (*Position, error) {
 	logrus.Debugf("start of opening a new position")
 
+	// нужно учесть slippage для внесения активов
+	baseAmountSlippage := in.BaseAmount.MulRound(in.Project.Slippage(), values.RoundUp)
+	quoteAmountSlippage := in.QuoteAmount.MulRound(in.Project.Slippage(), values.RoundUp)
+
 	p := in.Project.Pool()
 	data, err := svc.liquidityManager.IncreaseLiquidity(ctx, &ports.IncreaseLiquidityInput{
-		Network:     p.Network(),
-		Protocol:    p.Protocol(),
-		PoolAddress: p.Address(),
-		Pair:        p.Pair(),
-		Fee:         p.Fee(),
-		LowerPrice:  in.LowerPrice,
-		UpperPrice:  in.UpperPrice,
-		BaseAmount:  in.BaseAmount,
-		QuoteAmount: in.QuoteAmount,
+		Network:        p.Network(),
+		Protocol:       p.Protocol(),
+		PoolAddress:    p.Address(),
+		Pair:           p.Pair(),
+		Fee:            p.Fee(),
+		Wallet:         in.Project.Wallet(),
+		LowerPrice:     in.LowerPrice,
+		UpperPrice:     in.UpperPrice,
+		LowerTick:      in.LowerTick,
+		UpperTick:      in.UpperTick,
+		BaseAmount:     in.BaseAmount,
+		QuoteAmount:    in.QuoteAmount,
+		BaseMinAmount:  in.BaseAmount.Sub(baseAmountSlippage),
+		QuoteMinAmount: in.QuoteAmount.Sub(quoteAmountSlippage),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("liquidity manager: increase liquidity: %w", err)
@@ -78,6 +112,8 @@ func (svc *manager) Open(ctx context.Context, in *OpenPositionInput) (*Position,
 		address:                 data.Address,
 		lowerPrice:              in.LowerPrice,
 		upperPrice:              in.UpperPrice,
+		lowerTick:               in.LowerTick,
+		upperTick:               in.UpperTick,
 		initialPrice:            in.InitPrice,
 		liquidity:               data.Liquidity,
 		inBaseAmount:            data.BaseAmount,
@@ -101,20 +137,42 @@ func (svc *manager) Open(ctx context.Context, in *OpenPositionInput) (*Position,
 	return pos, nil
 }
 
-// Actualize updates information about position
+// Actualize updates information about position, the time the price left the range buffered
+// by the project rebalance policy is tracked until the price returns into the range
 func (svc *manager) Actualize(ctx context.Context, pos *Position) error {
-	data, err := svc.liquidityManager.GetPosition(ctx, &ports.GetPositionInput{})
+	data, err := svc.liquidityManager.GetPosition(ctx, &ports.GetPositionInput{
+		Network:         pos.Pool().Network(),
+		Protocol:        pos.Pool().Protocol(),
+		PoolAddress:     pos.Pool().Address(),
+		Fee:             pos.Pool().Fee(),
+		Pair:            pos.Pool().Pair(),
+		Wallet:          pos.Project().Wallet(),
+		PositionAddress: pos.Address(),
+	})
 	if err != nil {
 		return fmt.Errorf("liquidity manager: get position: %w", err)
 	}
 
-	pos.currentPrice = data.CurrentPrice
-	pos.currentBaseAmount = data.BaseAmount
-	pos.currentQuoteAmount = data.QuoteAmount
-	pos.currentBaseAccruedFees = data.BaseAccruedFees
-	pos.currentQuoteAccruedFees = data.QuoteAccruedFees
-
-	if err = svc.repo.Save(ctx, pos); err != nil {
+	err = svc.update(ctx, pos, func(p *Position) error {
+		if !p.IsOpen() {
+			return ErrNotOpen
+		}
+		p.currentPrice = data.CurrentPrice
+		p.liquidity = data.Liquidity
+		p.currentBaseAmount = data.BaseAmount
+		p.currentQuoteAmount = data.QuoteAmount
+		p.currentBaseAccruedFees = data.BaseAccruedFees
+		p.currentQuoteAccruedFees = data.QuoteAccruedFees
+		switch {
+		case !p.IsOutOfRange(p.Project().RebalancePolicy().Buffer):
+			p.outOfRangeSince = nil
+		case p.outOfRangeSince == nil:
+			now := time.Now()
+			p.outOfRangeSince = &now
+		}
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("save position in repo after actualize: %w", err)
 	}
 
@@ -124,9 +182,9 @@ func (svc *manager) Actualize(ctx context.Context, pos *Position) error {
 // Close closes position (remove liquidity)
 func (svc *manager) Close(ctx context.Context, pos *Position) error {
 	// нужно учесть slippage для получения активов
-	baseAmountSlippage := pos.currentBaseAmount.Mul(pos.Project().Slippage())
+	baseAmountSlippage := pos.currentBaseAmount.MulRound(pos.Project().Slippage(), values.RoundUp)
 	baseAmountWithSlippage := pos.currentBaseAmount.Sub(baseAmountSlippage)
-	quoteAmountSlippage := pos.currentQuoteAmount.Mul(pos.Project().Slippage())
+	quoteAmountSlippage := pos.currentQuoteAmount.MulRound(pos.Project().Slippage(), values.RoundUp)
 	quoteAmountWithSlippage := pos.currentQuoteAmount.Sub(quoteAmountSlippage)
 
 	data, err := svc.liquidityManager.DecreaseLiquidity(ctx, &ports.DecreaseLiquidityInput{
@@ -135,26 +193,83 @@ func (svc *manager) Close(ctx context.Context, pos *Position) error {
 		PoolAddress:     pos.Project().Pool().Address(),
 		Fee:             pos.Project().Pool().Fee(),
 		Pair:            pos.Project().Pool().Pair(),
+		Wallet:          pos.Project().Wallet(),
 		PositionAddress: pos.Address(),
 		Liquidity:       pos.liquidity,
-		BaseMaxAmount:   baseAmountWithSlippage,
-		QuoteMaxAmount:  quoteAmountWithSlippage,
+		BaseMinAmount:   baseAmountWithSlippage,
+		QuoteMinAmount:  quoteAmountWithSlippage,
 	})
 	if err != nil {
 		return fmt.Errorf("liquidity manager: decrease liquidity: %w", err)
 	}
 
-	pos.outBaseAmount = data.BaseAmount
-	pos.outQuoteAmount = data.QuoteAmount
+	closedAt := time.Now()
 
-	if err = svc.repo.Save(ctx, pos); err != nil {
+	err = svc.update(ctx, pos, func(p *Position) error {
+		if !p.IsOpen() {
+			return ErrNotOpen
+		}
+		p.outBaseAmount = data.BaseAmount
+		p.outQuoteAmount = data.QuoteAmount
+		p.transactionFee = p.transactionFee.Add(values.NewAmount(p.transactionFee.Token(), data.TransactionFee))
+		p.status = Closed
+		p.closedAt = &closedAt
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("save position in repo after close: %w", err)
 	}
 
 	return nil
 }
 
-// CollectFees collects position fees
+// CollectRewards collects position fees
 func (svc *manager) CollectRewards(ctx context.Context, pos *Position) ([]values.Amount, error) {
-	return nil, nil
+	data, err := svc.liquidityManager.CollectFees(ctx, &ports.CollectFeesInput{
+		Network:         pos.Pool().Network(),
+		Protocol:        pos.Pool().Protocol(),
+		PoolAddress:     pos.Pool().Address(),
+		Fee:             pos.Pool().Fee(),
+		Pair:            pos.Pool().Pair(),
+		Wallet:          pos.Project().Wallet(),
+		PositionAddress: pos.Address(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("liquidity manager: collect fees: %w", err)
+	}
+
+	// the fees are collected from the closed positions too, the change adds the fee of the collect
+	// transaction to the stored fees, so it holds on the reloaded position as well
+	err = svc.update(ctx, pos, func(p *Position) error {
+		p.transactionFee = p.transactionFee.Add(values.NewAmount(p.transactionFee.Token(), data.TransactionFee))
+		p.currentBaseAccruedFees = values.NewAmount(data.BaseAmount.Token(), 0)
+		p.currentQuoteAccruedFees = values.NewAmount(data.QuoteAmount.Token(), 0)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("save position in repo after collect rewards: %w", err)
+	}
+
+	rewards := make([]values.Amount, 0, 2)
+	for _, amount := range []values.Amount{data.BaseAmount, data.QuoteAmount} {
+		if !amount.IsZero() {
+			rewards = append(rewards, amount)
+		}
+	}
+
+	return rewards, nil
+}
+
+// update applies the change made on-chain to the position and saves it, when the position was changed
+// concurrently it is reloaded keeping its project and pool instances and the change is checked and applied again
+func (svc *manager) update(ctx context.Context, pos *Position, change func(*Position) error) error {
+	reload := func(ctx context.Context) (*Position, error) {
+		stored, err := svc.repo.FindOne(ctx, Filter{Pools: []*pool.Pool{pos.pool}, Addresses: []string{pos.address}})
+		if err != nil {
+			return nil, err
+		}
+		stored.project, stored.pool = pos.project, pos.pool
+		return stored, nil
+	}
+	return optimistic.Update(ctx, pos, ErrConflict, svc.repo.Save, reload, change)
 }
@@ -0,0 +1,159 @@
+package position
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+	"github.com/r1der/epos/internal/domain/ports"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+var (
+	weth = token.New("eth", "0xc02a", "WETH", 18)
+	usdc = token.New("eth", "0xa0b8", "USDC", 6)
+	pair = token.NewPair(weth, usdc)
+)
+
+// repo keeps the saved positions by their addresses
+type repo struct {
+	saved map[string]*Position
+}
+
+func (r *repo) FindOne(_ context.Context, f Filter) (*Position, error) {
+	for _, address := range f.Addresses {
+		if p, ok := r.saved[address]; ok {
+			return p, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *repo) Find(context.Context, Filter) ([]*Position, error) { return nil, nil }
+
+func (r *repo) Save(_ context.Context, p *Position) error {
+	p.SetVersion(p.Version() + 1)
+	r.saved[p.Address()] = p
+	return nil
+}
+
+// liquidityManager records the requests and replies with the amounts of the requests
+type liquidityManager struct {
+	increase *ports.IncreaseLiquidityInput
+	decrease *ports.DecreaseLiquidityInput
+}
+
+func (lm *liquidityManager) IncreaseLiquidity(_ context.Context, in *ports.IncreaseLiquidityInput) (*ports.IncreaseLiquidityOutput, error) {
+	lm.increase = in
+	return &ports.IncreaseLiquidityOutput{
+		Address: "1", Liquidity: big.NewInt(1000), BaseAmount: in.BaseAmount, QuoteAmount: in.QuoteAmount,
+		TransactionFee: big.NewInt(7),
+	}, nil
+}
+
+func (lm *liquidityManager) DecreaseLiquidity(_ context.Context, in *ports.DecreaseLiquidityInput) (*ports.DecreaseLiquidityOutput, error) {
+	lm.decrease = in
+	return &ports.DecreaseLiquidityOutput{
+		Address: in.PositionAddress, Liquidity: in.Liquidity, BaseAmount: in.BaseMinAmount, QuoteAmount: in.QuoteMinAmount,
+		TransactionFee: big.NewInt(5),
+	}, nil
+}
+
+func (lm *liquidityManager) GetPosition(context.Context, *ports.GetPositionInput) (*ports.GetPositionOutput, error) {
+	return nil, nil
+}
+
+func (lm *liquidityManager) CollectFees(context.Context, *ports.CollectFeesInput) (*ports.CollectFeesOutput, error) {
+	return nil, nil
+}
+
+// newProject creates an active project of the WETH/USDC pool with the slippage
+func newProject(slippage float64) *project.Project {
+	p := pool.Restore(pool.Snapshot{
+		Network: "eth", Protocol: "uniswap", Address: "0xp", Fee: pool.LowFee, Pair: pair,
+		LastPrice: values.NewPrice(pair, 2000),
+	})
+	w := wallet.Restore(wallet.Snapshot{Name: "main", Network: "eth", Address: "0xw", KeyRef: "keystore:0xw", NativeToken: weth})
+	return project.Restore(project.Snapshot{
+		ID: uuid.New(), Wallet: w, Pool: p, Name: "eth/usdc",
+		Investments:     values.NewAmount(usdc, 1000),
+		CurrentValue:    values.NewAmount(usdc, 1000),
+		PeakValue:       values.NewAmount(usdc, 1000),
+		IdleBase:        values.NewAmount(weth, 0),
+		IdleQuote:       values.NewAmount(usdc, 1000),
+		RangeVolatility: 0.05,
+		Slippage:        values.NewPercent(slippage),
+		ActivePositions: 1,
+		Status:          project.Active,
+		CreatedAt:       time.Now(),
+	})
+}
+
+func TestManagerSlippage(t *testing.T) {
+	ctx := context.Background()
+
+	// the slippage part of an amount is rounded up, so the minimum amount never exceeds the exact one
+	tests := []struct {
+		name              string
+		slippage          float64
+		base, quote       int64
+		minBase, minQuote int64
+	}{
+		{"exact", 0.01, 1_000_000, 2_000, 990_000, 1_980},
+		{"rounded", 0.01, 1_001, 999, 990, 989},
+		{"small", 0.003, 1, 100, 0, 99},
+		{"zero amount", 0.01, 0, 5_000, 0, 4_950},
+		{"no slippage", 0, 1_001, 999, 1_001, 999},
+		{"full slippage", 1, 1_001, 999, 0, 0},
+	}
+	for _, tt := range tests {
+		lm := &liquidityManager{}
+		svc := NewManager(&repo{saved: map[string]*Position{}}, lm)
+		proj := newProject(tt.slippage)
+
+		pos, err := svc.Open(ctx, &OpenPositionInput{
+			Project:     proj,
+			InitPrice:   values.NewPrice(pair, 2000),
+			LowerPrice:  values.NewPrice(pair, 1900),
+			UpperPrice:  values.NewPrice(pair, 2100),
+			LowerTick:   -100,
+			UpperTick:   100,
+			BaseAmount:  values.NewAmount(weth, tt.base),
+			QuoteAmount: values.NewAmount(usdc, tt.quote),
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		in := lm.increase
+		if in.BaseMinAmount.Value().Int64() != tt.minBase || in.QuoteMinAmount.Value().Int64() != tt.minQuote {
+			t.Errorf("%s: open minimum amounts %s and %s, want %d and %d", tt.name,
+				in.BaseMinAmount.Value(), in.QuoteMinAmount.Value(), tt.minBase, tt.minQuote)
+		}
+		if in.BaseAmount.Value().Int64() != tt.base || in.QuoteAmount.Value().Int64() != tt.quote {
+			t.Errorf("%s: open amounts %s and %s", tt.name, in.BaseAmount, in.QuoteAmount)
+		}
+
+		// the position is closed at the amounts it holds, here the amounts it was opened with
+		if err = svc.Close(ctx, pos); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		out := lm.decrease
+		if out.BaseMinAmount.Value().Int64() != tt.minBase || out.QuoteMinAmount.Value().Int64() != tt.minQuote {
+			t.Errorf("%s: close minimum amounts %s and %s, want %d and %d", tt.name,
+				out.BaseMinAmount.Value(), out.QuoteMinAmount.Value(), tt.minBase, tt.minQuote)
+		}
+		if out.Liquidity.Int64() != 1000 || out.PositionAddress != "1" {
+			t.Errorf("%s: closed %s liquidity of position %s", tt.name, out.Liquidity, out.PositionAddress)
+		}
+		if !pos.IsClosed() || pos.TransactionFee().Value().Int64() != 12 {
+			t.Errorf("%s: position %s with the fee %s after close", tt.name, pos.Status(), pos.TransactionFee())
+		}
+	}
+}
@@ -20,9 +20,11 @@ type Position struct {
 	project        *project.Project
 	pool           *pool.Pool
 	address        string
-	lowerPrice     *big.Float
-	upperPrice     *big.Float
-	initialPrice   *big.Float
+	lowerPrice     values.Price
+	upperPrice     values.Price
+	lowerTick      int
+	upperTick      int
+	initialPrice   values.Price
 	liquidity      *big.Int
 	inBaseAmount   values.Amount
 	inQuoteAmount  values.Amount
@@ -34,19 +36,26 @@ type Position struct {
 	closedAt       *time.Time
 
 	// updatable values
-	currentPrice            *big.Float
+	currentPrice            values.Price
 	currentBaseAmount       values.Amount
 	currentQuoteAmount      values.Amount
 	currentBaseAccruedFees  values.Amount
 	currentQuoteAccruedFees values.Amount
+	// outOfRangeSince is the time the price left the buffered range, nil while the price is in the range
+	outOfRangeSince *time.Time
+
+	// version is the stored version the position was loaded at, zero for an unsaved position
+	version int
 }
 
 func (p *Position) Project() *project.Project        { return p.project }
 func (p *Position) Pool() *pool.Pool                 { return p.pool }
 func (p *Position) Address() string                  { return p.address }
-func (p *Position) LowerPrice() *big.Float           { return p.lowerPrice }
-func (p *Position) UpperPrice() *big.Float           { return p.upperPrice }
-func (p *Position) InitialPrice() *big.Float         { return p.initialPrice }
+func (p *Position) LowerPrice() values.Price         { return p.lowerPrice }
+func (p *Position) UpperPrice() values.Price         { return p.upperPrice }
+func (p *Position) LowerTick() int                   { return p.lowerTick }
+func (p *Position) UpperTick() int                   { return p.upperTick }
+func (p *Position) InitialPrice() values.Price       { return p.initialPrice }
 func (p *Position) Liquidity() *big.Int              { return p.liquidity }
 func (p *Position) InputBaseAmount() values.Amount   { return p.inBaseAmount }
 func (p *Position) InputQuoteAmount() values.Amount  { return p.inQuoteAmount }
@@ -59,11 +68,12 @@ func (p *Position) TransactionFee() values.Amount    { return p.transactionFee }
 func (p *Position) CreatedAt() time.Time             { return p.createdAt }
 func (p *Position) ClosedAt() *time.Time             { return p.closedAt }
 
-func (p *Position) CurrentPrice() *big.Float               { return p.currentPrice }
+func (p *Position) CurrentPrice() values.Price             { return p.currentPrice }
 func (p *Position) CurrentBaseAmount() values.Amount       { return p.currentBaseAmount }
 func (p *Position) CurrentQuoteAmount() values.Amount      { return p.currentQuoteAmount }
 func (p *Position) CurrentBaseAccruedFees() values.Amount  { return p.currentBaseAccruedFees }
 func (p *Position) CurrentQuoteAccruedFees() values.Amount { return p.currentQuoteAccruedFees }
+func (p *Position) OutOfRangeSince() *time.Time            { return p.outOfRangeSince }
 
 func (p *Position) IsInRange() bool {
 	if p.currentPrice.Cmp(p.lowerPrice) >= 0 && p.currentPrice.Cmp(p.upperPrice) <= 0 {
@@ -71,3 +81,119 @@ func (p *Position) IsInRange() bool {
 	}
 	return false
 }
+
+// IsOutOfRange checks the price is beyond the range edges by more than the buffer percent of the edge price
+func (p *Position) IsOutOfRange(buffer values.Percent) bool {
+	lower := p.lowerPrice.Mul(1 - buffer.Value())
+	upper := p.upperPrice.Mul(1 + buffer.Value())
+	return p.currentPrice.Cmp(lower) < 0 || p.currentPrice.Cmp(upper) > 0
+}
+
+// Version is the version of the stored position this position is based on
+func (p *Position) Version() int { return p.version }
+
+// SetVersion is called by the repositories after the position is saved with the new version
+func (p *Position) SetVersion(version int) { p.version = version }
+
+// Snapshot holds the persisted state of the position
+type Snapshot struct {
+	Project                 *project.Project
+	Pool                    *pool.Pool
+	Address                 string
+	LowerPrice              values.Price
+	UpperPrice              values.Price
+	LowerTick               int
+	UpperTick               int
+	InitialPrice            values.Price
+	Liquidity               *big.Int
+	InBaseAmount            values.Amount
+	InQuoteAmount           values.Amount
+	OutBaseAmount           values.Amount
+	OutQuoteAmount          values.Amount
+	Status                  Status
+	TransactionFee          values.Amount
+	CreatedAt               time.Time
+	ClosedAt                *time.Time
+	CurrentPrice            values.Price
+	CurrentBaseAmount       values.Amount
+	CurrentQuoteAmount      values.Amount
+	CurrentBaseAccruedFees  values.Amount
+	CurrentQuoteAccruedFees values.Amount
+	OutOfRangeSince         *time.Time
+	Version                 int
+}
+
+// Restore rebuilds the position from its persisted state
+func Restore(s Snapshot) *Position {
+	return &Position{
+		project:                 s.Project,
+		pool:                    s.Pool,
+		address:                 s.Address,
+		lowerPrice:              s.LowerPrice,
+		upperPrice:              s.UpperPrice,
+		lowerTick:               s.LowerTick,
+		upperTick:               s.UpperTick,
+		initialPrice:            s.InitialPrice,
+		liquidity:               copyInt(s.Liquidity),
+		inBaseAmount:            s.InBaseAmount,
+		inQuoteAmount:           s.InQuoteAmount,
+		outBaseAmount:           s.OutBaseAmount,
+		outQuoteAmount:          s.OutQuoteAmount,
+		status:                  s.Status,
+		transactionFee:          s.TransactionFee,
+		createdAt:               s.CreatedAt,
+		closedAt:                copyTime(s.ClosedAt),
+		currentPrice:            s.CurrentPrice,
+		currentBaseAmount:       s.CurrentBaseAmount,
+		currentQuoteAmount:      s.CurrentQuoteAmount,
+		currentBaseAccruedFees:  s.CurrentBaseAccruedFees,
+		currentQuoteAccruedFees: s.CurrentQuoteAccruedFees,
+		outOfRangeSince:         copyTime(s.OutOfRangeSince),
+		version:                 s.Version,
+	}
+}
+
+// Snapshot returns the persisted state of the position, Restore of the snapshot rebuilds the same position
+func (p *Position) Snapshot() Snapshot {
+	return Snapshot{
+		Project:                 p.project,
+		Pool:                    p.pool,
+		Address:                 p.address,
+		LowerPrice:              p.lowerPrice,
+		UpperPrice:              p.upperPrice,
+		LowerTick:               p.lowerTick,
+		UpperTick:               p.upperTick,
+		InitialPrice:            p.initialPrice,
+		Liquidity:               copyInt(p.liquidity),
+		InBaseAmount:            p.inBaseAmount,
+		InQuoteAmount:           p.inQuoteAmount,
+		OutBaseAmount:           p.outBaseAmount,
+		OutQuoteAmount:          p.outQuoteAmount,
+		Status:                  p.status,
+		TransactionFee:          p.transactionFee,
+		CreatedAt:               p.createdAt,
+		ClosedAt:                copyTime(p.closedAt),
+		CurrentPrice:            p.currentPrice,
+		CurrentBaseAmount:       p.currentBaseAmount,
+		CurrentQuoteAmount:      p.currentQuoteAmount,
+		CurrentBaseAccruedFees:  p.currentBaseAccruedFees,
+		CurrentQuoteAccruedFees: p.currentQuoteAccruedFees,
+		OutOfRangeSince:         copyTime(p.outOfRangeSince),
+		Version:                 p.version,
+	}
+}
+
+func copyInt(v *big.Int) *big.Int {
+	if v == nil {
+		return nil
+	}
+	return new(big.Int).Set(v)
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
@@ -3,6 +3,7 @@ package position
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/r1der/epos/internal/domain/entity/pool"
 	"github.com/r1der/epos/internal/domain/entity/project"
@@ -10,6 +11,8 @@ import (
 
 var (
 	ErrNotFound = errors.New("position not found")
+	// ErrConflict is returned by Save when the stored position was changed since it was loaded
+	ErrConflict = errors.New("position was changed concurrently")
 )
 
 type Repository interface {
@@ -23,6 +26,24 @@ type Filter struct {
 	Pools     []*pool.Pool
 	Addresses []string
 	Statuses  []Status
+	// CreatedFrom and CreatedTo select the entities created in [CreatedFrom, CreatedTo), a zero time is unbounded
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// ClosedFrom and ClosedTo select the positions closed in [ClosedFrom, ClosedTo), a zero time is unbounded
+	ClosedFrom time.Time
+	ClosedTo   time.Time
+	OrderBy    OrderBy
+	// Limit limits the number of the found entities, zero means no limit
+	Limit  int
+	Offset int
 }
 
+// OrderBy sorts by the field, the "-" prefix sorts in the descending order
 type OrderBy string
+
+const (
+	OrderByCreatedAt     OrderBy = "created_at"
+	OrderByCreatedAtDesc OrderBy = "-created_at"
+	OrderByClosedAt      OrderBy = "closed_at"
+	OrderByClosedAtDesc  OrderBy = "-closed_at"
+)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/r1der/epos/internal/domain/entity/optimistic"
 	"github.com/r1der/epos/internal/domain/entity/pool"
 	"github.com/r1der/epos/internal/domain/entity/wallet"
 	"github.com/r1der/epos/internal/domain/ports"
@@ -15,13 +16,17 @@ import (
 )
 
 var (
-	ErrInvestmentsNotEnough = errors.New("investments not enough")
+	ErrInvestmentsNotEnough   = errors.New("investments not enough")
+	ErrNoActivePositions      = errors.New("at least one active position is required")
+	ErrInvalidRebalancePolicy = errors.New("invalid rebalance policy")
+	ErrNotActive              = errors.New("project is not active")
 )
 
 type Manager interface {
 	New(ctx context.Context, in *NewProjectInput) (*Project, error)
 	Deactivate(ctx context.Context, proj *Project, reason InactiveReason) error
 	UpdateWorth(ctx context.Context, proj *Project, worth values.Amount) error
+	MoveFunds(ctx context.Context, proj *Project, received, spent []values.Amount) error
 }
 
 type manager struct {
@@ -44,12 +49,48 @@ type NewProjectInput struct {
 	TakeProfit      values.Percent
 	StopLoss        values.Percent
 	RangeVolatility values.Percent
+	// RangeStrategy calculates the ranges of the positions, nil is the fixed percent by RangeVolatility
+	RangeStrategy   pool.RangeStrategy
 	Slippage        values.Percent
 	ActivePositions int
+	// ConvertOnExit converts the assets left after closing the positions into the investments token
+	// when the project leaves the pool
+	ConvertOnExit bool
+	// TrailingStop exits when the project is in profit and its worth falls by the percent from the peak worth
+	TrailingStop values.Percent
+	// MaxDrawdown exits when the worth falls by the percent from the peak worth
+	MaxDrawdown values.Percent
+	// MaxHoldingDuration exits when the project runs for the duration
+	MaxHoldingDuration time.Duration
+	// RebalancePolicy decides when the out-of-range positions are closed to be re-opened
+	RebalancePolicy RebalancePolicy
 }
 
 // New creates a new smart-pool strategy
 func (svc *manager) New(ctx context.Context, in *NewProjectInput) (*Project, error) {
+	if in.ActivePositions < 1 {
+		return nil, ErrNoActivePositions
+	}
+
+	strategy := in.RangeStrategy
+	if strategy == nil {
+		strategy = pool.FixedPercent{Percent: in.RangeVolatility}
+	}
+	if err := strategy.Validate(); err != nil {
+		return nil, fmt.Errorf("range strategy: %w", err)
+	}
+	// the absolute bounds are persisted in the prices of the pool pair
+	rangeStrategy := in.RangeStrategy
+	if bounds, ok := rangeStrategy.(pool.AbsoluteBounds); ok {
+		var err error
+		if rangeStrategy, err = bounds.ForPair(in.Pool.Pair()); err != nil {
+			return nil, fmt.Errorf("range strategy: %w", err)
+		}
+	}
+	if err := in.RebalancePolicy.Validate(); err != nil {
+		return nil, err
+	}
+
 	// we check if there are funds for investment in the strategy
 	bal, err := svc.balance.Get(ctx, in.Wallet, in.Investments.Token())
 	if err != nil {
@@ -60,22 +101,35 @@ func (svc *manager) New(ctx context.Context, in *NewProjectInput) (*Project, err
 		return nil, ErrInvestmentsNotEnough
 	}
 
+	pair := in.Pool.Pair()
 	proj := &Project{
-		id:              uuid.New(),
-		wallet:          in.Wallet,
-		pool:            in.Pool,
-		name:            in.Name,
-		investments:     in.Investments,
-		takeProfit:      in.TakeProfit,
-		stopLoss:        in.StopLoss,
-		rangeVolatility: in.RangeVolatility,
-		slippage:        in.Slippage,
-		status:          Active,
-		inactiveReason:  EmptyReason,
-		currentValue:    in.Investments,
-		createdAt:       time.Now(),
+		id:                 uuid.New(),
+		wallet:             in.Wallet,
+		pool:               in.Pool,
+		name:               in.Name,
+		investments:        in.Investments,
+		takeProfit:         in.TakeProfit,
+		stopLoss:           in.StopLoss,
+		rangeVolatility:    in.RangeVolatility,
+		rangeStrategy:      rangeStrategy,
+		slippage:           in.Slippage,
+		activePositions:    in.ActivePositions,
+		convertOnExit:      in.ConvertOnExit,
+		trailingStop:       in.TrailingStop,
+		maxDrawdown:        in.MaxDrawdown,
+		maxHoldingDuration: in.MaxHoldingDuration,
+		peakValue:          in.Investments,
+		rebalancePolicy:    in.RebalancePolicy,
+		idleBase:           values.NewAmount(pair.BaseToken(), 0),
+		idleQuote:          values.NewAmount(pair.QuoteToken(), 0),
+		status:             Active,
+		inactiveReason:     EmptyReason,
+		currentValue:       in.Investments,
+		createdAt:          time.Now(),
 	}
 
+	proj.moveFunds([]values.Amount{in.Investments}, nil)
+
 	if err = svc.repo.Save(ctx, proj); err != nil {
 		return nil, fmt.Errorf("save project after create: %w", err)
 	}
@@ -84,19 +138,63 @@ func (svc *manager) New(ctx context.Context, in *NewProjectInput) (*Project, err
 
 // Deactivate makes the project as inactive
 func (svc *manager) Deactivate(ctx context.Context, proj *Project, reason InactiveReason) error {
-	proj.status = Inactive
-	proj.inactiveReason = reason
-	if err := svc.repo.Save(ctx, proj); err != nil {
+	deactivatedAt := time.Now()
+	err := svc.update(ctx, proj, func(p *Project) error {
+		if !p.IsActive() {
+			return ErrNotActive
+		}
+		p.status = Inactive
+		p.inactiveReason = reason
+		p.deactivatedAt = &deactivatedAt
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("save project after deactivate: %w", err)
 	}
 	return nil
 }
 
-// UpdateWorth updates a current project worth
+// UpdateWorth updates a current project worth and the peak worth
 func (svc *manager) UpdateWorth(ctx context.Context, proj *Project, worth values.Amount) error {
-	proj.currentValue = worth
-	if err := svc.repo.Save(ctx, proj); err != nil {
+	err := svc.update(ctx, proj, func(p *Project) error {
+		if !p.IsActive() {
+			return ErrNotActive
+		}
+		p.currentValue = worth
+		if p.peakValue.Token() == nil || worth.Cmp(p.peakValue) > 0 {
+			p.peakValue = worth
+		}
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("save project after update worth: %w", err)
 	}
 	return nil
 }
+
+// MoveFunds records the pool tokens the project received into its idle funds and the ones it spent from them,
+// the funds are moved on-chain already, so the move is applied to the project changed concurrently as well
+func (svc *manager) MoveFunds(ctx context.Context, proj *Project, received, spent []values.Amount) error {
+	err := svc.update(ctx, proj, func(p *Project) error {
+		p.moveFunds(received, spent)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("save project after move funds: %w", err)
+	}
+	return nil
+}
+
+// update applies the change to the project and saves it, when the project was changed concurrently
+// it is reloaded keeping its wallet and pool instances and the change is checked and applied again
+func (svc *manager) update(ctx context.Context, proj *Project, change func(*Project) error) error {
+	reload := func(ctx context.Context) (*Project, error) {
+		stored, err := svc.repo.FindOne(ctx, Filter{Ids: []uuid.UUID{proj.id}})
+		if err != nil {
+			return nil, err
+		}
+		stored.wallet, stored.pool = proj.wallet, proj.pool
+		return stored, nil
+	}
+	return optimistic.Update(ctx, proj, ErrConflict, svc.repo.Save, reload, change)
+}
@@ -1,6 +1,7 @@
 package project
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,10 +23,48 @@ const (
 	StopLoss       InactiveReason = "stop-loss"
 	TakeProfit     InactiveReason = "take-profit"
 	NotEnoughFunds InactiveReason = "not-enough-funds"
-	NotEnoughGas   InactiveReason = "not-enough-gas"
-	EmptyReason    InactiveReason = ""
+	// NotEnoughGas is the reason of the stored projects only, a project without gas stays active to be retried
+	NotEnoughGas InactiveReason = "not-enough-gas"
+	// TrailingStop, MaxDrawdown and MaxHoldingDuration are the exit rules tracking the peak project worth
+	TrailingStop       InactiveReason = "trailing-stop"
+	MaxDrawdown        InactiveReason = "max-drawdown"
+	MaxHoldingDuration InactiveReason = "max-holding-duration"
+	EmptyReason        InactiveReason = ""
 )
 
+// RebalancePolicy decides when the out-of-range positions are closed to be re-opened,
+// the zero fields are disabled so the zero policy rebalances as soon as the price leaves the range
+type RebalancePolicy struct {
+	// Buffer is the percent the price must move beyond the range edge for the position to be out of range
+	Buffer values.Percent
+	// MinOutOfRange is the time the position must stay out of range before it is closed
+	MinOutOfRange time.Duration
+	// Cooldown is the time after the position is opened during which it is not closed
+	Cooldown time.Duration
+	// MaxPerDay caps the positions closed for rebalancing in the last 24 hours
+	MaxPerDay int
+}
+
+// NewRebalancePolicy creates the rebalance policy, the buffer must be below 100% so the buffered range stays positive
+func NewRebalancePolicy(buffer values.Percent, minOutOfRange, cooldown time.Duration, maxPerDay int) (RebalancePolicy, error) {
+	p := RebalancePolicy{Buffer: buffer, MinOutOfRange: minOutOfRange, Cooldown: cooldown, MaxPerDay: maxPerDay}
+	if err := p.Validate(); err != nil {
+		return RebalancePolicy{}, err
+	}
+	return p, nil
+}
+
+// Validate checks the buffer is in [0, 1) and the other fields are not negative
+func (p RebalancePolicy) Validate() error {
+	if p.Buffer < 0 || p.Buffer >= 1 {
+		return fmt.Errorf("%w: buffer %f not in [0, 1)", ErrInvalidRebalancePolicy, p.Buffer)
+	}
+	if p.MinOutOfRange < 0 || p.Cooldown < 0 || p.MaxPerDay < 0 {
+		return fmt.Errorf("%w: negative limits", ErrInvalidRebalancePolicy)
+	}
+	return nil
+}
+
 type Project struct {
 	id              uuid.UUID
 	wallet          *wallet.Wallet
@@ -35,27 +74,196 @@ type Project struct {
 	takeProfit      values.Percent
 	stopLoss        values.Percent
 	rangeVolatility values.Percent
+	// rangeStrategy calculates the ranges of the positions, nil is the fixed percent by rangeVolatility
+	rangeStrategy   pool.RangeStrategy
 	slippage        values.Percent
 	activePositions int
+	convertOnExit   bool
 	currentValue    values.Amount
-	status          Status
-	inactiveReason  InactiveReason
-	createdAt       time.Time
-}
-
-func (p *Project) ID() uuid.UUID                   { return p.id }
-func (p *Project) Wallet() *wallet.Wallet          { return p.wallet }
-func (p *Project) Pool() *pool.Pool                { return p.pool }
-func (p *Project) Name() string                    { return p.name }
-func (p *Project) Investments() values.Amount      { return p.investments }
-func (p *Project) TakeProfit() values.Percent      { return p.takeProfit }
-func (p *Project) StopLoss() values.Percent        { return p.stopLoss }
-func (p *Project) RangeVolatility() values.Percent { return p.rangeVolatility }
-func (p *Project) Slippage() values.Percent        { return p.slippage }
-func (p *Project) ActivePositions() int            { return p.activePositions }
-func (p *Project) Status() Status                  { return p.status }
-func (p *Project) IsActive() bool                  { return p.status == Active }
-func (p *Project) IsInactive() bool                { return p.status == Inactive }
-func (p *Project) InactiveReason() InactiveReason  { return p.inactiveReason }
-func (p *Project) CurrentValue() values.Amount     { return p.currentValue }
-func (p *Project) CreatedAt() time.Time            { return p.createdAt }
+	// the exit rules tracking the peak worth, a zero rule is disabled
+	trailingStop       values.Percent
+	maxDrawdown        values.Percent
+	maxHoldingDuration time.Duration
+	// rebalancePolicy decides when the out-of-range positions are closed to be re-opened
+	rebalancePolicy RebalancePolicy
+	// peakValue is the highest worth the project reached
+	peakValue values.Amount
+	// idleBase and idleQuote are the pool tokens of the project held on the wallet outside of the positions,
+	// the wallet may hold the funds of other projects too
+	idleBase       values.Amount
+	idleQuote      values.Amount
+	status         Status
+	inactiveReason InactiveReason
+	createdAt      time.Time
+	deactivatedAt  *time.Time
+
+	// version is the stored version the project was loaded at, zero for an unsaved project
+	version int
+}
+
+func (p *Project) ID() uuid.UUID                     { return p.id }
+func (p *Project) Wallet() *wallet.Wallet            { return p.wallet }
+func (p *Project) Pool() *pool.Pool                  { return p.pool }
+func (p *Project) Name() string                      { return p.name }
+func (p *Project) Investments() values.Amount        { return p.investments }
+func (p *Project) TakeProfit() values.Percent        { return p.takeProfit }
+func (p *Project) StopLoss() values.Percent          { return p.stopLoss }
+func (p *Project) RangeVolatility() values.Percent   { return p.rangeVolatility }
+func (p *Project) Slippage() values.Percent          { return p.slippage }
+func (p *Project) ActivePositions() int              { return p.activePositions }
+func (p *Project) ConvertOnExit() bool               { return p.convertOnExit }
+func (p *Project) Status() Status                    { return p.status }
+func (p *Project) IsActive() bool                    { return p.status == Active }
+func (p *Project) IsInactive() bool                  { return p.status == Inactive }
+func (p *Project) InactiveReason() InactiveReason    { return p.inactiveReason }
+func (p *Project) CurrentValue() values.Amount       { return p.currentValue }
+func (p *Project) TrailingStop() values.Percent      { return p.trailingStop }
+func (p *Project) MaxDrawdown() values.Percent       { return p.maxDrawdown }
+func (p *Project) MaxHoldingDuration() time.Duration { return p.maxHoldingDuration }
+func (p *Project) PeakValue() values.Amount          { return p.peakValue }
+func (p *Project) RebalancePolicy() RebalancePolicy  { return p.rebalancePolicy }
+func (p *Project) IdleBase() values.Amount           { return p.idleBase }
+func (p *Project) IdleQuote() values.Amount          { return p.idleQuote }
+func (p *Project) CreatedAt() time.Time              { return p.createdAt }
+func (p *Project) DeactivatedAt() *time.Time         { return p.deactivatedAt }
+
+// RangeStrategy is the strategy calculating the ranges of the project positions
+func (p *Project) RangeStrategy() pool.RangeStrategy {
+	if p.rangeStrategy == nil {
+		return pool.FixedPercent{Percent: p.rangeVolatility}
+	}
+	return p.rangeStrategy
+}
+
+// Version is the version of the stored project this project is based on
+func (p *Project) Version() int { return p.version }
+
+// SetVersion is called by the repositories after the project is saved with the new version
+func (p *Project) SetVersion(version int) { p.version = version }
+
+// Snapshot holds the persisted state of the project
+type Snapshot struct {
+	ID                 uuid.UUID
+	Wallet             *wallet.Wallet
+	Pool               *pool.Pool
+	Name               string
+	Investments        values.Amount
+	TakeProfit         values.Percent
+	StopLoss           values.Percent
+	RangeVolatility    values.Percent
+	RangeStrategy      pool.RangeStrategy
+	Slippage           values.Percent
+	ActivePositions    int
+	ConvertOnExit      bool
+	CurrentValue       values.Amount
+	TrailingStop       values.Percent
+	MaxDrawdown        values.Percent
+	MaxHoldingDuration time.Duration
+	PeakValue          values.Amount
+	RebalancePolicy    RebalancePolicy
+	IdleBase           values.Amount
+	IdleQuote          values.Amount
+	Status             Status
+	InactiveReason     InactiveReason
+	CreatedAt          time.Time
+	DeactivatedAt      *time.Time
+	Version            int
+}
+
+// Restore rebuilds the project from its persisted state
+func Restore(s Snapshot) *Project {
+	return &Project{
+		id:                 s.ID,
+		wallet:             s.Wallet,
+		pool:               s.Pool,
+		name:               s.Name,
+		investments:        s.Investments,
+		takeProfit:         s.TakeProfit,
+		stopLoss:           s.StopLoss,
+		rangeVolatility:    s.RangeVolatility,
+		rangeStrategy:      s.RangeStrategy,
+		slippage:           s.Slippage,
+		activePositions:    s.ActivePositions,
+		convertOnExit:      s.ConvertOnExit,
+		currentValue:       s.CurrentValue,
+		trailingStop:       s.TrailingStop,
+		maxDrawdown:        s.MaxDrawdown,
+		maxHoldingDuration: s.MaxHoldingDuration,
+		peakValue:          s.PeakValue,
+		rebalancePolicy:    s.RebalancePolicy,
+		idleBase:           s.IdleBase,
+		idleQuote:          s.IdleQuote,
+		status:             s.Status,
+		inactiveReason:     s.InactiveReason,
+		createdAt:          s.CreatedAt,
+		deactivatedAt:      copyTime(s.DeactivatedAt),
+		version:            s.Version,
+	}
+}
+
+// Snapshot returns the persisted state of the project, Restore of the snapshot rebuilds the same project
+func (p *Project) Snapshot() Snapshot {
+	return Snapshot{
+		ID:                 p.id,
+		Wallet:             p.wallet,
+		Pool:               p.pool,
+		Name:               p.name,
+		Investments:        p.investments,
+		TakeProfit:         p.takeProfit,
+		StopLoss:           p.stopLoss,
+		RangeVolatility:    p.rangeVolatility,
+		RangeStrategy:      p.rangeStrategy,
+		Slippage:           p.slippage,
+		ActivePositions:    p.activePositions,
+		ConvertOnExit:      p.convertOnExit,
+		CurrentValue:       p.currentValue,
+		TrailingStop:       p.trailingStop,
+		MaxDrawdown:        p.maxDrawdown,
+		MaxHoldingDuration: p.maxHoldingDuration,
+		PeakValue:          p.peakValue,
+		RebalancePolicy:    p.rebalancePolicy,
+		IdleBase:           p.idleBase,
+		IdleQuote:          p.idleQuote,
+		Status:             p.status,
+		InactiveReason:     p.inactiveReason,
+		CreatedAt:          p.createdAt,
+		DeactivatedAt:      copyTime(p.deactivatedAt),
+		Version:            p.version,
+	}
+}
+
+// moveFunds adds the received pool tokens to the idle funds and takes the spent ones from them,
+// the idle funds never go below zero and the other tokens are not tracked
+func (p *Project) moveFunds(received, spent []values.Amount) {
+	for _, a := range received {
+		switch {
+		case a.Token().Eq(p.idleBase.Token()):
+			p.idleBase = p.idleBase.Add(a)
+		case a.Token().Eq(p.idleQuote.Token()):
+			p.idleQuote = p.idleQuote.Add(a)
+		}
+	}
+	for _, a := range spent {
+		switch {
+		case a.Token().Eq(p.idleBase.Token()):
+			p.idleBase = takeFunds(p.idleBase, a)
+		case a.Token().Eq(p.idleQuote.Token()):
+			p.idleQuote = takeFunds(p.idleQuote, a)
+		}
+	}
+}
+
+func takeFunds(idle, spent values.Amount) values.Amount {
+	if idle.Cmp(spent) <= 0 {
+		return values.NewAmount(idle.Token(), 0)
+	}
+	return idle.Sub(spent)
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
@@ -3,6 +3,7 @@ package project
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -12,6 +13,8 @@ import (
 
 var (
 	ErrNotFound = errors.New("project not found")
+	// ErrConflict is returned by Save when the stored project was changed since it was loaded
+	ErrConflict = errors.New("project was changed concurrently")
 )
 
 type Repository interface {
@@ -26,6 +29,20 @@ type Filter struct {
 	Pools    []*pool.Pool
 	Statuses []Status
 	Reasons  []InactiveReason
+	// CreatedFrom and CreatedTo select the entities created in [CreatedFrom, CreatedTo), a zero time is unbounded
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	OrderBy     OrderBy
+	// Limit limits the number of the found entities, zero means no limit
+	Limit  int
+	Offset int
 }
 
+// OrderBy sorts by the field, the "-" prefix sorts in the descending order
 type OrderBy string
+
+const (
+	OrderByName          OrderBy = "name"
+	OrderByCreatedAt     OrderBy = "created_at"
+	OrderByCreatedAtDesc OrderBy = "-created_at"
+)
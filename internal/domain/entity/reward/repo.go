@@ -3,6 +3,7 @@ package reward
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/r1der/epos/internal/domain/entity/position"
 	"github.com/r1der/epos/internal/domain/entity/token"
@@ -10,6 +11,8 @@ import (
 
 var (
 	ErrNotFound = errors.New("reward not found")
+	// ErrConflict is returned by Save when the stored reward was changed since it was loaded
+	ErrConflict = errors.New("reward was changed concurrently")
 )
 
 type Repository interface {
@@ -21,6 +24,19 @@ type Repository interface {
 type Filter struct {
 	Positions []*position.Position
 	Tokens    []*token.Token
+	// CreatedFrom and CreatedTo select the entities created in [CreatedFrom, CreatedTo), a zero time is unbounded
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	OrderBy     OrderBy
+	// Limit limits the number of the found entities, zero means no limit
+	Limit  int
+	Offset int
 }
 
+// OrderBy sorts by the field, the "-" prefix sorts in the descending order
 type OrderBy string
+
+const (
+	OrderByCreatedAt     OrderBy = "created_at"
+	OrderByCreatedAtDesc OrderBy = "-created_at"
+)
@@ -14,6 +14,9 @@ type Reward struct {
 	pos       *position.Position
 	amount    values.Amount
 	createdAt time.Time
+
+	// version is the stored version the reward was loaded at, zero for an unsaved reward
+	version int
 }
 
 func (r *Reward) ID() uuid.UUID                { return r.id }
@@ -29,3 +32,40 @@ func New(pos *position.Position, amount values.Amount) *Reward {
 		createdAt: time.Now(),
 	}
 }
+
+// Version is the version of the stored reward this reward is based on
+func (r *Reward) Version() int { return r.version }
+
+// SetVersion is called by the repositories after the reward is saved with the new version
+func (r *Reward) SetVersion(version int) { r.version = version }
+
+// Snapshot holds the persisted state of the reward
+type Snapshot struct {
+	ID        uuid.UUID
+	Position  *position.Position
+	Amount    values.Amount
+	CreatedAt time.Time
+	Version   int
+}
+
+// Restore rebuilds the reward from its persisted state
+func Restore(s Snapshot) *Reward {
+	return &Reward{
+		id:        s.ID,
+		pos:       s.Position,
+		amount:    s.Amount,
+		createdAt: s.CreatedAt,
+		version:   s.Version,
+	}
+}
+
+// Snapshot returns the persisted state of the reward, Restore of the snapshot rebuilds the same reward
+func (r *Reward) Snapshot() Snapshot {
+	return Snapshot{
+		ID:        r.id,
+		Position:  r.pos,
+		Amount:    r.amount,
+		CreatedAt: r.createdAt,
+		Version:   r.version,
+	}
+}
@@ -14,3 +14,9 @@ func (p *Pair) String() string     { return fmt.Sprintf("%s/%s", p.base, p.quote
 func NewPair(base, quote *Token) *Pair {
 	return &Pair{base: base, quote: quote}
 }
+
+// IsBaseToken0 reports whether the base token sorts before the quote token by address,
+// i.e. it is the token0 of the pool
+func (p *Pair) IsBaseToken0() bool {
+	return p.base.addressValue().Cmp(p.quote.addressValue()) < 0
+}
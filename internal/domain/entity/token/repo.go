@@ -19,6 +19,16 @@ type Filter struct {
 	Networks  []string
 	Addresses []string
 	Tickers   []Ticker
+	OrderBy   OrderBy
+	// Limit limits the number of the found entities, zero means no limit
+	Limit  int
+	Offset int
 }
 
+// OrderBy sorts by the field, the "-" prefix sorts in the descending order
 type OrderBy string
+
+const (
+	OrderByNetwork OrderBy = "network"
+	OrderByTicker  OrderBy = "ticker"
+)
@@ -1,8 +1,8 @@
 package token
 
 import (
-	"math"
 	"math/big"
+	"strings"
 )
 
 type Ticker string
@@ -22,16 +22,36 @@ func (t *Token) Ticker() Ticker  { return t.ticker }
 func (t *Token) Decimals() int   { return t.decimals }
 func (t *Token) String() string  { return t.ticker.String() }
 
+// humanPrecision is the mantissa precision of the human values, enough to keep any 256 bit base value exactly
+const humanPrecision = 512
+
+// ToBaseValue converts the human value to the base units, the result is truncated towards zero
 func (t *Token) ToBaseValue(v *big.Float) *big.Int {
-	baseValue, _ := new(big.Float).
-		Mul(v, new(big.Float).SetFloat64(math.Pow10(t.decimals))).
-		Int(nil)
-	return baseValue
+	if v.IsInf() {
+		return new(big.Int)
+	}
+	r, _ := v.Rat(nil)
+	r.Mul(r, new(big.Rat).SetInt(t.unit()))
+	return new(big.Int).Quo(r.Num(), r.Denom())
 }
 
 func (t *Token) ToHumanValue(v *big.Int) *big.Float {
-	return new(big.Float).
-		Quo(new(big.Float).SetInt(v), new(big.Float).SetFloat64(math.Pow10(t.decimals)))
+	return new(big.Float).SetPrec(humanPrecision).
+		Quo(new(big.Float).SetInt(v), new(big.Float).SetInt(t.unit()))
+}
+
+// unit returns the number of the base units in one token
+func (t *Token) unit() *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.decimals)), nil)
+}
+
+// addressValue returns the numeric value of the hex address
+func (t *Token) addressValue() *big.Int {
+	v, _ := new(big.Int).SetString(strings.TrimPrefix(strings.ToLower(t.address), "0x"), 16)
+	if v == nil {
+		return new(big.Int)
+	}
+	return v
 }
 
 func (t *Token) Eq(t2 *Token) bool {
@@ -0,0 +1,17 @@
+package wallet
+
+import "context"
+
+// KeyStore keeps the wallet private keys encrypted, the wallets refer to their keys by the key references
+type KeyStore interface {
+	// Import stores the private key and returns the wallet address and the reference to the stored key
+	Import(ctx context.Context, network, privateKey string) (address, keyRef string, err error)
+	// Address resolves the wallet address of the key reference, e.g. of a key kept by a remote signer
+	Address(ctx context.Context, network, keyRef string) (string, error)
+}
+
+// HDKeyStore derives the wallet keys from the seeds, the wallets keep the references to the derivation paths
+type HDKeyStore interface {
+	// Derive derives the key of the seed at the address index and returns the wallet address and the key reference
+	Derive(ctx context.Context, network, seed string, index uint32) (address, keyRef string, err error)
+}
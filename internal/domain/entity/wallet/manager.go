@@ -2,53 +2,131 @@ package wallet
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/r1der/epos/internal/domain/entity/token"
-	"github.com/r1der/epos/internal/domain/ports"
+)
+
+var (
+	ErrInvalidKey = errors.New("either a private key or a key reference is required")
+	// ErrNoHDKeyStore is returned by Derive when the manager has no HD key store
+	ErrNoHDKeyStore = errors.New("no HD key store")
 )
 
 type Manager interface {
 	New(ctx context.Context, in *NewWalletInput) (*Wallet, error)
+	Derive(ctx context.Context, in *DeriveWalletsInput) ([]*Wallet, error)
 	Get(ctx context.Context, network, address string) (*Wallet, error)
 }
 
 type manager struct {
-	repo          Repository
-	addrGenerator ports.WalletAddressGenerator
+	repo     Repository
+	keyStore KeyStore
+	hdKeys   HDKeyStore
 }
 
-func NewManager(repo Repository, addrGenerator ports.WalletAddressGenerator) Manager {
+// NewManager creates the wallet manager, hdKeys may be nil when no wallets are derived from seeds
+func NewManager(repo Repository, keyStore KeyStore, hdKeys HDKeyStore) Manager {
 	return &manager{
-		repo:          repo,
-		addrGenerator: addrGenerator,
+		repo:     repo,
+		keyStore: keyStore,
+		hdKeys:   hdKeys,
 	}
 }
 
 type NewWalletInput struct {
-	Name        string
-	Network     string
-	PrivateKey  string
+	Name    string
+	Network string
+	// PrivateKey is imported into the key store, the wallet keeps only the reference to it
+	PrivateKey string
+	// KeyRef refers to the key kept outside the bot, e.g. by a remote signer, instead of the PrivateKey
+	KeyRef      string
 	NativeToken *token.Token
 }
 
 // New creates a new wallet in selected network
 func (svc *manager) New(ctx context.Context, in *NewWalletInput) (*Wallet, error) {
-	addr, err := svc.addrGenerator.Generate(ctx, in.Network, in.PrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("generate wallet address: %w", err)
+	var (
+		addr, keyRef string
+		err          error
+	)
+	switch {
+	case in.PrivateKey != "" && in.KeyRef == "":
+		addr, keyRef, err = svc.keyStore.Import(ctx, in.Network, in.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("import wallet key: %w", err)
+		}
+	case in.KeyRef != "" && in.PrivateKey == "":
+		keyRef = in.KeyRef
+		addr, err = svc.keyStore.Address(ctx, in.Network, in.KeyRef)
+		if err != nil {
+			return nil, fmt.Errorf("resolve wallet key reference: %w", err)
+		}
+	default:
+		return nil, ErrInvalidKey
 	}
+
 	return &Wallet{
 		name:        in.Name,
 		network:     in.Network,
 		address:     addr,
-		privateKey:  in.PrivateKey,
+		keyRef:      keyRef,
 		nativeToken: in.NativeToken,
 		createdAt:   time.Now(),
 	}, nil
 }
 
+type DeriveWalletsInput struct {
+	// Name prefixes the wallet names, the address index is appended to it
+	Name    string
+	Network string
+	// Seed names the seed of the HD key store the wallets are derived from
+	Seed string
+	// Count is the number of the wallets to derive
+	Count       int
+	NativeToken *token.Token
+}
+
+// Derive derives and saves the next Count wallets of the seed, the address indexes of the wallets
+// which are already saved are skipped
+func (svc *manager) Derive(ctx context.Context, in *DeriveWalletsInput) ([]*Wallet, error) {
+	if svc.hdKeys == nil {
+		return nil, ErrNoHDKeyStore
+	}
+
+	wallets := make([]*Wallet, 0, in.Count)
+	for index := uint32(0); len(wallets) < in.Count; index++ {
+		addr, keyRef, err := svc.hdKeys.Derive(ctx, in.Network, in.Seed, index)
+		if err != nil {
+			return wallets, fmt.Errorf("derive wallet %d: %w", index, err)
+		}
+
+		_, err = svc.repo.FindOne(ctx, Filter{Networks: []string{in.Network}, Addresses: []string{addr}})
+		switch {
+		case err == nil:
+			continue
+		case !errors.Is(err, ErrNotFound):
+			return wallets, fmt.Errorf("find wallet %s: %w", addr, err)
+		}
+
+		w := &Wallet{
+			name:        fmt.Sprintf("%s %d", in.Name, index),
+			network:     in.Network,
+			address:     addr,
+			keyRef:      keyRef,
+			nativeToken: in.NativeToken,
+			createdAt:   time.Now(),
+		}
+		if err = svc.repo.Save(ctx, w); err != nil {
+			return wallets, fmt.Errorf("save wallet %s: %w", addr, err)
+		}
+		wallets = append(wallets, w)
+	}
+	return wallets, nil
+}
+
 // Get finds a wallet
 func (svc *manager) Get(ctx context.Context, network, address string) (*Wallet, error) {
 	return svc.repo.FindOne(ctx, Filter{Networks: []string{network}, Addresses: []string{address}})
@@ -0,0 +1,133 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+)
+
+var weth = token.New("eth", "0xc02a", "WETH", 18)
+
+// repo keeps the saved wallets by their networks and addresses
+type repo struct {
+	saved   map[string]*Wallet
+	saveErr error
+}
+
+func newRepo(ww ...*Wallet) *repo {
+	r := &repo{saved: map[string]*Wallet{}}
+	for _, w := range ww {
+		r.saved[w.NetworkId()+"/"+w.Address()] = w
+	}
+	return r
+}
+
+func (r *repo) FindOne(_ context.Context, f Filter) (*Wallet, error) {
+	for _, net := range f.Networks {
+		for _, address := range f.Addresses {
+			if w, ok := r.saved[net+"/"+address]; ok {
+				return w, nil
+			}
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *repo) Find(context.Context, Filter) ([]*Wallet, error) { return nil, nil }
+
+func (r *repo) Save(_ context.Context, w *Wallet) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	r.saved[w.NetworkId()+"/"+w.Address()] = w
+	return nil
+}
+
+// hdKeys derives the address of the index as 0xa<index>, it fails the indexes of failAt
+type hdKeys struct {
+	failAt map[uint32]bool
+}
+
+func (k *hdKeys) Derive(_ context.Context, _, seed string, index uint32) (string, string, error) {
+	if k.failAt[index] {
+		return "", "", errors.New("invalid derived key")
+	}
+	return fmt.Sprintf("0xa%d", index), fmt.Sprintf("hd:%s/%d", seed, index), nil
+}
+
+// derived creates the wallet saved at the address index
+func derived(net string, index int) *Wallet {
+	return Restore(Snapshot{
+		Name: fmt.Sprintf("old %d", index), Network: net, Address: fmt.Sprintf("0xa%d", index),
+		KeyRef: fmt.Sprintf("hd:dev/%d", index), NativeToken: weth, CreatedAt: time.Now(),
+	})
+}
+
+func TestManagerDerive(t *testing.T) {
+	ctx := context.Background()
+	// the indexes 0 and 2 are saved, the index 1 is saved on the other network only
+	r := newRepo(derived("eth", 0), derived("eth", 2), derived("arb", 1))
+	m := NewManager(r, nil, &hdKeys{})
+
+	ww, err := m.Derive(ctx, &DeriveWalletsInput{Name: "hot", Network: "eth", Seed: "dev", Count: 3, NativeToken: weth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ name, address, keyRef string }{
+		{"hot 1", "0xa1", "hd:dev/1"},
+		{"hot 3", "0xa3", "hd:dev/3"},
+		{"hot 4", "0xa4", "hd:dev/4"},
+	}
+	if len(ww) != len(want) {
+		t.Fatalf("derived %d wallets, want %d", len(ww), len(want))
+	}
+	for i, w := range ww {
+		if w.Name() != want[i].name || w.Address() != want[i].address || w.KeyRef() != want[i].keyRef ||
+			w.NetworkId() != "eth" || !w.NativeToken().Eq(weth) {
+			t.Errorf("wallet %d: %+v", i, w.Snapshot())
+		}
+		if r.saved["eth/"+w.Address()] != w {
+			t.Errorf("wallet %s is not saved", w.Address())
+		}
+	}
+	// the saved wallets are kept
+	if r.saved["eth/0xa0"].Name() != "old 0" || len(r.saved) != 6 {
+		t.Fatalf("saved %d wallets", len(r.saved))
+	}
+
+	// the next derivation goes on after the derived wallets
+	ww, err = m.Derive(ctx, &DeriveWalletsInput{Name: "hot", Network: "eth", Seed: "dev", Count: 1, NativeToken: weth})
+	if err != nil || len(ww) != 1 || ww[0].Address() != "0xa5" {
+		t.Fatalf("derived %v, %v", ww, err)
+	}
+	if ww, err = m.Derive(ctx, &DeriveWalletsInput{Network: "eth", Seed: "dev"}); err != nil || len(ww) != 0 {
+		t.Fatalf("derived %v of no wallets, %v", ww, err)
+	}
+}
+
+func TestManagerDeriveErrors(t *testing.T) {
+	ctx := context.Background()
+	in := &DeriveWalletsInput{Name: "hot", Network: "eth", Seed: "dev", Count: 3, NativeToken: weth}
+
+	if _, err := NewManager(newRepo(), nil, nil).Derive(ctx, in); !errors.Is(err, ErrNoHDKeyStore) {
+		t.Fatalf("expected the no HD key store error, got %v", err)
+	}
+
+	// the wallets derived before the failed derivation are returned with the error
+	r := newRepo(derived("eth", 0))
+	ww, err := NewManager(r, nil, &hdKeys{failAt: map[uint32]bool{2: true}}).Derive(ctx, in)
+	if err == nil || len(ww) != 1 || ww[0].Address() != "0xa1" || len(r.saved) != 2 {
+		t.Fatalf("derived %d wallets, saved %d, %v", len(ww), len(r.saved), err)
+	}
+
+	r = newRepo()
+	r.saveErr = errors.New("disk full")
+	ww, err = NewManager(r, nil, &hdKeys{}).Derive(ctx, in)
+	if !errors.Is(err, r.saveErr) || len(ww) != 0 {
+		t.Fatalf("derived %d wallets, %v", len(ww), err)
+	}
+}
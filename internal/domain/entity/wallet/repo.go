@@ -3,21 +3,38 @@ package wallet
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 var (
 	ErrNotFound = errors.New("wallet not found")
+	// ErrConflict is returned by Save when the stored wallet was changed since it was loaded
+	ErrConflict = errors.New("wallet was changed concurrently")
 )
 
 type Repository interface {
 	FindOne(context.Context, Filter) (*Wallet, error)
-	FindO(context.Context, Filter) ([]*Wallet, error)
+	Find(context.Context, Filter) ([]*Wallet, error)
 	Save(context.Context, *Wallet) error
 }
 
 type Filter struct {
 	Networks  []string
 	Addresses []string
+	// CreatedFrom and CreatedTo select the entities created in [CreatedFrom, CreatedTo), a zero time is unbounded
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	OrderBy     OrderBy
+	// Limit limits the number of the found entities, zero means no limit
+	Limit  int
+	Offset int
 }
 
+// OrderBy sorts by the field, the "-" prefix sorts in the descending order
 type OrderBy string
+
+const (
+	OrderByName          OrderBy = "name"
+	OrderByCreatedAt     OrderBy = "created_at"
+	OrderByCreatedAtDesc OrderBy = "-created_at"
+)
@@ -6,18 +6,66 @@ import (
 	"github.com/r1der/epos/internal/domain/entity/token"
 )
 
+// Wallet is an account on a network, its private key is kept by the key store
+// and the wallet holds only the reference to the key
 type Wallet struct {
 	name        string
 	network     string
 	address     string
-	privateKey  string
+	keyRef      string
 	nativeToken *token.Token
 	createdAt   time.Time
+
+	// version is the stored version the wallet was loaded at, zero for an unsaved wallet
+	version int
 }
 
 func (w *Wallet) Name() string              { return w.name }
 func (w *Wallet) NetworkId() string         { return w.network }
 func (w *Wallet) Address() string           { return w.address }
-func (w *Wallet) PrivateKey() string        { return w.privateKey }
+func (w *Wallet) KeyRef() string            { return w.keyRef }
 func (w *Wallet) NativeToken() *token.Token { return w.nativeToken }
 func (w *Wallet) CreatedAt() time.Time      { return w.createdAt }
+
+// Version is the version of the stored wallet this wallet is based on
+func (w *Wallet) Version() int { return w.version }
+
+// SetVersion is called by the repositories after the wallet is saved with the new version
+func (w *Wallet) SetVersion(version int) { w.version = version }
+
+// Snapshot holds the persisted state of the wallet
+type Snapshot struct {
+	Name        string
+	Network     string
+	Address     string
+	KeyRef      string
+	NativeToken *token.Token
+	CreatedAt   time.Time
+	Version     int
+}
+
+// Restore rebuilds the wallet from its persisted state
+func Restore(s Snapshot) *Wallet {
+	return &Wallet{
+		name:        s.Name,
+		network:     s.Network,
+		address:     s.Address,
+		keyRef:      s.KeyRef,
+		nativeToken: s.NativeToken,
+		createdAt:   s.CreatedAt,
+		version:     s.Version,
+	}
+}
+
+// Snapshot returns the persisted state of the wallet, Restore of the snapshot rebuilds the same wallet
+func (w *Wallet) Snapshot() Snapshot {
+	return Snapshot{
+		Name:        w.name,
+		Network:     w.network,
+		Address:     w.address,
+		KeyRef:      w.keyRef,
+		NativeToken: w.nativeToken,
+		CreatedAt:   w.createdAt,
+		Version:     w.version,
+	}
+}
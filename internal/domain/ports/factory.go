@@ -3,28 +3,31 @@ package ports
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/r1der/epos/internal/domain/entity/token"
 	"github.com/r1der/epos/internal/domain/values"
 )
 
 type Pool struct {
-	Address   string
-	LastPrice *big.Float
-	Liquidity *big.Int
+	Address     string
+	LastPrice   values.Price
+	Liquidity   *big.Int
+	TickSpacing int
 }
 
 type Factory interface {
 	FindPool(ctx context.Context, network, protocol string, pair *token.Pair, fee values.Percent) (*Pool, error)
-	GetPool(ctx context.Context, network, protocol, address string) (*Pool, error)
+	GetPool(ctx context.Context, network, protocol string, pair *token.Pair, address string) (*Pool, error)
 	CalculateRange(ctx context.Context, in *CalculateRangeInput) (*CalculateRangeOutput, error)
-	CalculateAmounts(ctx context.Context, in *CalculateAmountsInput) (*CalculateAmountsOutput, error)
+	PriceHistory(ctx context.Context, in *PriceHistoryInput) ([]values.Price, error)
 }
 
 type CalculateRangeInput struct {
 	Network         string
 	Protocol        string
 	PoolAddress     string
+	Pair            *token.Pair
 	BaseVolatility  values.Percent
 	QuoteVolatility values.Percent
 }
@@ -33,21 +36,18 @@ type CalculateRangeOutput struct {
 	Network     string
 	Protocol    string
 	PoolAddress string
-	LastPrice   *big.Float
-	LowerPrice  *big.Float
-	UpperPrice  *big.Float
-}
-
-type CalculateAmountsInput struct {
-	InitialPrice *big.Float
-	LowerPrice   *big.Float
-	UpperPrice   *big.Float
-	BaseAmount   values.Amount
-	QuoteAmount  values.Amount
+	LastPrice   values.Price
+	LowerPrice  values.Price
+	UpperPrice  values.Price
+	TickSpacing int
 }
 
-type CalculateAmountsOutput struct {
-	Liquidity   *big.Int
-	BaseAmount  values.Amount
-	QuoteAmount values.Amount
+// PriceHistoryInput selects the last Periods periods of the Interval length
+type PriceHistoryInput struct {
+	Network     string
+	Protocol    string
+	PoolAddress string
+	Pair        *token.Pair
+	Interval    time.Duration
+	Periods     int
 }
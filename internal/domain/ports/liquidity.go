@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
 	"github.com/r1der/epos/internal/domain/values"
 )
 
@@ -12,18 +13,24 @@ type LiquidityManager interface {
 	IncreaseLiquidity(ctx context.Context, in *IncreaseLiquidityInput) (*IncreaseLiquidityOutput, error)
 	DecreaseLiquidity(ctx context.Context, in *DecreaseLiquidityInput) (*DecreaseLiquidityOutput, error)
 	GetPosition(ctx context.Context, in *GetPositionInput) (*GetPositionOutput, error)
+	CollectFees(ctx context.Context, in *CollectFeesInput) (*CollectFeesOutput, error)
 }
 
 type IncreaseLiquidityInput struct {
-	Network     string
-	Protocol    string
-	PoolAddress string
-	Fee         values.Percent
-	Pair        *token.Pair
-	LowerPrice  *big.Float
-	UpperPrice  *big.Float
-	BaseAmount  values.Amount
-	QuoteAmount values.Amount
+	Network        string
+	Protocol       string
+	PoolAddress    string
+	Fee            values.Percent
+	Pair           *token.Pair
+	Wallet         *wallet.Wallet
+	LowerPrice     values.Price
+	UpperPrice     values.Price
+	LowerTick      int
+	UpperTick      int
+	BaseAmount     values.Amount
+	QuoteAmount    values.Amount
+	BaseMinAmount  values.Amount
+	QuoteMinAmount values.Amount
 }
 
 type IncreaseLiquidityOutput struct {
@@ -40,10 +47,11 @@ type DecreaseLiquidityInput struct {
 	PoolAddress     string
 	Fee             values.Percent
 	Pair            *token.Pair
+	Wallet          *wallet.Wallet
 	PositionAddress string
 	Liquidity       *big.Int
-	BaseMaxAmount   values.Amount
-	QuoteMaxAmount  values.Amount
+	BaseMinAmount   values.Amount
+	QuoteMinAmount  values.Amount
 }
 
 type DecreaseLiquidityOutput struct {
@@ -60,14 +68,32 @@ type GetPositionInput struct {
 	PoolAddress     string
 	Fee             values.Percent
 	Pair            *token.Pair
+	Wallet          *wallet.Wallet
 	PositionAddress string
 }
 
 type GetPositionOutput struct {
-	CurrentPrice     *big.Float
+	CurrentPrice     values.Price
 	Liquidity        *big.Int
 	BaseAmount       values.Amount
 	QuoteAmount      values.Amount
 	BaseAccruedFees  values.Amount
 	QuoteAccruedFees values.Amount
 }
+
+type CollectFeesInput struct {
+	Network         string
+	Protocol        string
+	PoolAddress     string
+	Fee             values.Percent
+	Pair            *token.Pair
+	Wallet          *wallet.Wallet
+	PositionAddress string
+}
+
+type CollectFeesOutput struct {
+	Address        string
+	BaseAmount     values.Amount
+	QuoteAmount    values.Amount
+	TransactionFee *big.Int
+}
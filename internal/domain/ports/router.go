@@ -4,9 +4,20 @@ import (
 	"context"
 	"math/big"
 
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
 	"github.com/r1der/epos/internal/domain/values"
 )
 
+type SwapMode string
+
+const (
+	// ExactInput swaps exactly AmountIn and requires at least AmountOut in return
+	ExactInput SwapMode = "exact-input"
+	// ExactOutput swaps at most AmountIn and requires exactly AmountOut in return
+	ExactOutput SwapMode = "exact-output"
+)
+
 type Router interface {
 	Swap(ctx context.Context, in *SwapInput) (*SwapOutput, error)
 }
@@ -16,6 +27,9 @@ type SwapInput struct {
 	Protocol    string
 	PoolAddress string
 	Fee         values.Percent
+	Pair        *token.Pair
+	Wallet      *wallet.Wallet
+	Mode        SwapMode
 	AmountIn    values.Amount
 	AmountOut   values.Amount
 }
@@ -24,6 +38,6 @@ type SwapOutput struct {
 	Address        string
 	AmountIn       values.Amount
 	AmountOut      values.Amount
-	FilledPrice    *big.Float
+	FilledPrice    values.Price
 	TransactionFee *big.Int
 }
@@ -11,7 +11,3 @@ import (
 type Balance interface {
 	Get(ctx context.Context, wa *wallet.Wallet, token *token.Token) (values.Amount, error)
 }
-
-type WalletAddressGenerator interface {
-	Generate(ctx context.Context, network, privateKey string) (string, error)
-}
@@ -2,9 +2,10 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"math/big"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/r1der/epos/internal/domain/entity/position"
 	"github.com/r1der/epos/internal/domain/entity/project"
 	"github.com/r1der/epos/internal/domain/entity/reward"
+	"github.com/r1der/epos/internal/domain/entity/token"
 	"github.com/r1der/epos/internal/domain/ports"
 	"github.com/r1der/epos/internal/domain/values"
 )
@@ -50,11 +52,7 @@ func NewProjectExecutor(
 
 // Execute runs a strategy
 func (svc *projectExecutor) Execute(ctx context.Context, proj *project.Project) error {
-	can, err := svc.canBeExecuted(ctx, proj)
-	if err != nil {
-		return fmt.Errorf("can the project be executed: %w", err)
-	}
-	if !can {
+	if proj.IsInactive() {
 		return nil
 	}
 
@@ -63,29 +61,62 @@ func (svc *projectExecutor) Execute(ctx context.Context, proj *project.Project)
 	if err != nil {
 		return fmt.Errorf("position manager: open positions: %w", err)
 	}
-	logrus.Printf("open positions: %d", len(openPositions))
+	logrus.Printf("open positions: %d of %d", len(openPositions), proj.ActivePositions())
 
-	// если нет активных позиций, запускаем новую
-	if len(openPositions) > 0 {
-		return svc.open(ctx, proj)
+	// получаем актуальные суммы и комиссии открытых позиций
+	for _, pos := range openPositions {
+		if err = svc.actualize(ctx, pos); err != nil {
+			return fmt.Errorf("actualize position %s: %w", pos.Address(), err)
+		}
 	}
 
-	// @todo сделать возможность держать несколько позиций
-	// проверяем, позиция в рендже или нет
-	openPosition := openPositions[0]
-	return svc.check(ctx, proj, openPosition)
+	// переоцениваем проект по текущим ценам, риски проверяются по актуальной стоимости
+	if err = svc.updateWorth(ctx, proj, openPositions); err != nil {
+		return fmt.Errorf("update project worth: %w", err)
+	}
+
+	can, err := svc.canBeExecuted(ctx, proj, openPositions)
+	if err != nil {
+		return fmt.Errorf("can the project be executed: %w", err)
+	}
+	if !can {
+		return nil
+	}
+
+	// проверяем каждую позицию независимо, позиции вне ренджа закрываются по политике ребалансировки
+	var errs []error
+	active := 0
+	for _, pos := range openPositions {
+		if err = svc.check(ctx, proj, pos); err != nil {
+			errs = append(errs, fmt.Errorf("check position %s: %w", pos.Address(), err))
+		}
+		if pos.IsOpen() {
+			active++
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// открываем новые позиции на свободные места
+	for ; active < proj.ActivePositions() && proj.IsActive(); active++ {
+		if err = svc.open(ctx, proj); err != nil {
+			return fmt.Errorf("open position %d of %d: %w", active+1, proj.ActivePositions(), err)
+		}
+	}
+	return nil
 }
 
 // open creates a new position
 func (svc *projectExecutor) open(ctx context.Context, proj *project.Project) error {
 	// определяем диапазон для позиции
 	// диапазон зависит от точки входа и указанной волатильности
-	pricesRange, err := svc.poolManager.CalculatePositionRange(ctx, proj.Pool(), proj.RangeVolatility())
+	pricesRange, err := svc.poolManager.CalculatePositionRange(ctx, proj.Pool(), proj.RangeStrategy())
 	if err != nil {
 		return fmt.Errorf("calculate range: %w", err)
 	}
-	log.Printf("position price range calculated: lower: %f, current: %f, upper: %f",
-		pricesRange.LowerPrice, pricesRange.InitialPrice, pricesRange.UpperPrice)
+	log.Printf("position price range calculated: lower: %f [%d], current: %f, upper: %f [%d]",
+		pricesRange.LowerPrice, pricesRange.LowerTick, pricesRange.InitialPrice, pricesRange.UpperPrice, pricesRange.UpperTick)
 
 	// определяем сумму инвестиций
 	// сумма делиться на кол-во разрешенных открытых позиций
@@ -100,11 +131,11 @@ func (svc *projectExecutor) open(ctx context.Context, proj *project.Project) err
 	half := investment.Div(2)
 
 	if investment.Token().Eq(pair.BaseToken()) { // investment in base asset
-		baseAmount = values.NewAmount(pair.BaseToken(), half.Value())
-		quoteAmount = values.NewAmount(pair.QuoteToken(), half.Mul(pricesRange.InitialPrice).Value())
+		baseAmount = half
+		quoteAmount = pricesRange.InitialPrice.Convert(half)
 	} else if investment.Token().Eq(pair.QuoteToken()) { // investment in quote asset
-		baseAmount = values.NewAmount(pair.BaseToken(), half.Div(pricesRange.InitialPrice).Value())
-		quoteAmount = values.NewAmount(pair.QuoteToken(), half.Value())
+		baseAmount = pricesRange.InitialPrice.Convert(half)
+		quoteAmount = half
 	} else {
 		// @todo реализовать в будущем ZapIn токенов отличных от активов пула
 		return fmt.Errorf("invalid investment token")
@@ -124,7 +155,7 @@ func (svc *projectExecutor) open(ctx context.Context, proj *project.Project) err
 		baseAmount.Value(), baseAmount.Token(), baseAmount.HumanValue(), baseAmount.Token(),
 		quoteAmount.Value(), quoteAmount.Token(), quoteAmount.HumanValue(), quoteAmount.Token())
 
-	// получаем средства на балансе выбранных активов
+	// получаем средства проекта на балансе выбранных активов, кошелек может хранить средства других проектов
 	baseBalance, err := svc.balance.Get(ctx, proj.Wallet(), pair.BaseToken())
 	if err != nil {
 		return fmt.Errorf("get base token balance: %w", err)
@@ -133,6 +164,7 @@ func (svc *projectExecutor) open(ctx context.Context, proj *project.Project) err
 	if err != nil {
 		return fmt.Errorf("get quote token balance: %w", err)
 	}
+	baseBalance, quoteBalance = minAmount(baseBalance, proj.IdleBase()), minAmount(quoteBalance, proj.IdleQuote())
 	logrus.Printf("balances loaded: base %d %s (%f %s), quote: %d %s (%f %s)",
 		baseBalance.Value(), baseBalance.Token(), baseBalance.HumanValue(), baseBalance.Token(),
 		quoteBalance.Value(), quoteBalance.Token(), quoteBalance.HumanValue(), quoteBalance.Token())
@@ -149,7 +181,7 @@ func (svc *projectExecutor) open(ctx context.Context, proj *project.Project) err
 
 	// делаем своп недостающих активов
 	if swapData != nil {
-		if err = svc.swap(ctx, proj, swapData); err != nil {
+		if _, err = svc.swap(ctx, proj, swapData); err != nil {
 			return fmt.Errorf("swap: %w", err)
 		}
 	}
@@ -160,12 +192,17 @@ func (svc *projectExecutor) open(ctx context.Context, proj *project.Project) err
 		InitPrice:   pricesRange.InitialPrice,
 		LowerPrice:  pricesRange.LowerPrice,
 		UpperPrice:  pricesRange.UpperPrice,
+		LowerTick:   pricesRange.LowerTick,
+		UpperTick:   pricesRange.UpperTick,
 		BaseAmount:  baseAmount,
 		QuoteAmount: quoteAmount,
 	})
 	if err != nil {
 		return fmt.Errorf("open position: %w", err)
 	}
+	if err = svc.projectManager.MoveFunds(ctx, proj, nil, []values.Amount{pos.InputBaseAmount(), pos.InputQuoteAmount()}); err != nil {
+		return fmt.Errorf("move funds into position: %w", err)
+	}
 
 	logrus.Printf("position %s on %s/%s openned: %s",
 		pos.Pool().Pair(), pos.Pool().Network(), pos.Pool().Protocol(), pos.Address())
@@ -173,7 +210,7 @@ func (svc *projectExecutor) open(ctx context.Context, proj *project.Project) err
 	return nil
 }
 
-// check checks the current position range
+// check closes the position out of range by the project rebalance policy, the position must be actualized
 func (svc *projectExecutor) check(ctx context.Context, proj *project.Project, pos *position.Position) error {
 	logrus.Debugf("start of checking the current position")
 
@@ -184,22 +221,14 @@ func (svc *projectExecutor) check(ctx context.Context, proj *project.Project, po
 	}
 	logrus.Printf("pool %s updated: last price: %f", p.Pair(), p.LastPrice())
 
-	if err = svc.positionManager.Actualize(ctx, pos); err != nil {
-		return fmt.Errorf("actualize position: %w", err)
+	rebalance, reason, err := svc.shouldRebalance(ctx, proj, pos)
+	if err != nil {
+		return fmt.Errorf("rebalance decision: %w", err)
 	}
-	logrus.Printf(
-		"position %s [%f] on %s/%s actualized: base amount: %d %s (%f %s), quote amount: %d %s (%f %s), base accrued fees: %d %s (%f %s), quote accrued fees: %d %s (%f %s): ",
+	logrus.Printf("position %s [%f] on %s/%s rebalance: %t, price %f [%f - %f]: %s",
 		pos.Pool().Pair(), pos.Pool().Fee(), pos.Pool().Network(), pos.Pool().Protocol(),
-		pos.CurrentBaseAmount().Value(), pos.CurrentBaseAmount().Token(), pos.CurrentBaseAmount().HumanValue(), pos.CurrentBaseAmount().Token(),
-		pos.CurrentQuoteAmount().Value(), pos.CurrentQuoteAmount().Token(), pos.CurrentQuoteAmount().HumanValue(), pos.CurrentQuoteAmount().Token(),
-		pos.CurrentBaseAccruedFees().Value(), pos.CurrentBaseAccruedFees().Token(), pos.CurrentBaseAccruedFees().HumanValue(), pos.CurrentBaseAccruedFees().Token(),
-		pos.CurrentQuoteAccruedFees().Value(), pos.CurrentQuoteAccruedFees().Token(), pos.CurrentQuoteAccruedFees().HumanValue(), pos.CurrentQuoteAccruedFees().Token(),
-	)
-
-	if pos.IsInRange() {
-		logrus.Printf("position %s [%f] on %s/%s is in-range: price %f [%f - %f]",
-			pos.Pool().Pair(), pos.Pool().Fee(), pos.Pool().Network(), pos.Pool().Protocol(),
-			pos.CurrentPrice(), pos.LowerPrice(), pos.UpperPrice())
+		rebalance, pos.CurrentPrice(), pos.LowerPrice(), pos.UpperPrice(), reason)
+	if !rebalance {
 		return nil
 	}
 
@@ -213,6 +242,63 @@ func (svc *projectExecutor) check(ctx context.Context, proj *project.Project, po
 	return nil
 }
 
+// shouldRebalance decides by the project rebalance policy whether the position is closed to be re-opened,
+// the reason of the decision is returned to be logged
+func (svc *projectExecutor) shouldRebalance(ctx context.Context, proj *project.Project, pos *position.Position) (bool, string, error) {
+	policy := proj.RebalancePolicy()
+
+	// цена внутри диапазона с учетом буфера за его границами
+	if pos.IsInRange() {
+		return false, "in range", nil
+	}
+	if !pos.IsOutOfRange(policy.Buffer) {
+		return false, fmt.Sprintf("within the %f buffer beyond the range", policy.Buffer), nil
+	}
+
+	// ждем, пока цена не продержится вне диапазона заданное время
+	outOfRange := time.Duration(0)
+	if since := pos.OutOfRangeSince(); since != nil {
+		outOfRange = time.Since(*since)
+	}
+	if outOfRange < policy.MinOutOfRange {
+		return false, fmt.Sprintf("out of range for %s of %s", outOfRange.Round(time.Second), policy.MinOutOfRange), nil
+	}
+
+	// не трогаем недавно открытую позицию
+	if age := time.Since(pos.CreatedAt()); age < policy.Cooldown {
+		return false, fmt.Sprintf("opened %s ago, cooldown %s", age.Round(time.Second), policy.Cooldown), nil
+	}
+
+	// ограничиваем число ребалансировок за сутки
+	if policy.MaxPerDay > 0 {
+		closed, err := svc.positionManager.GetClosedPositions(ctx, proj, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			return false, "", fmt.Errorf("position manager: closed positions: %w", err)
+		}
+		if len(closed) >= policy.MaxPerDay {
+			return false, fmt.Sprintf("%d of %d rebalances in the last 24h", len(closed), policy.MaxPerDay), nil
+		}
+	}
+
+	return true, fmt.Sprintf("out of range for %s", outOfRange.Round(time.Second)), nil
+}
+
+// actualize loads the current amounts and the accrued fees of the position
+func (svc *projectExecutor) actualize(ctx context.Context, pos *position.Position) error {
+	if err := svc.positionManager.Actualize(ctx, pos); err != nil {
+		return fmt.Errorf("actualize position: %w", err)
+	}
+	logrus.Printf(
+		"position %s [%f] on %s/%s actualized: base amount: %d %s (%f %s), quote amount: %d %s (%f %s), base accrued fees: %d %s (%f %s), quote accrued fees: %d %s (%f %s): ",
+		pos.Pool().Pair(), pos.Pool().Fee(), pos.Pool().Network(), pos.Pool().Protocol(),
+		pos.CurrentBaseAmount().Value(), pos.CurrentBaseAmount().Token(), pos.CurrentBaseAmount().HumanValue(), pos.CurrentBaseAmount().Token(),
+		pos.CurrentQuoteAmount().Value(), pos.CurrentQuoteAmount().Token(), pos.CurrentQuoteAmount().HumanValue(), pos.CurrentQuoteAmount().Token(),
+		pos.CurrentBaseAccruedFees().Value(), pos.CurrentBaseAccruedFees().Token(), pos.CurrentBaseAccruedFees().HumanValue(), pos.CurrentBaseAccruedFees().Token(),
+		pos.CurrentQuoteAccruedFees().Value(), pos.CurrentQuoteAccruedFees().Token(), pos.CurrentQuoteAccruedFees().HumanValue(), pos.CurrentQuoteAccruedFees().Token(),
+	)
+	return nil
+}
+
 // close закрывает позицию (уменьшение ликвидности позиции и сбор всех вознаграждений)
 func (svc *projectExecutor) close(ctx context.Context, pos *position.Position) error {
 	if err := svc.positionManager.Close(ctx, pos); err != nil {
@@ -236,80 +322,208 @@ func (svc *projectExecutor) close(ctx context.Context, pos *position.Position) e
 		return fmt.Errorf("add rewards: %w", err)
 	}
 
+	// выведенные из позиции активы и вознаграждения возвращаются в свободные средства проекта
+	received := append([]values.Amount{pos.OutputBaseAmount(), pos.OutputQuoteAmount()}, amounts...)
+	if err = svc.projectManager.MoveFunds(ctx, pos.Project(), received, nil); err != nil {
+		return fmt.Errorf("move funds out of position: %w", err)
+	}
+
 	logrus.Printf("rewards for position %s on %s/%s collected",
 		pos.Pool().Pair(), pos.Pool().Network(), pos.Pool().Protocol())
 
-	return svc.recalculateProjectWorth(ctx, pos)
+	return svc.recalculateProjectWorth(ctx, pos.Project())
 }
 
 // exchange swaps assets
-func (svc *projectExecutor) swap(ctx context.Context, proj *project.Project, data *SwapData) error {
+func (svc *projectExecutor) swap(ctx context.Context, proj *project.Project, data *SwapData) (*order.Order, error) {
 	logrus.Debugf("start of swapping tokens")
 
 	ord, err := svc.orderManager.New(ctx, &order.NewOrderInput{
 		Project:   proj,
+		Mode:      data.Mode,
 		AmountIn:  data.AmountIn,
 		AmountOut: data.AmountOut,
 		Price:     data.Price,
 	})
 	if err != nil {
-		return fmt.Errorf("new order: %w", err)
+		return nil, fmt.Errorf("new order: %w", err)
+	}
+	if err = svc.projectManager.MoveFunds(ctx, proj, []values.Amount{ord.AmountOut()}, []values.Amount{ord.AmountIn()}); err != nil {
+		return nil, fmt.Errorf("move swapped funds: %w", err)
 	}
 	log.Printf("order of swapping %d %s (%f %s) => %d %s (%f %s) by price %f created: %s",
 		ord.AmountIn().Value(), ord.AmountIn().Token(), ord.AmountIn().HumanValue(), ord.AmountIn().Token(),
 		ord.AmountOut().Value(), ord.AmountOut().Token(), ord.AmountOut().HumanValue(), ord.AmountOut().Token(),
 		ord.FilledPrice(), ord.Address())
 
-	return nil
+	return ord, nil
 }
 
-// canBeExecuted checks the project requirements and stop-loss
-func (svc *projectExecutor) canBeExecuted(ctx context.Context, proj *project.Project) (bool, error) {
-	if proj.IsInactive() {
-		return false, nil
-	}
-
+// canBeExecuted checks the project requirements, stop-loss and take-profit by the current project worth
+func (svc *projectExecutor) canBeExecuted(ctx context.Context, proj *project.Project, openPositions []*position.Position) (bool, error) {
 	// проверяем газ в сети
 	gas, err := svc.balance.Get(ctx, proj.Wallet(), proj.Wallet().NativeToken())
 	if err != nil {
 		return false, fmt.Errorf("get native balance: %w", err)
 	}
 	if gas.IsZero() {
-		if err = svc.projectManager.Deactivate(ctx, proj, project.NotEnoughGas); err != nil {
-			return false, fmt.Errorf("deactivate project: %w", err)
-		}
-		log.Printf("project deactivated: %s, reason: %s", proj.ID().String(), proj.InactiveReason())
+		// без газа не отправить ни одной транзакции: проект остается активным, а позиции открытыми,
+		// и проект выполняется снова на следующем запуске после пополнения кошелька
+		logrus.Printf("project %s skipped: no %s for gas on %s, positions left open: %d",
+			proj.ID(), gas.Token(), proj.Wallet().Address(), len(openPositions))
 		return false, nil
+	}
+
+	worth := proj.CurrentValue()
 
+	// нулевые правила отключены
+	if proj.StopLoss().Value() > 0 {
+		limit := proj.Investments().Sub(proj.Investments().Mul(proj.StopLoss()))
+		if worth.Cmp(limit) < 0 {
+			return false, svc.exitBy(ctx, proj, openPositions, project.StopLoss)
+		}
 	}
 
-	stopLoss := values.NewAmount(proj.Investments().Token(), proj.Investments().Mul(proj.StopLoss().Float()))
-	limit := proj.Investments().Sub(stopLoss)
+	if proj.TakeProfit().Value() > 0 {
+		takeProfit := proj.Investments().Add(proj.Investments().Mul(proj.TakeProfit()))
+		logrus.Printf("project %s worth: %d %s (%f %s), take-profit: %d %s (%f %s)", proj.ID(),
+			worth.Value(), worth.Token(), worth.HumanValue(), worth.Token(),
+			takeProfit.Value(), takeProfit.Token(), takeProfit.HumanValue(), takeProfit.Token())
 
-	if proj.CurrentValue().Value().Cmp(limit.Value()) < 0 {
-		if err = svc.projectManager.Deactivate(ctx, proj, project.StopLoss); err != nil {
-			return false, fmt.Errorf("deactivate project: %w", err)
+		if worth.Cmp(takeProfit) >= 0 {
+			return false, svc.exitBy(ctx, proj, openPositions, project.TakeProfit)
 		}
-		log.Printf("project deactivated: %s, reason: %s", proj.ID().String(), proj.InactiveReason())
-		return false, nil
+	}
+
+	// правила от максимальной стоимости проекта
+	peak := proj.PeakValue()
+
+	// трейлинг-стоп работает только когда проект в прибыли
+	if proj.TrailingStop().Value() > 0 && peak.Cmp(proj.Investments()) > 0 {
+		trailingStop := peak.Sub(peak.Mul(proj.TrailingStop()))
+		logrus.Printf("project %s peak worth: %f %s, trailing stop: %f %s", proj.ID(),
+			peak.HumanValue(), peak.Token(), trailingStop.HumanValue(), trailingStop.Token())
+
+		if worth.Cmp(trailingStop) < 0 {
+			return false, svc.exitBy(ctx, proj, openPositions, project.TrailingStop)
+		}
+	}
+
+	if proj.MaxDrawdown().Value() > 0 {
+		drawdownLimit := peak.Sub(peak.Mul(proj.MaxDrawdown()))
+		if worth.Cmp(drawdownLimit) < 0 {
+			return false, svc.exitBy(ctx, proj, openPositions, project.MaxDrawdown)
+		}
+	}
+
+	if proj.MaxHoldingDuration() > 0 && time.Since(proj.CreatedAt()) >= proj.MaxHoldingDuration() {
+		return false, svc.exitBy(ctx, proj, openPositions, project.MaxHoldingDuration)
 	}
 
 	return true, nil
 }
 
+// exitBy exits the project by the rule
+func (svc *projectExecutor) exitBy(ctx context.Context, proj *project.Project, openPositions []*position.Position, reason project.InactiveReason) error {
+	logrus.Printf("project %s exits by %s", proj.ID(), reason)
+	if err := svc.exit(ctx, proj, openPositions, reason); err != nil {
+		return fmt.Errorf("exit project: %w", err)
+	}
+	return nil
+}
+
+// exit closes the open positions collecting their fees, converts the assets they return into the investments token
+// when the project is configured so and deactivates the project with the worth it ends up with.
+// When a position cannot be closed the project stays active to retry on the next run, the retry closes
+// the positions left open and converts the assets of all the closed ones
+func (svc *projectExecutor) exit(ctx context.Context, proj *project.Project, openPositions []*position.Position, reason project.InactiveReason) error {
+	var errs []error
+	closed := make([]*position.Position, 0, len(openPositions))
+	for _, pos := range openPositions {
+		if err := svc.close(ctx, pos); err != nil {
+			errs = append(errs, fmt.Errorf("close position %s: %w", pos.Address(), err))
+			continue
+		}
+		closed = append(closed, pos)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if proj.ConvertOnExit() {
+		if err := svc.convertToInvestments(ctx, proj); err != nil {
+			// позиции уже закрыты, активы остаются на кошельке
+			errs = append(errs, fmt.Errorf("convert to investments token: %w", err))
+		}
+	}
+
+	if err := svc.projectManager.Deactivate(ctx, proj, reason); err != nil {
+		return fmt.Errorf("deactivate project: %w", err)
+	}
+	log.Printf("project deactivated: %s, reason: %s, positions closed: %d of %d, worth: %d %s (%f %s)",
+		proj.ID().String(), proj.InactiveReason(), len(closed), len(openPositions),
+		proj.CurrentValue().Value(), proj.CurrentValue().Token(), proj.CurrentValue().HumanValue(), proj.CurrentValue().Token())
+
+	return errors.Join(errs...)
+}
+
+// convertToInvestments swaps the idle funds of the project in the other pool token into the investments token,
+// at most the wallet balance of the token is swapped
+func (svc *projectExecutor) convertToInvestments(ctx context.Context, proj *project.Project) error {
+	investToken := proj.Investments().Token()
+	pair := proj.Pool().Pair()
+
+	other, amount := pair.BaseToken(), proj.IdleBase()
+	if other.Eq(investToken) {
+		other, amount = pair.QuoteToken(), proj.IdleQuote()
+	} else if !pair.QuoteToken().Eq(investToken) {
+		return fmt.Errorf("invalid investment token")
+	}
+
+	bal, err := svc.balance.Get(ctx, proj.Wallet(), other)
+	if err != nil {
+		return fmt.Errorf("get %s balance: %w", other, err)
+	}
+	amount = minAmount(amount, bal)
+	if amount.IsZero() {
+		return nil
+	}
+
+	// получаем актуальную цену в пуле
+	p, err := svc.poolManager.Get(ctx, proj.Pool().Network(), proj.Pool().Protocol(), pair, proj.Pool().Fee())
+	if err != nil {
+		return err
+	}
+	expected := p.LastPrice().Convert(amount)
+	minAmountOut := expected.Sub(expected.MulRound(proj.Slippage(), values.RoundUp))
+
+	if _, err = svc.swap(ctx, proj, &SwapData{
+		Mode:      ports.ExactInput,
+		Price:     p.LastPrice(),
+		AmountIn:  amount,
+		AmountOut: minAmountOut,
+	}); err != nil {
+		return err
+	}
+
+	// учитываем фактический результат свопа в стоимости проекта
+	return svc.recalculateProjectWorth(ctx, proj)
+}
+
 type SwapData struct {
+	Mode      ports.SwapMode
 	AmountIn  values.Amount
 	AmountOut values.Amount
-	Price     *big.Float
+	Price     values.Price
 }
 
 // canBeOpened checks whether there are enough current assets to open a position (taking into account swap if necessary)
-func (svc *projectExecutor) canBeOpened(price *big.Float, baseAmount, quoteAmount, baseBalance, quoteBalance values.Amount, slippage values.Percent) (bool, *SwapData) {
+func (svc *projectExecutor) canBeOpened(price values.Price, baseAmount, quoteAmount, baseBalance, quoteBalance values.Amount, slippage values.Percent) (bool, *SwapData) {
 	if baseBalance.Value().Cmp(baseAmount.Value()) == -1 && quoteBalance.Value().Cmp(quoteAmount.Value()) == -1 {
 		// обоих активов на балансе меньше чем нужно для открытия позиции
 		return false, nil
-	} else if baseBalance.Value().Cmp(baseAmount.Value()) == 1 && quoteBalance.Value().Cmp(quoteAmount.Value()) == 1 {
-		// обоих активов на балансе достаточно для открытия позиции
+	} else if baseBalance.Value().Cmp(baseAmount.Value()) >= 0 && quoteBalance.Value().Cmp(quoteAmount.Value()) >= 0 {
+		// обоих активов на балансе достаточно для открытия позиции, в том числе ровно столько, сколько нужно
 		return true, nil
 
 	}
@@ -318,8 +532,8 @@ func (svc *projectExecutor) canBeOpened(price *big.Float, baseAmount, quoteAmoun
 
 	if baseBalance.Value().Cmp(baseAmount.Value()) == -1 { // меньше базового актива
 		delta := baseAmount.Sub(baseBalance)
-		deltaCost := values.NewAmount(quoteAmount.Token(), delta.Mul(price))
-		deltaCostSlippage := deltaCost.Mul(slippage)
+		deltaCost := price.ConvertRound(delta, values.RoundUp)
+		deltaCostSlippage := deltaCost.MulRound(slippage, values.RoundUp)
 		deltaCostWithSlippage := deltaCost.Add(deltaCostSlippage)
 
 		// корректирующего актива должно хватить на своп недостающего базового актива + слиппадж
@@ -329,14 +543,15 @@ func (svc *projectExecutor) canBeOpened(price *big.Float, baseAmount, quoteAmoun
 		}
 
 		swapData = &SwapData{
+			Mode:      ports.ExactOutput,
 			Price:     price,
 			AmountIn:  deltaCostWithSlippage,
 			AmountOut: delta,
 		}
 	} else { // меньше корректирующего актива
 		delta := quoteAmount.Sub(quoteBalance)
-		deltaCost := values.NewAmount(baseAmount.Token(), delta.Div(price))
-		deltaCostSlippage := deltaCost.Mul(slippage)
+		deltaCost := price.ConvertRound(delta, values.RoundUp)
+		deltaCostSlippage := deltaCost.MulRound(slippage, values.RoundUp)
 		deltaCostWithSlippage := deltaCost.Add(deltaCostSlippage)
 
 		// базового актива должно хватить на своп недостающего корректирующего актива + слиппадж
@@ -346,6 +561,7 @@ func (svc *projectExecutor) canBeOpened(price *big.Float, baseAmount, quoteAmoun
 		}
 
 		swapData = &SwapData{
+			Mode:      ports.ExactOutput,
 			Price:     price,
 			AmountIn:  deltaCostWithSlippage,
 			AmountOut: delta,
@@ -355,60 +571,67 @@ func (svc *projectExecutor) canBeOpened(price *big.Float, baseAmount, quoteAmoun
 	return true, swapData
 }
 
-func (svc *projectExecutor) recalculateProjectWorth(ctx context.Context, pos *position.Position) error {
-	// стоимость активов которые вывели из пула по текущей цене
-	// + стоимость заработанных комиссий
-
-	proj := pos.Project()
-
-	worth := values.NewAmount(proj.Investments().Token(), 0)
-
-	currentPrice := pos.CurrentPrice()
-	baseAmount := pos.OutputBaseAmount()
-	quoteAmount := pos.OutputQuoteAmount()
-
-	// получаем стоимость активов пула
-	if worth.Token().Eq(baseAmount.Token()) {
-		worth = worth.Add(baseAmount)
-		worth = worth.Add(values.NewAmount(worth.Token(), quoteAmount.Div(currentPrice)))
-	} else {
-		worth = worth.Add(quoteAmount)
-		worth = worth.Add(values.NewAmount(worth.Token(), baseAmount.Mul(currentPrice)))
+// recalculateProjectWorth marks the project to market after its positions or balances changed
+func (svc *projectExecutor) recalculateProjectWorth(ctx context.Context, proj *project.Project) error {
+	openPositions, err := svc.positionManager.GetOpenPositions(ctx, proj)
+	if err != nil {
+		return fmt.Errorf("position manager: open positions: %w", err)
 	}
-	logrus.Printf("position assets cost: %d %s (%f %s)",
-		worth.Value(), worth.Token(), worth.HumanValue(), worth.Token())
+	return svc.updateWorth(ctx, proj, openPositions)
+}
 
-	// получаем стоимость заработанных комиссий
-	rewards, err := svc.rewardManger.GetPositionRewards(ctx, pos)
+// updateWorth marks the project to market and saves the worth: the current amounts and the accrued fees
+// of the actualized open positions and the idle funds of the project converted at the pool price
+func (svc *projectExecutor) updateWorth(ctx context.Context, proj *project.Project, openPositions []*position.Position) error {
+	// получаем актуальную цену в пуле
+	p, err := svc.poolManager.Get(ctx, proj.Pool().Network(), proj.Pool().Protocol(), proj.Pool().Pair(), proj.Pool().Fee())
 	if err != nil {
-		return fmt.Errorf("get reward for position: %w", err)
+		return err
+	}
+	pair := p.Pair()
+	investToken := proj.Investments().Token()
+	if !investToken.Eq(pair.BaseToken()) && !investToken.Eq(pair.QuoteToken()) {
+		return fmt.Errorf("invalid investment token")
 	}
 
-	// invest  => ETH
-	// reward  => USDC ()
+	// учитываются только свободные средства проекта, а не весь баланс кошелька
+	idle := worthIn(investToken, p.LastPrice(), proj.IdleBase(), proj.IdleQuote())
 
-	rewardsWorth := values.NewAmount(worth.Token(), 0)
-	for _, r := range rewards {
-		if worth.Token().Eq(baseAmount.Token()) && r.Amount().Token().Eq(quoteAmount.Token()) {
-			cost := values.NewAmount(worth.Token(), r.Amount().Div(currentPrice))
-			rewardsWorth = rewardsWorth.Add(cost)
-		} else if worth.Token().Eq(quoteAmount.Token()) && r.Amount().Token().Eq(baseAmount.Token()) {
-			cost := values.NewAmount(worth.Token(), r.Amount().Mul(currentPrice))
-			rewardsWorth = rewardsWorth.Add(cost)
-		} else {
-			rewardsWorth = rewardsWorth.Add(r.Amount())
-		}
+	amounts := make([]values.Amount, 0, 4*len(openPositions))
+	for _, pos := range openPositions {
+		amounts = append(amounts, pos.CurrentBaseAmount(), pos.CurrentQuoteAmount(),
+			pos.CurrentBaseAccruedFees(), pos.CurrentQuoteAccruedFees())
 	}
-	logrus.Printf("position rewards cost: %d %s (%f %s)",
-		rewardsWorth.Value(), rewardsWorth.Token(), rewardsWorth.HumanValue(), rewardsWorth.Token())
+	positions := worthIn(investToken, p.LastPrice(), amounts...)
 
-	worth = worth.Add(rewardsWorth)
-	logrus.Printf("total cost: %d %s (%f %s)",
-		worth.Value(), worth.Token(), worth.HumanValue(), worth.Token())
+	worth := idle.Add(positions)
+	logrus.Printf("project %s worth: %d %s (%f %s), positions: %f %s, idle: %f %s", proj.ID(),
+		worth.Value(), worth.Token(), worth.HumanValue(), worth.Token(),
+		positions.HumanValue(), positions.Token(), idle.HumanValue(), idle.Token())
 
 	if err = svc.projectManager.UpdateWorth(ctx, proj, worth); err != nil {
 		return fmt.Errorf("update project worth: %w", err)
 	}
-
 	return nil
 }
+
+// worthIn sums the amounts converted into the token by the price
+func worthIn(t *token.Token, price values.Price, amounts ...values.Amount) values.Amount {
+	worth := values.NewAmount(t, 0)
+	for _, a := range amounts {
+		if a.Token().Eq(t) {
+			worth = worth.Add(a)
+		} else {
+			worth = worth.Add(price.Convert(a))
+		}
+	}
+	return worth
+}
+
+// minAmount is the lesser of the amounts of the same token
+func minAmount(a, b values.Amount) values.Amount {
+	if a.Cmp(b) > 0 {
+		return b
+	}
+	return a
+}
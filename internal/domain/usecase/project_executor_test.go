@@ -0,0 +1,543 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/r1der/epos/internal/domain/entity/order"
+	"github.com/r1der/epos/internal/domain/entity/pool"
+	"github.com/r1der/epos/internal/domain/entity/position"
+	"github.com/r1der/epos/internal/domain/entity/project"
+	"github.com/r1der/epos/internal/domain/entity/reward"
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/internal/domain/entity/wallet"
+	"github.com/r1der/epos/internal/domain/ports"
+	"github.com/r1der/epos/internal/domain/values"
+)
+
+var (
+	eth  = token.New("eth", "0x0000", "ETH", 18)
+	weth = token.New("eth", "0xc02a", "WETH", 18)
+	usdc = token.New("eth", "0xa0b8", "USDC", 6)
+	pair = token.NewPair(weth, usdc)
+
+	// the range of the opened positions around the pool price of 2000 USDC per WETH
+	priceRange = &pool.Range{
+		InitialPrice: values.NewPrice(pair, 2000),
+		LowerPrice:   values.NewPrice(pair, 1900),
+		UpperPrice:   values.NewPrice(pair, 2100),
+		LowerTick:    -100,
+		UpperTick:    100,
+	}
+)
+
+// calls logs the calls of the managers and the ports in their order
+type calls []string
+
+func (c *calls) add(format string, args ...any) { *c = append(*c, fmt.Sprintf(format, args...)) }
+
+func (c *calls) index(call string) int { return slices.Index(*c, call) }
+
+// projectRepo keeps the saved project
+type projectRepo struct {
+	saved *project.Project
+}
+
+func (r *projectRepo) FindOne(context.Context, project.Filter) (*project.Project, error) {
+	if r.saved == nil {
+		return nil, project.ErrNotFound
+	}
+	return r.saved, nil
+}
+
+func (r *projectRepo) Find(context.Context, project.Filter) ([]*project.Project, error) {
+	return nil, nil
+}
+
+func (r *projectRepo) Save(_ context.Context, p *project.Project) error {
+	p.SetVersion(p.Version() + 1)
+	r.saved = p
+	return nil
+}
+
+// positionRepo keeps the saved positions in the order they are saved first
+type positionRepo struct {
+	saved []*position.Position
+}
+
+func (r *positionRepo) FindOne(_ context.Context, f position.Filter) (*position.Position, error) {
+	for _, p := range r.saved {
+		if slices.Contains(f.Addresses, p.Address()) {
+			return p, nil
+		}
+	}
+	return nil, position.ErrNotFound
+}
+
+func (r *positionRepo) Find(_ context.Context, f position.Filter) ([]*position.Position, error) {
+	var found []*position.Position
+	for _, p := range r.saved {
+		if slices.Contains(f.Statuses, p.Status()) {
+			found = append(found, p)
+		}
+	}
+	return found, nil
+}
+
+func (r *positionRepo) Save(_ context.Context, p *position.Position) error {
+	p.SetVersion(p.Version() + 1)
+	if !slices.Contains(r.saved, p) {
+		r.saved = append(r.saved, p)
+	}
+	return nil
+}
+
+// liquidityManager opens the positions at the requested amounts, the positions are priced
+// by the prices set for their addresses and at the initial range price otherwise,
+// the decrease of the position fails once with the error set for its address
+type liquidityManager struct {
+	log    *calls
+	opened int
+	prices map[string]values.Price
+	fail   map[string]error
+}
+
+func (lm *liquidityManager) IncreaseLiquidity(_ context.Context, in *ports.IncreaseLiquidityInput) (*ports.IncreaseLiquidityOutput, error) {
+	lm.opened++
+	return &ports.IncreaseLiquidityOutput{
+		Address: fmt.Sprintf("new %d", lm.opened), Liquidity: big.NewInt(1000),
+		BaseAmount: in.BaseAmount, QuoteAmount: in.QuoteAmount, TransactionFee: big.NewInt(1),
+	}, nil
+}
+
+func (lm *liquidityManager) DecreaseLiquidity(_ context.Context, in *ports.DecreaseLiquidityInput) (*ports.DecreaseLiquidityOutput, error) {
+	lm.log.add("decrease %s", in.PositionAddress)
+	if err := lm.fail[in.PositionAddress]; err != nil {
+		delete(lm.fail, in.PositionAddress)
+		return nil, err
+	}
+	return &ports.DecreaseLiquidityOutput{
+		Address: in.PositionAddress, Liquidity: in.Liquidity, BaseAmount: in.BaseMinAmount, QuoteAmount: in.QuoteMinAmount,
+		TransactionFee: big.NewInt(1),
+	}, nil
+}
+
+func (lm *liquidityManager) GetPosition(_ context.Context, in *ports.GetPositionInput) (*ports.GetPositionOutput, error) {
+	price, ok := lm.prices[in.PositionAddress]
+	if !ok {
+		price = priceRange.InitialPrice
+	}
+	return &ports.GetPositionOutput{
+		CurrentPrice: price, Liquidity: big.NewInt(1000),
+		BaseAmount: values.NewAmount(weth, 250_000_000_000_000_000), QuoteAmount: values.NewAmount(usdc, 500_000_000),
+		BaseAccruedFees: values.NewAmount(weth, 0), QuoteAccruedFees: values.NewAmount(usdc, 0),
+	}, nil
+}
+
+func (lm *liquidityManager) CollectFees(_ context.Context, in *ports.CollectFeesInput) (*ports.CollectFeesOutput, error) {
+	return &ports.CollectFeesOutput{
+		Address: in.PositionAddress, BaseAmount: values.NewAmount(weth, 0), QuoteAmount: values.NewAmount(usdc, 0),
+		TransactionFee: big.NewInt(1),
+	}, nil
+}
+
+// positionManager records the calls of the position manager it wraps
+type positionManager struct {
+	position.Manager
+	log    *calls
+	opened []*position.OpenPositionInput
+}
+
+func (m *positionManager) Open(ctx context.Context, in *position.OpenPositionInput) (*position.Position, error) {
+	m.opened = append(m.opened, in)
+	pos, err := m.Manager.Open(ctx, in)
+	if err == nil {
+		m.log.add("open %s", pos.Address())
+	}
+	return pos, err
+}
+
+func (m *positionManager) Close(ctx context.Context, pos *position.Position) error {
+	m.log.add("close %s", pos.Address())
+	return m.Manager.Close(ctx, pos)
+}
+
+// projectManager records the calls of the project manager it wraps
+type projectManager struct {
+	project.Manager
+	log *calls
+}
+
+func (m *projectManager) Deactivate(ctx context.Context, proj *project.Project, reason project.InactiveReason) error {
+	m.log.add("deactivate %s", reason)
+	return m.Manager.Deactivate(ctx, proj, reason)
+}
+
+func (m *projectManager) UpdateWorth(ctx context.Context, proj *project.Project, worth values.Amount) error {
+	m.log.add("update worth %s", worth.Value())
+	return m.Manager.UpdateWorth(ctx, proj, worth)
+}
+
+// poolManager prices the pool at the range initial price and opens the positions at the requested amounts
+type poolManager struct {
+	pool *pool.Pool
+}
+
+func (m *poolManager) Get(context.Context, string, string, *token.Pair, values.Percent) (*pool.Pool, error) {
+	return m.pool, nil
+}
+
+func (m *poolManager) CalculatePositionRange(context.Context, *pool.Pool, pool.RangeStrategy) (*pool.Range, error) {
+	return priceRange, nil
+}
+
+func (m *poolManager) CalculatePositionAmounts(_ context.Context, _ *pool.Range, baseAmount, quoteAmount values.Amount) (*pool.Amounts, error) {
+	return &pool.Amounts{Liquidity: big.NewInt(1000), BaseAmount: baseAmount, QuoteAmount: quoteAmount}, nil
+}
+
+// orderManager fills the orders at their amounts
+type orderManager struct {
+	orders []*order.NewOrderInput
+}
+
+func (m *orderManager) New(_ context.Context, in *order.NewOrderInput) (*order.Order, error) {
+	m.orders = append(m.orders, in)
+	return order.Restore(order.Snapshot{
+		Project: in.Project, Pool: in.Project.Pool(), Address: fmt.Sprintf("order %d", len(m.orders)),
+		AmountIn: in.AmountIn, AmountOut: in.AmountOut, FilledPrice: in.Price,
+		TransactionFee: values.NewAmount(eth, 1), CreatedAt: time.Now(),
+	}), nil
+}
+
+type rewardManager struct{}
+
+func (rewardManager) Add(context.Context, *position.Position, ...values.Amount) ([]*reward.Reward, error) {
+	return nil, nil
+}
+
+func (rewardManager) GetPositionRewards(context.Context, *position.Position) ([]*reward.Reward, error) {
+	return nil, nil
+}
+
+// balance holds the wallet balances by the tokens, the native token balance is logged as the gas check
+type balance struct {
+	log     *calls
+	amounts map[*token.Token]int64
+}
+
+func (b *balance) Get(_ context.Context, _ *wallet.Wallet, t *token.Token) (values.Amount, error) {
+	if t == eth {
+		b.log.add("gas")
+	}
+	return values.NewAmount(t, b.amounts[t]), nil
+}
+
+// executor is the project executor over the recording managers
+type executor struct {
+	ProjectExecutor
+	log       *calls
+	lm        *liquidityManager
+	positions *positionManager
+	orders    *orderManager
+	repo      *positionRepo
+	bal       *balance
+	proj      *project.Project
+}
+
+// newExecutor creates the executor of the active WETH/USDC project investing 3000 USDC into the positions,
+// the project holds 1 WETH and 3000 USDC idle, the wallet holds more of both tokens
+func newExecutor(t *testing.T, activePositions int) *executor {
+	t.Helper()
+	log := &calls{}
+	p := pool.Restore(pool.Snapshot{
+		Network: "eth", Protocol: "uniswap", Address: "0xp", Fee: pool.LowFee, Pair: pair,
+		LastPrice: priceRange.InitialPrice,
+	})
+	w := wallet.Restore(wallet.Snapshot{Name: "main", Network: "eth", Address: "0xw", KeyRef: "keystore:0xw", NativeToken: eth})
+	proj := project.Restore(project.Snapshot{
+		ID: uuid.New(), Wallet: w, Pool: p, Name: "eth/usdc",
+		Investments:     values.NewAmount(usdc, 3_000_000_000),
+		CurrentValue:    values.NewAmount(usdc, 3_000_000_000),
+		PeakValue:       values.NewAmount(usdc, 3_000_000_000),
+		IdleBase:        values.NewAmount(weth, 1_000_000_000_000_000_000),
+		IdleQuote:       values.NewAmount(usdc, 3_000_000_000),
+		RangeVolatility: 0.05,
+		Slippage:        values.NewPercent(0.01),
+		ActivePositions: activePositions,
+		Status:          project.Active,
+		CreatedAt:       time.Now(),
+		Version:         1,
+	})
+
+	e := &executor{
+		log:    log,
+		lm:     &liquidityManager{log: log, prices: map[string]values.Price{}, fail: map[string]error{}},
+		orders: &orderManager{},
+		repo:   &positionRepo{},
+		bal: &balance{log: log, amounts: map[*token.Token]int64{
+			eth: 1_000_000_000_000_000_000, weth: 5_000_000_000_000_000_000, usdc: 10_000_000_000,
+		}},
+		proj: proj,
+	}
+	e.positions = &positionManager{Manager: position.NewManager(e.repo, e.lm), log: log}
+	e.ProjectExecutor = NewProjectExecutor(
+		&poolManager{pool: p}, e.positions,
+		&projectManager{Manager: project.NewManager(&projectRepo{saved: proj}, e.bal), log: log},
+		e.orders, rewardManager{}, e.bal,
+	)
+	return e
+}
+
+// configure rebuilds the project with the changed settings, the positions are loaded by the rebuilt project
+func (e *executor) configure(change func(*project.Snapshot)) {
+	s := e.proj.Snapshot()
+	change(&s)
+	e.proj = project.Restore(s)
+}
+
+// exitOnStopLoss makes the project hold nothing but its positions, so the stop-loss of 10% is triggered
+// by the positions worth 1000 USDC each while the project holds less than three of them
+func (e *executor) exitOnStopLoss() {
+	e.configure(func(s *project.Snapshot) {
+		s.StopLoss = values.NewPercent(0.1)
+		s.IdleBase, s.IdleQuote = values.NewAmount(weth, 0), values.NewAmount(usdc, 0)
+	})
+}
+
+// open saves the open position of the project entered at 0.25 WETH and 500 USDC in the range
+func (e *executor) open(t *testing.T, address string) {
+	t.Helper()
+	pos := position.Restore(position.Snapshot{
+		Project: e.proj, Pool: e.proj.Pool(), Address: address,
+		LowerPrice: priceRange.LowerPrice, UpperPrice: priceRange.UpperPrice,
+		LowerTick: priceRange.LowerTick, UpperTick: priceRange.UpperTick,
+		InitialPrice: priceRange.InitialPrice, Liquidity: big.NewInt(1000),
+		InBaseAmount:  values.NewAmount(weth, 250_000_000_000_000_000),
+		InQuoteAmount: values.NewAmount(usdc, 500_000_000),
+		Status:        position.Open, TransactionFee: values.NewAmount(eth, 1),
+		CreatedAt:    time.Now().Add(-time.Hour),
+		CurrentPrice: priceRange.InitialPrice,
+	})
+	if err := e.repo.Save(context.Background(), pos); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// openAddresses lists the addresses of the open positions in the order they were saved
+func (e *executor) openAddresses() []string {
+	var addresses []string
+	for _, p := range e.repo.saved {
+		if p.IsOpen() {
+			addresses = append(addresses, p.Address())
+		}
+	}
+	return addresses
+}
+
+func TestExecuteOpensFreeSlots(t *testing.T) {
+	e := newExecutor(t, 3)
+	e.open(t, "old")
+
+	if err := e.Execute(context.Background(), e.proj); err != nil {
+		t.Fatal(err)
+	}
+
+	// the free slots are filled up to the active positions, the in-range position is kept
+	if got := e.openAddresses(); !slices.Equal(got, []string{"old", "new 1", "new 2"}) {
+		t.Fatalf("open positions %v", got)
+	}
+	// the investments are split across the active positions, a half of the share into each token
+	for i, in := range e.positions.opened {
+		if in.BaseAmount.Value().Int64() != 250_000_000_000_000_000 || !in.BaseAmount.Token().Eq(weth) ||
+			in.QuoteAmount.Value().Int64() != 500_000_000 || !in.QuoteAmount.Token().Eq(usdc) {
+			t.Errorf("position %d opened with %s and %s", i, in.BaseAmount, in.QuoteAmount)
+		}
+	}
+	// the idle funds are enough, nothing is swapped and the funds move into the positions
+	if len(e.orders.orders) != 0 {
+		t.Errorf("swapped %d times", len(e.orders.orders))
+	}
+	if e.proj.IdleBase().Value().Int64() != 500_000_000_000_000_000 || e.proj.IdleQuote().Value().Int64() != 2_000_000_000 {
+		t.Errorf("idle funds %s and %s", e.proj.IdleBase(), e.proj.IdleQuote())
+	}
+	if !e.proj.IsActive() || slices.ContainsFunc(*e.log, func(c string) bool { return strings.HasPrefix(c, "close") }) {
+		t.Errorf("calls %v", *e.log)
+	}
+}
+
+func TestExecuteRebalancesOutOfRange(t *testing.T) {
+	e := newExecutor(t, 2)
+	e.open(t, "in range")
+	e.open(t, "out of range")
+	e.lm.prices["out of range"] = values.NewPrice(pair, 2500)
+
+	if err := e.Execute(context.Background(), e.proj); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the out-of-range position is closed and a new one takes its slot
+	if got := e.openAddresses(); !slices.Equal(got, []string{"in range", "new 1"}) {
+		t.Fatalf("open positions %v", got)
+	}
+	closeAt, openAt := e.log.index("close out of range"), e.log.index("open new 1")
+	if closeAt < 0 || openAt < closeAt || e.log.index("close in range") >= 0 || e.log.index("decrease in range") >= 0 {
+		t.Fatalf("calls %v", *e.log)
+	}
+	if len(e.positions.opened) != 1 || !e.proj.IsActive() {
+		t.Fatalf("opened %d positions, project %s", len(e.positions.opened), e.proj.Status())
+	}
+}
+
+func TestExecuteUpdatesWorthBeforeChecks(t *testing.T) {
+	e := newExecutor(t, 1)
+	e.open(t, "old")
+	// the stored worth is healthy, so the stop-loss is triggered by the worth marked to market on this run only
+	e.exitOnStopLoss()
+
+	if err := e.Execute(context.Background(), e.proj); err != nil {
+		t.Fatal(err)
+	}
+
+	worthAt, gasAt := e.log.index("update worth 1000000000"), e.log.index("gas")
+	if worthAt < 0 || gasAt < worthAt {
+		t.Fatalf("calls %v", *e.log)
+	}
+	if e.proj.IsActive() || e.proj.InactiveReason() != project.StopLoss {
+		t.Fatalf("project %s by %q", e.proj.Status(), e.proj.InactiveReason())
+	}
+	if len(e.openAddresses()) != 0 || len(e.positions.opened) != 0 {
+		t.Fatalf("open positions %v, opened %d", e.openAddresses(), len(e.positions.opened))
+	}
+}
+
+func TestExecuteWithoutGas(t *testing.T) {
+	ctx := context.Background()
+	e := newExecutor(t, 2)
+	e.open(t, "in range")
+	e.open(t, "out of range")
+	e.lm.prices["out of range"] = values.NewPrice(pair, 2500)
+	e.exitOnStopLoss()
+	e.bal.amounts[eth] = 0
+
+	// no transaction is sent, the project stays active with its positions open to be retried
+	if err := e.Execute(ctx, e.proj); err != nil {
+		t.Fatal(err)
+	}
+	if !e.proj.IsActive() || !slices.Equal(e.openAddresses(), []string{"in range", "out of range"}) {
+		t.Fatalf("project %s with open positions %v", e.proj.Status(), e.openAddresses())
+	}
+	for _, c := range *e.log {
+		if !strings.HasPrefix(c, "update worth") && c != "gas" {
+			t.Fatalf("calls %v", *e.log)
+		}
+	}
+
+	// the retry after the wallet is refilled exits the project closing all the positions
+	e.bal.amounts[eth] = 1_000_000_000_000_000_000
+	if err := e.Execute(ctx, e.proj); err != nil {
+		t.Fatal(err)
+	}
+	if e.proj.IsActive() || e.proj.InactiveReason() != project.StopLoss || len(e.openAddresses()) != 0 {
+		t.Fatalf("project %s by %q with open positions %v", e.proj.Status(), e.proj.InactiveReason(), e.openAddresses())
+	}
+}
+
+func TestExecuteExitRetry(t *testing.T) {
+	ctx := context.Background()
+	e := newExecutor(t, 2)
+	e.open(t, "a")
+	e.open(t, "b")
+	e.exitOnStopLoss()
+	e.configure(func(s *project.Snapshot) { s.ConvertOnExit = true })
+	e.lm.fail["b"] = errors.New("execution reverted")
+
+	// the position failed to close keeps the project active, nothing is converted before all the positions are closed
+	if err := e.Execute(ctx, e.proj); err == nil || !strings.Contains(err.Error(), "execution reverted") {
+		t.Fatalf("expected the close error, got %v", err)
+	}
+	if !e.proj.IsActive() || !slices.Equal(e.openAddresses(), []string{"b"}) || len(e.orders.orders) != 0 {
+		t.Fatalf("project %s with open positions %v, swapped %d times",
+			e.proj.Status(), e.openAddresses(), len(e.orders.orders))
+	}
+
+	// the retry closes the position left open and converts the WETH of both positions, 0.2475 WETH each
+	// after the slippage, into the investments token
+	if err := e.Execute(ctx, e.proj); err != nil {
+		t.Fatal(err)
+	}
+	if e.proj.IsActive() || e.proj.InactiveReason() != project.StopLoss || len(e.openAddresses()) != 0 {
+		t.Fatalf("project %s by %q with open positions %v", e.proj.Status(), e.proj.InactiveReason(), e.openAddresses())
+	}
+	if len(e.orders.orders) != 1 {
+		t.Fatalf("swapped %d times", len(e.orders.orders))
+	}
+	if in := e.orders.orders[0]; in.Mode != ports.ExactInput || in.AmountIn.Cmp(values.NewAmount(weth, 495_000_000_000_000_000)) != 0 {
+		t.Fatalf("swapped %s in %s mode", in.AmountIn, in.Mode)
+	}
+	if !e.proj.IdleBase().IsZero() {
+		t.Fatalf("idle %s left", e.proj.IdleBase())
+	}
+	// the position closed on the first run is not closed again
+	closes := 0
+	for _, c := range *e.log {
+		if c == "decrease a" {
+			closes++
+		}
+	}
+	if closes != 1 {
+		t.Fatalf("calls %v", *e.log)
+	}
+}
+
+func TestCanBeOpened(t *testing.T) {
+	svc := &projectExecutor{}
+	price := values.NewPrice(pair, 2000)
+	slippage := values.NewPercent(0.01)
+	base, quote := values.NewAmount(weth, 1_000_000_000_000_000_000), values.NewAmount(usdc, 2_000_000_000)
+
+	tests := []struct {
+		name                      string
+		baseBalance, quoteBalance int64
+		can                       bool
+		swap                      *SwapData
+	}{
+		{"both enough", 2_000_000_000_000_000_000, 3_000_000_000, true, nil},
+		{"exactly enough", 1_000_000_000_000_000_000, 2_000_000_000, true, nil},
+		{"exactly enough base", 1_000_000_000_000_000_000, 3_000_000_000, true, nil},
+		{"exactly enough quote", 2_000_000_000_000_000_000, 2_000_000_000, true, nil},
+		{"both short", 500_000_000_000_000_000, 1_000_000_000, false, nil},
+		// 0.5 WETH costs 1000 USDC and 10 USDC of the slippage
+		{"base short", 500_000_000_000_000_000, 3_010_000_000, true, &SwapData{
+			Mode: ports.ExactOutput, Price: price,
+			AmountIn: values.NewAmount(usdc, 1_010_000_000), AmountOut: values.NewAmount(weth, 500_000_000_000_000_000),
+		}},
+		{"base short by the slippage", 500_000_000_000_000_000, 3_009_999_999, false, nil},
+		// 1000 USDC costs 0.5 WETH and 0.005 WETH of the slippage
+		{"quote short", 1_505_000_000_000_000_000, 1_000_000_000, true, &SwapData{
+			Mode: ports.ExactOutput, Price: price,
+			AmountIn: values.NewAmount(weth, 505_000_000_000_000_000), AmountOut: values.NewAmount(usdc, 1_000_000_000),
+		}},
+		{"quote short by the slippage", 1_504_999_999_999_999_999, 1_000_000_000, false, nil},
+	}
+	for _, tt := range tests {
+		can, swap := svc.canBeOpened(price, base, quote,
+			values.NewAmount(weth, tt.baseBalance), values.NewAmount(usdc, tt.quoteBalance), slippage)
+		if can != tt.can || (swap == nil) != (tt.swap == nil) {
+			t.Errorf("%s: %t with %+v, want %t with %+v", tt.name, can, swap, tt.can, tt.swap)
+			continue
+		}
+		if swap != nil && (swap.Mode != tt.swap.Mode || swap.Price.Cmp(tt.swap.Price) != 0 ||
+			swap.AmountIn.Cmp(tt.swap.AmountIn) != 0 || swap.AmountOut.Cmp(tt.swap.AmountOut) != 0) {
+			t.Errorf("%s: swap %s for %s, want %s for %s", tt.name,
+				swap.AmountIn, swap.AmountOut, tt.swap.AmountIn, tt.swap.AmountOut)
+		}
+	}
+}
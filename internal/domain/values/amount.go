@@ -1,101 +1,284 @@
 package values
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/r1der/epos/internal/domain/entity/token"
 )
 
+var (
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrAmountPrecision = errors.New("amount exceeds the token precision")
+	ErrUnknownToken    = errors.New("unknown token")
+)
+
+var decimalNumber = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?$`)
+
+// Rounding selects how a result that does not fit the token precision is rounded to the base units
+type Rounding int
+
+const (
+	// RoundDown rounds towards zero
+	RoundDown Rounding = iota
+	// RoundUp rounds away from zero
+	RoundUp
+	// RoundHalfUp rounds to the nearest base unit, ties away from zero
+	RoundHalfUp
+	// RoundHalfEven rounds to the nearest base unit, ties to the even one
+	RoundHalfEven
+)
+
+// Amount is an exact amount of the token kept in the token base units
 type Amount struct {
 	token *token.Token
 	value *big.Int
 }
 
-func (a Amount) Value() *big.Int     { return a.value }
+// Value returns the amount in the token base units
+func (a Amount) Value() *big.Int     { return new(big.Int).Set(a.val()) }
 func (a Amount) Token() *token.Token { return a.token }
 
-func (a Amount) IsZero() bool {
-	if a.value.Cmp(big.NewInt(0)) == 0 {
-		return true
-	}
-	return false
+func (a Amount) IsZero() bool { return a.val().Sign() == 0 }
+func (a Amount) Sign() int    { return a.val().Sign() }
+
+// Cmp compares the amounts of the same token
+func (a Amount) Cmp(a2 Amount) int {
+	a.mustSameToken(a2, "comparing")
+	return a.val().Cmp(a2.val())
+}
+
+func (a Amount) Neg() Amount {
+	return Amount{token: a.token, value: new(big.Int).Neg(a.val())}
+}
+
+func (a Amount) Abs() Amount {
+	return Amount{token: a.token, value: new(big.Int).Abs(a.val())}
 }
 
 func (a Amount) Add(a2 Amount) Amount {
-	if !a.token.Eq(a2.token) {
-		panic(fmt.Errorf("try adding an amount to an invalid token: [%s, %s, %s] <> [%s, %s,%s]",
-			a.token.Network(), a.token.Address(), a.token.Ticker(),
-			a2.token.Network(), a2.token.Address(), a2.token.Ticker()))
-	}
+	a.mustSameToken(a2, "adding")
 	return Amount{
 		token: a.token,
-		value: new(big.Int).Add(a.value, a2.value),
+		value: new(big.Int).Add(a.val(), a2.val()),
 	}
 }
 
 func (a Amount) Sub(a2 Amount) Amount {
-	if !a.token.Eq(a2.token) {
-		panic(fmt.Errorf("try subtracting an amount to an invalid token: [%s, %s, %s] <> [%s, %s,%s]",
-			a.token.Network(), a.token.Address(), a.token.Ticker(),
-			a2.token.Network(), a2.token.Address(), a2.token.Ticker()))
-	}
+	a.mustSameToken(a2, "subtracting")
 	return Amount{
 		token: a.token,
-		value: new(big.Int).Add(a.value, a2.value),
+		value: new(big.Int).Sub(a.val(), a2.val()),
 	}
 }
 
-func (a Amount) Mul(val interface{}) Amount {
-	value := unknownValueToInt(a.token, val)
+// Mul multiplies the amount by the factor rounding the result down,
+// the factor is an integer, *big.Float, *big.Rat, float64 or Percent
+func (a Amount) Mul(factor interface{}) Amount {
+	return a.MulRound(factor, RoundDown)
+}
+
+// MulRound multiplies the amount by the factor with the given rounding
+func (a Amount) MulRound(factor interface{}, rounding Rounding) Amount {
+	r := new(big.Rat).SetInt(a.val())
 	return Amount{
 		token: a.token,
-		value: new(big.Int).Mul(a.value, value),
+		value: round(r.Mul(r, factorToRat(factor)), rounding),
 	}
 }
 
-func (a Amount) Div(val interface{}) Amount {
-	value := unknownValueToInt(a.token, val)
+// Div divides the amount by the factor rounding the result down
+func (a Amount) Div(factor interface{}) Amount {
+	return a.DivRound(factor, RoundDown)
+}
+
+// DivRound divides the amount by the factor with the given rounding
+func (a Amount) DivRound(factor interface{}, rounding Rounding) Amount {
+	f := factorToRat(factor)
+	if f.Sign() == 0 {
+		panic(fmt.Errorf("division of the %s amount by zero", a.token))
+	}
+	r := new(big.Rat).SetInt(a.val())
 	return Amount{
 		token: a.token,
-		value: new(big.Int).Quo(a.value, value),
+		value: round(r.Quo(r, f), rounding),
 	}
 }
 
+// Rat returns the exact human value of the amount
+func (a Amount) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(a.val(), unit(a.token))
+}
+
 func (a Amount) HumanValue() interface{} {
-	return a.token.ToHumanValue(a.value)
+	return a.token.ToHumanValue(a.val())
+}
+
+// Text formats the exact human value without trailing zeros, e.g. "1.25"
+func (a Amount) Text() string {
+	s := a.Rat().FloatString(a.token.Decimals())
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// String formats the amount with the token ticker, e.g. "1.25 WETH"
+func (a Amount) String() string {
+	if a.token == nil {
+		return a.val().String()
+	}
+	return a.Text() + " " + a.token.Ticker().String()
+}
+
+func (a Amount) val() *big.Int {
+	if a.value == nil {
+		return new(big.Int)
+	}
+	return a.value
+}
+
+func (a Amount) mustSameToken(a2 Amount, op string) {
+	if !a.token.Eq(a2.token) {
+		panic(fmt.Errorf("try %s an amount of an invalid token: [%s, %s, %s] <> [%s, %s, %s]", op,
+			a.token.Network(), a.token.Address(), a.token.Ticker(),
+			a2.token.Network(), a2.token.Address(), a2.token.Ticker()))
+	}
 }
 
-func unknownValueToInt(t *token.Token, val interface{}) *big.Int {
-	value := big.NewInt(0)
+// unit returns the number of the token base units in one token
+func unit(t *token.Token) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.Decimals())), nil)
+}
+
+// round rounds the rational number to an integer with the given rounding
+func round(r *big.Rat, rounding Rounding) *big.Int {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if m.Sign() == 0 {
+		return q
+	}
+
+	var away bool
+	switch rounding {
+	case RoundDown:
+		away = false
+	case RoundUp:
+		away = true
+	case RoundHalfUp, RoundHalfEven:
+		half := new(big.Int).Lsh(new(big.Int).Abs(m), 1).Cmp(r.Denom())
+		away = half > 0 || half == 0 && (rounding == RoundHalfUp || q.Bit(0) == 1)
+	default:
+		panic(fmt.Errorf("unknown rounding: %d", rounding))
+	}
 
-	switch val.(type) {
+	if away {
+		q.Add(q, big.NewInt(int64(r.Sign())))
+	}
+	return q
+}
+
+// factorToRat converts the factor to an exact rational number,
+// the float64 based values are taken by their shortest decimal representation
+func factorToRat(factor interface{}) *big.Rat {
+	switch f := factor.(type) {
 	case *big.Int:
-		value = val.(*big.Int)
-		break
-	case int64:
-		value = new(big.Int).SetInt64(val.(int64))
-		break
+		return new(big.Rat).SetInt(f)
 	case int:
-		value = new(big.Int).SetInt64(int64(val.(int)))
-		break
+		return new(big.Rat).SetInt64(int64(f))
+	case int64:
+		return new(big.Rat).SetInt64(f)
+	case *big.Rat:
+		return new(big.Rat).Set(f)
 	case *big.Float:
-		value = t.ToBaseValue(val.(*big.Float))
-		break
+		if f.IsInf() {
+			panic(fmt.Errorf("infinite amount factor"))
+		}
+		r, _ := f.Rat(nil)
+		return r
 	case float64:
-		value = t.ToBaseValue(big.NewFloat(val.(float64)))
-		break
-	case Amount:
-		value = val.(Amount).Value()
-		break
+		return floatToRat(f)
+	case Percent:
+		return f.Rat()
+	default:
+		panic(fmt.Errorf("unsupported amount factor type %T", factor))
 	}
+}
 
-	return value
+func floatToRat(f float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	if !ok {
+		panic(fmt.Errorf("invalid amount factor: %v", f))
+	}
+	return r
 }
 
+// NewAmount creates an amount, an integer or an Amount value is taken in the token base units,
+// a *big.Float, *big.Rat or float64 value is the human value rounded down to the base units
 func NewAmount(t *token.Token, val interface{}) Amount {
+	var value *big.Int
+
+	switch v := val.(type) {
+	case nil:
+		value = new(big.Int)
+	case *big.Int:
+		value = new(big.Int)
+		if v != nil {
+			value.Set(v)
+		}
+	case int64:
+		value = big.NewInt(v)
+	case int:
+		value = big.NewInt(int64(v))
+	case Amount:
+		value = v.Value()
+	case *big.Float, *big.Rat, float64:
+		r := factorToRat(v)
+		value = round(r.Mul(r, new(big.Rat).SetInt(unit(t))), RoundDown)
+	default:
+		panic(fmt.Errorf("unsupported amount value type %T", val))
+	}
+
 	return Amount{
 		token: t,
-		value: unknownValueToInt(t, val),
+		value: value,
+	}
+}
+
+// NewAmountFromString parses the exact human value of the token amount, e.g. "1.25" or "1.25 WETH"
+func NewAmountFromString(t *token.Token, s string) (Amount, error) {
+	number, ticker, _ := strings.Cut(strings.TrimSpace(s), " ")
+	if ticker = strings.TrimSpace(ticker); ticker != "" && ticker != t.Ticker().String() {
+		return Amount{}, fmt.Errorf("%w: %q is not a %s amount", ErrInvalidAmount, s, t.Ticker())
+	}
+
+	if !decimalNumber.MatchString(number) {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	r, _ := new(big.Rat).SetString(number)
+	r.Mul(r, new(big.Rat).SetInt(unit(t)))
+	if !r.IsInt() {
+		return Amount{}, fmt.Errorf("%w: %q has more than %d decimals", ErrAmountPrecision, s, t.Decimals())
+	}
+
+	return Amount{token: t, value: new(big.Int).Set(r.Num())}, nil
+}
+
+// ParseAmount parses the human amount with the ticker, e.g. "1.25 WETH", the token is looked up by the ticker
+func ParseAmount(s string, tokens ...*token.Token) (Amount, error) {
+	_, ticker, found := strings.Cut(strings.TrimSpace(s), " ")
+	if !found {
+		return Amount{}, fmt.Errorf("%w: %q has no ticker", ErrInvalidAmount, s)
+	}
+
+	ticker = strings.TrimSpace(ticker)
+	for _, t := range tokens {
+		if t.Ticker().String() == ticker {
+			return NewAmountFromString(t, s)
+		}
 	}
+	return Amount{}, fmt.Errorf("%w: %s", ErrUnknownToken, ticker)
 }
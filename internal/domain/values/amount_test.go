@@ -0,0 +1,180 @@
+package values
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+)
+
+var (
+	weth = token.New("eth", "0xc02a", "WETH", 18)
+	usdc = token.New("eth", "0xa0b8", "USDC", 6)
+)
+
+// randInt returns a random integer of up to the bits, negative in about a half of the cases
+func randInt(rnd *rand.Rand, bits int) *big.Int {
+	v := new(big.Int).Rand(rnd, new(big.Int).Lsh(big.NewInt(1), uint(rnd.Intn(bits)+1)))
+	if rnd.Intn(2) == 0 {
+		v.Neg(v)
+	}
+	return v
+}
+
+func FuzzAmountParseFormat(f *testing.F) {
+	for _, s := range []string{"0", "1", "1.25", "-1.25", "0.000001", "123456789.123456789", "+7", "1.", ".5", "1e3", "1.25 WETH"} {
+		f.Add(s, uint8(18), []byte{1, 2, 3}, false)
+	}
+	f.Add("1.1234567", uint8(6), []byte{0xff, 0xff}, true)
+	f.Add("0", uint8(0), []byte{}, false)
+
+	f.Fuzz(func(t *testing.T, s string, decimals uint8, raw []byte, neg bool) {
+		tk := token.New("eth", "0x1", "TKN", int(decimals%40))
+
+		// a parsed amount is formatted to the text parsed back to the same amount
+		if a, err := NewAmountFromString(tk, s); err == nil {
+			b, err := NewAmountFromString(tk, a.Text())
+			if err != nil {
+				t.Fatalf("parse formatted %q of %q: %v", a.Text(), s, err)
+			}
+			if b.Value().Cmp(a.Value()) != 0 {
+				t.Fatalf("%q parsed to %s, its text %q parsed to %s", s, a.Value(), a.Text(), b.Value())
+			}
+			if c, err := ParseAmount(a.String(), tk); err != nil || c.Value().Cmp(a.Value()) != 0 {
+				t.Fatalf("%q with the ticker parsed to %v: %v", a.String(), c, err)
+			}
+		}
+
+		// any amount is formatted exactly
+		v := new(big.Int).SetBytes(raw)
+		if neg {
+			v.Neg(v)
+		}
+		a := NewAmount(tk, v)
+		b, err := NewAmountFromString(tk, a.Text())
+		if err != nil {
+			t.Fatalf("parse formatted %q of %s: %v", a.Text(), v, err)
+		}
+		if b.Value().Cmp(v) != 0 {
+			t.Fatalf("%s formatted to %q parsed to %s", v, a.Text(), b.Value())
+		}
+	})
+}
+
+func TestAmountParse(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int64
+		err  error
+	}{
+		{"1.25", 1_250_000, nil},
+		{"-0.000001", -1, nil},
+		{"1.25 USDC", 1_250_000, nil},
+		{"1.0000001", 0, ErrAmountPrecision},
+		{"1.25 WETH", 0, ErrInvalidAmount},
+		{"1e3", 0, ErrInvalidAmount},
+		{"", 0, ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		a, err := NewAmountFromString(usdc, tt.s)
+		if tt.err != nil {
+			if err == nil || !errors.Is(err, tt.err) {
+				t.Errorf("%q: expected %v, got %v", tt.s, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || a.Value().Int64() != tt.want {
+			t.Errorf("%q: got %v, %v, want %d", tt.s, a.Value(), err, tt.want)
+		}
+	}
+}
+
+func TestAmountMulRound(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	half := big.NewRat(1, 2)
+
+	for i := 0; i < 20000; i++ {
+		a := NewAmount(weth, randInt(rnd, 200))
+		num, den := randInt(rnd, 80), new(big.Int).Add(new(big.Int).Rand(rnd, big.NewInt(1e12)), big.NewInt(1))
+		if i%7 == 0 {
+			// the ties of the halves
+			num, den = big.NewInt(int64(2*rnd.Intn(100)+1)), big.NewInt(2)
+		}
+		factor := new(big.Rat).SetFrac(num, den)
+		exact := new(big.Rat).Mul(new(big.Rat).SetInt(a.Value()), factor)
+
+		for _, rounding := range []Rounding{RoundDown, RoundUp, RoundHalfUp, RoundHalfEven} {
+			got := a.MulRound(factor, rounding).Value()
+			diff := new(big.Rat).Sub(new(big.Rat).SetInt(got), exact)
+			away := diff.Sign() * exact.Sign()
+			dist := new(big.Rat).Abs(diff)
+
+			if exact.IsInt() {
+				if got.Cmp(exact.Num()) != 0 {
+					t.Fatalf("%s * %s [%d]: exact %s rounded to %s", a.Value(), factor, rounding, exact, got)
+				}
+				continue
+			}
+			if dist.Cmp(big.NewRat(1, 1)) >= 0 {
+				t.Fatalf("%s * %s [%d]: %s is not next to %s", a.Value(), factor, rounding, got, exact)
+			}
+
+			switch rounding {
+			case RoundDown:
+				if away > 0 {
+					t.Fatalf("%s * %s: %s rounded down away from zero to %s", a.Value(), factor, exact, got)
+				}
+			case RoundUp:
+				if away < 0 {
+					t.Fatalf("%s * %s: %s rounded up towards zero to %s", a.Value(), factor, exact, got)
+				}
+			case RoundHalfUp, RoundHalfEven:
+				switch dist.Cmp(half) {
+				case 1:
+					t.Fatalf("%s * %s [%d]: %s is not the nearest to %s", a.Value(), factor, rounding, got, exact)
+				case 0:
+					if rounding == RoundHalfUp && away < 0 {
+						t.Fatalf("%s * %s: the tie %s rounded half up towards zero to %s", a.Value(), factor, exact, got)
+					}
+					if rounding == RoundHalfEven && got.Bit(0) != 0 {
+						t.Fatalf("%s * %s: the tie %s rounded half even to the odd %s", a.Value(), factor, exact, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestAmountAddSub(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+
+	for i := 0; i < 10000; i++ {
+		a, b, c := NewAmount(usdc, randInt(rnd, 256)), NewAmount(usdc, randInt(rnd, 256)), NewAmount(usdc, randInt(rnd, 256))
+
+		if a.Add(b).Add(c).Cmp(a.Add(b.Add(c))) != 0 {
+			t.Fatalf("(%s + %s) + %s is not %s + (%s + %s)", a, b, c, a, b, c)
+		}
+		if a.Sub(b).Sub(c).Cmp(a.Sub(b.Add(c))) != 0 {
+			t.Fatalf("(%s - %s) - %s is not %s - (%s + %s)", a, b, c, a, b, c)
+		}
+		if a.Add(b).Cmp(b.Add(a)) != 0 {
+			t.Fatalf("%s + %s is not %s + %s", a, b, b, a)
+		}
+		if a.Add(b).Sub(b).Cmp(a) != 0 {
+			t.Fatalf("%s + %s - %s is not %s", a, b, b, a)
+		}
+		if a.Sub(b).Cmp(a.Add(b.Neg())) != 0 {
+			t.Fatalf("%s - %s is not %s + -%s", a, b, a, b)
+		}
+	}
+
+	// the operands are not changed
+	a, b := NewAmount(usdc, 5), NewAmount(usdc, 3)
+	_ = a.Add(b)
+	_ = a.Sub(b)
+	if a.Value().Int64() != 5 || b.Value().Int64() != 3 {
+		t.Fatalf("the operands changed to %s, %s", a.Value(), b.Value())
+	}
+}
@@ -2,6 +2,7 @@ package values
 
 import (
 	"math/big"
+	"strconv"
 )
 
 type Percent float64
@@ -9,6 +10,12 @@ type Percent float64
 func (p Percent) Value() float64    { return float64(p) }
 func (p Percent) Float() *big.Float { return big.NewFloat(float64(p)) }
 
+// Rat returns the exact fraction of the percent by its shortest decimal representation, e.g. 0.003 is 3/1000
+func (p Percent) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(float64(p), 'g', -1, 64))
+	return r
+}
+
 func NewPercent(percent float64) Percent {
 	return Percent(percent)
 }
@@ -0,0 +1,176 @@
+package values
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/pkg/clmath"
+)
+
+// Price is the decimal-adjusted price of the pair base token in the quote token,
+// i.e. the amount of the quote tokens paid for one base token
+type Price struct {
+	pair  *token.Pair
+	value *big.Float
+}
+
+func (p Price) Pair() *token.Pair { return p.pair }
+
+// Float returns the human price of the base token in the quote token
+func (p Price) Float() *big.Float { return new(big.Float).SetPrec(clmath.Precision).Set(p.val()) }
+
+func (p Price) IsZero() bool { return p.val().Sign() == 0 }
+
+// Cmp compares the prices of the same pair, the price of the reversed pair is inverted before comparing
+func (p Price) Cmp(p2 Price) int {
+	switch {
+	case p.samePair(p2.pair):
+		return p.val().Cmp(p2.val())
+	case p.reversedPair(p2.pair):
+		return p.val().Cmp(p2.Invert().val())
+	default:
+		panic(fmt.Errorf("try comparing prices of different pairs: %s <> %s", p.pair, p2.pair))
+	}
+}
+
+// Mul multiplies the price by the factor, e.g. to shift it by a volatility
+func (p Price) Mul(factor interface{}) Price {
+	f := new(big.Float).SetPrec(clmath.Precision).SetRat(factorToRat(factor))
+	return Price{pair: p.pair, value: f.Mul(f, p.val())}
+}
+
+// Invert returns the price of the quote token in the base token of the reversed pair
+func (p Price) Invert() Price {
+	pair := token.NewPair(p.pair.QuoteToken(), p.pair.BaseToken())
+	if p.IsZero() {
+		return Price{pair: pair, value: new(big.Float).SetPrec(clmath.Precision)}
+	}
+	return Price{pair: pair, value: new(big.Float).SetPrec(clmath.Precision).Quo(big.NewFloat(1), p.val())}
+}
+
+// SqrtPriceX96 converts the price to the sqrt price of the pool of the pair tokens
+func (p Price) SqrtPriceX96() (*big.Int, error) {
+	base, quote := p.pair.BaseToken(), p.pair.QuoteToken()
+	if p.pair.IsBaseToken0() {
+		return clmath.PriceToSqrtPriceX96(p.val(), base.Decimals(), quote.Decimals())
+	}
+	if p.val().Sign() <= 0 {
+		return nil, clmath.ErrInvalidPrice
+	}
+	return clmath.PriceToSqrtPriceX96(p.Invert().val(), quote.Decimals(), base.Decimals())
+}
+
+// Tick converts the price to the greatest pool tick whose sqrt price does not exceed the price sqrt price
+func (p Price) Tick() (int, error) {
+	sqrtPriceX96, err := p.SqrtPriceX96()
+	if err != nil {
+		return 0, err
+	}
+	return clmath.GetTickAtSqrtRatio(sqrtPriceX96)
+}
+
+// Convert converts the amount of one pair token to the other one rounding the result down
+func (p Price) Convert(a Amount) Amount {
+	return p.ConvertRound(a, RoundDown)
+}
+
+// ConvertRound converts the amount of one pair token to the other one with the given rounding
+func (p Price) ConvertRound(a Amount, rounding Rounding) Amount {
+	price, _ := p.val().Rat(nil)
+	human := a.Rat()
+
+	var t *token.Token
+	switch {
+	case a.token.Eq(p.pair.BaseToken()):
+		t = p.pair.QuoteToken()
+		human.Mul(human, price)
+	case a.token.Eq(p.pair.QuoteToken()):
+		if price.Sign() == 0 {
+			panic(fmt.Errorf("conversion of the %s amount by zero %s price", a.token, p.pair))
+		}
+		t = p.pair.BaseToken()
+		human.Quo(human, price)
+	default:
+		panic(fmt.Errorf("try converting an amount of %s by %s price", a.token, p.pair))
+	}
+
+	return Amount{
+		token: t,
+		value: round(human.Mul(human, new(big.Rat).SetInt(unit(t))), rounding),
+	}
+}
+
+// String formats the price with the pair, e.g. "1850.25 USDC/WETH"
+func (p Price) String() string {
+	if p.pair == nil {
+		return p.val().Text('f', -1)
+	}
+	return fmt.Sprintf("%s %s/%s", p.val().Text('f', p.pair.QuoteToken().Decimals()),
+		p.pair.QuoteToken(), p.pair.BaseToken())
+}
+
+// Format formats the price as a number for the float verbs, so the price can be logged as a *big.Float
+func (p Price) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 's', 'v':
+		_, _ = fmt.Fprint(s, p.String())
+	default:
+		p.val().Format(s, verb)
+	}
+}
+
+func (p Price) val() *big.Float {
+	if p.value == nil {
+		return new(big.Float).SetPrec(clmath.Precision)
+	}
+	return p.value
+}
+
+func (p Price) samePair(pair *token.Pair) bool {
+	return p.pair.BaseToken().Eq(pair.BaseToken()) && p.pair.QuoteToken().Eq(pair.QuoteToken())
+}
+
+func (p Price) reversedPair(pair *token.Pair) bool {
+	return p.pair.BaseToken().Eq(pair.QuoteToken()) && p.pair.QuoteToken().Eq(pair.BaseToken())
+}
+
+// NewPrice creates the price of the pair base token in the quote token,
+// the value is a *big.Float, *big.Rat, float64 or an integer
+func NewPrice(pair *token.Pair, val interface{}) Price {
+	return Price{
+		pair:  pair,
+		value: new(big.Float).SetPrec(clmath.Precision).SetRat(factorToRat(val)),
+	}
+}
+
+// NewPriceFromAmounts creates the price at which the base amount is exchanged for the quote amount
+func NewPriceFromAmounts(baseAmount, quoteAmount Amount) Price {
+	pair := token.NewPair(baseAmount.Token(), quoteAmount.Token())
+	if baseAmount.IsZero() {
+		return Price{pair: pair, value: new(big.Float).SetPrec(clmath.Precision)}
+	}
+	return NewPrice(pair, new(big.Rat).Quo(quoteAmount.Rat(), baseAmount.Rat()))
+}
+
+// NewPriceFromSqrtPriceX96 creates the price from the sqrt price of the pool of the pair tokens
+func NewPriceFromSqrtPriceX96(pair *token.Pair, sqrtPriceX96 *big.Int) Price {
+	base, quote := pair.BaseToken(), pair.QuoteToken()
+	if pair.IsBaseToken0() {
+		return Price{pair: pair, value: clmath.SqrtPriceX96ToPrice(sqrtPriceX96, base.Decimals(), quote.Decimals())}
+	}
+	price := clmath.SqrtPriceX96ToPrice(sqrtPriceX96, quote.Decimals(), base.Decimals())
+	if price.Sign() != 0 {
+		price.Quo(big.NewFloat(1), price)
+	}
+	return Price{pair: pair, value: price}
+}
+
+// NewPriceAtTick creates the price at the pool tick of the pair tokens
+func NewPriceAtTick(pair *token.Pair, tick int) (Price, error) {
+	sqrtPriceX96, err := clmath.GetSqrtRatioAtTick(tick)
+	if err != nil {
+		return Price{}, err
+	}
+	return NewPriceFromSqrtPriceX96(pair, sqrtPriceX96), nil
+}
@@ -0,0 +1,180 @@
+package values
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/r1der/epos/internal/domain/entity/token"
+	"github.com/r1der/epos/pkg/clmath"
+)
+
+// the weth/usdc pair has the base token1 and the usdc/weth pair has the base token0
+var pairs = []*token.Pair{token.NewPair(weth, usdc), token.NewPair(usdc, weth)}
+
+func TestPriceTickRoundTrip(t *testing.T) {
+	for _, pair := range pairs {
+		for _, tick := range []int{clmath.MinTick, -887000, -276325, -200311, -1, 0, 1, 60, 200311, 276324, 887000, clmath.MaxTick - 1} {
+			p, err := NewPriceAtTick(pair, tick)
+			if err != nil {
+				t.Fatalf("%s at %d: %v", pair, tick, err)
+			}
+			got, err := p.Tick()
+			if err != nil {
+				t.Fatalf("%s at %d: %v", pair, tick, err)
+			}
+			if got != tick {
+				t.Errorf("%s: price %s at tick %d converted to tick %d", pair, p, tick, got)
+			}
+		}
+
+		// the price lies between the prices of its tick and the next one
+		for _, v := range []float64{2000, 1850.25, 3.5, 1, 0.0005, 1e-9} {
+			p := NewPrice(pair, v)
+			tick, err := p.Tick()
+			if err != nil {
+				t.Fatalf("%s %v: %v", pair, v, err)
+			}
+			at, err := NewPriceAtTick(pair, tick)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next, err := NewPriceAtTick(pair, tick+1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the higher tick is the lower price of the base token1
+			if at.Cmp(next) > 0 {
+				at, next = next, at
+			}
+			if p.Cmp(at) < 0 || p.Cmp(next) > 0 {
+				t.Errorf("%s: price %v out of [%s, %s] of tick %d", pair, v, at, next, tick)
+			}
+		}
+	}
+}
+
+func TestPriceSqrtPriceRoundTrip(t *testing.T) {
+	// the sqrt price is rounded down, so the price is recovered to the relative error of about 2^-96
+	tolerance := new(big.Float).SetFloat64(1e-27)
+	for _, pair := range pairs {
+		for _, v := range []float64{2000, 1850.25, 1, 0.0005, 1e-9} {
+			p := NewPrice(pair, v)
+			sqrtPriceX96, err := p.SqrtPriceX96()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := NewPriceFromSqrtPriceX96(pair, sqrtPriceX96)
+			diff := new(big.Float).Sub(got.Float(), p.Float())
+			diff.Quo(diff.Abs(diff), p.Float())
+			if diff.Cmp(tolerance) > 0 || got.Pair() != pair {
+				t.Errorf("%s: price %v converted to %s, relative error %.3g", pair, v, got, diff)
+			}
+		}
+
+		for _, tick := range []int{-200311, -1, 0, 1, 200311} {
+			sqrtPriceX96, err := clmath.GetSqrtRatioAtTick(tick)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewPriceFromSqrtPriceX96(pair, sqrtPriceX96).SqrtPriceX96()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Cmp(sqrtPriceX96) != 0 {
+				t.Errorf("%s: sqrt price %s converted to %s", pair, sqrtPriceX96, got)
+			}
+		}
+
+		if _, err := NewPrice(pair, 0).SqrtPriceX96(); err == nil {
+			t.Errorf("%s: expected the invalid price error of the zero price", pair)
+		}
+	}
+}
+
+func TestPriceInvert(t *testing.T) {
+	tolerance := new(big.Float).SetMantExp(big.NewFloat(1), -250)
+	for _, pair := range pairs {
+		for _, v := range []float64{2000, 1850.25, 3, 1, 0.0005, 1e-9} {
+			p := NewPrice(pair, v)
+			inverted := p.Invert()
+			if !inverted.Pair().BaseToken().Eq(pair.QuoteToken()) || !inverted.Pair().QuoteToken().Eq(pair.BaseToken()) {
+				t.Errorf("%s: inverted to the %s pair", pair, inverted.Pair())
+			}
+			// the price of the reversed pair is compared by its inverted value
+			if p.Cmp(inverted) != 0 {
+				t.Errorf("%s: price %v differs from its inverted price %s", pair, v, inverted)
+			}
+
+			twice := inverted.Invert()
+			diff := new(big.Float).Sub(twice.Float(), p.Float())
+			diff.Quo(diff.Abs(diff), p.Float())
+			if diff.Cmp(tolerance) > 0 || !twice.Pair().BaseToken().Eq(pair.BaseToken()) {
+				t.Errorf("%s: price %v inverted twice to %s", pair, v, twice)
+			}
+		}
+
+		if zero := NewPrice(pair, 0).Invert(); !zero.IsZero() {
+			t.Errorf("%s: zero price inverted to %s", pair, zero)
+		}
+	}
+}
+
+func TestPriceConvertRound(t *testing.T) {
+	pair := token.NewPair(weth, usdc)
+	tests := []struct {
+		name   string
+		price  interface{}
+		amount Amount
+		// want holds the results of RoundDown, RoundUp, RoundHalfUp and RoundHalfEven
+		want [4]int64
+	}{
+		// 1 weth at 1850.123456789 usdc is 1850.123456789 usdc of 6 decimals
+		{"base to quote", 1850.123456789, NewAmount(weth, int64(1e18)), [4]int64{1850123456, 1850123457, 1850123457, 1850123457}},
+		{"base to quote below half", 1850.1234561, NewAmount(weth, int64(1e18)), [4]int64{1850123456, 1850123457, 1850123456, 1850123456}},
+		// the dyadic price keeps the ties exact
+		{"odd tie", 0.5, NewAmount(weth, int64(3e12)), [4]int64{1, 2, 2, 2}},
+		{"even tie", 0.5, NewAmount(weth, int64(5e12)), [4]int64{2, 3, 3, 2}},
+		{"negative tie", 0.5, NewAmount(weth, int64(-5e12)), [4]int64{-2, -3, -3, -2}},
+		{"exact", 2000, NewAmount(weth, int64(15e17)), [4]int64{3e9, 3e9, 3e9, 3e9}},
+		// 1 usdc base unit is 1e-6/3 weth, i.e. 333333333333.33 wei
+		{"quote to base", 3, NewAmount(usdc, 1), [4]int64{333333333333, 333333333334, 333333333333, 333333333333}},
+		{"quote to base above half", 3, NewAmount(usdc, 2), [4]int64{666666666666, 666666666667, 666666666667, 666666666667}},
+		{"zero", 2000, NewAmount(usdc, 0), [4]int64{0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		p := NewPrice(pair, tt.price)
+		for i, rounding := range []Rounding{RoundDown, RoundUp, RoundHalfUp, RoundHalfEven} {
+			got := p.ConvertRound(tt.amount, rounding)
+			want := usdc
+			if tt.amount.Token().Eq(usdc) {
+				want = weth
+			}
+			if !got.Token().Eq(want) || got.Value().Int64() != tt.want[i] {
+				t.Errorf("%s: rounding %d: got %s, want %d of %s", tt.name, rounding, got.Value(), tt.want[i], want)
+			}
+		}
+		if got := p.Convert(tt.amount); got.Value().Int64() != tt.want[0] {
+			t.Errorf("%s: converted to %s, want %d rounded down", tt.name, got.Value(), tt.want[0])
+		}
+	}
+
+	// the price of the reversed pair converts the same amounts
+	p := NewPrice(token.NewPair(usdc, weth), 0.5)
+	if got := p.ConvertRound(NewAmount(usdc, 5), RoundHalfEven); !got.Token().Eq(weth) || got.Value().Int64() != 2_500_000_000_000 {
+		t.Errorf("reversed pair: got %s", got)
+	}
+
+	for name, convert := range map[string]func(){
+		"other token": func() { NewPrice(pair, 2000).Convert(NewAmount(token.New("eth", "0xdac1", "USDT", 6), 1)) },
+		"zero price":  func() { NewPrice(pair, 0).Convert(NewAmount(usdc, 1)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected the panic", name)
+				}
+			}()
+			convert()
+		}()
+	}
+}
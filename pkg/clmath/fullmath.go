@@ -0,0 +1,79 @@
+// Package clmath is an exact big.Int port of the Uniswap v3 concentrated liquidity math
+// (TickMath, SqrtPriceMath, LiquidityAmounts), every function returns the same value as its Solidity counterpart.
+package clmath
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrOverflow         = errors.New("overflow")
+	ErrInvalidLiquidity = errors.New("invalid liquidity")
+	ErrInvalidPrice     = errors.New("invalid price")
+)
+
+var (
+	// Q96 is the fixed point resolution of the sqrt prices
+	Q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+	maxUint128 = maxUint(128)
+	maxUint160 = maxUint(160)
+	maxUint256 = maxUint(256)
+)
+
+func maxUint(bits uint) *big.Int {
+	return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bits), big.NewInt(1))
+}
+
+// MulDiv calculates floor(a×b÷denominator)
+func MulDiv(a, b, denominator *big.Int) (*big.Int, error) {
+	if denominator.Sign() == 0 {
+		return nil, ErrOverflow
+	}
+	result := new(big.Int).Mul(a, b)
+	result.Quo(result, denominator)
+	if result.Cmp(maxUint256) > 0 {
+		return nil, ErrOverflow
+	}
+	return result, nil
+}
+
+// MulDivRoundingUp calculates ceil(a×b÷denominator)
+func MulDivRoundingUp(a, b, denominator *big.Int) (*big.Int, error) {
+	if denominator.Sign() == 0 {
+		return nil, ErrOverflow
+	}
+	product := new(big.Int).Mul(a, b)
+	result, remainder := new(big.Int).QuoRem(product, denominator, new(big.Int))
+	if remainder.Sign() > 0 {
+		result.Add(result, big.NewInt(1))
+	}
+	if result.Cmp(maxUint256) > 0 {
+		return nil, ErrOverflow
+	}
+	return result, nil
+}
+
+// divRoundingUp calculates ceil(x÷y), UnsafeMath.divRoundingUp
+func divRoundingUp(x, y *big.Int) *big.Int {
+	result, remainder := new(big.Int).QuoRem(x, y, new(big.Int))
+	if remainder.Sign() > 0 {
+		result.Add(result, big.NewInt(1))
+	}
+	return result
+}
+
+func toUint128(x *big.Int) (*big.Int, error) {
+	if x.Sign() < 0 || x.Cmp(maxUint128) > 0 {
+		return nil, ErrOverflow
+	}
+	return x, nil
+}
+
+func toUint160(x *big.Int) (*big.Int, error) {
+	if x.Sign() < 0 || x.Cmp(maxUint160) > 0 {
+		return nil, ErrOverflow
+	}
+	return x, nil
+}
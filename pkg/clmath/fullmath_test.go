@@ -0,0 +1,117 @@
+package clmath
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// q128 scales the FullMath test values of v3-core
+var q128 = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// q128Frac returns q128 × num ÷ den
+func q128Frac(num, den int64) *big.Int {
+	v := new(big.Int).Mul(q128, big.NewInt(num))
+	return v.Quo(v, big.NewInt(den))
+}
+
+// the values of the Solidity FullMath library, see the v3-core FullMath tests
+func TestMulDiv(t *testing.T) {
+	plusOne := func(v *big.Int) *big.Int { return new(big.Int).Add(v, big.NewInt(1)) }
+	tests := []struct {
+		name             string
+		a, b, d          *big.Int
+		want, wantUp     *big.Int
+		overflow, overUp bool
+	}{
+		{name: "zero denominator", a: q128, b: big.NewInt(5), d: new(big.Int), overflow: true, overUp: true},
+		{name: "zero denominator and overflowing numerator", a: q128, b: q128, d: new(big.Int), overflow: true, overUp: true},
+		{name: "overflowing output", a: q128, b: q128, d: big.NewInt(1), overflow: true, overUp: true},
+		{name: "all max inputs", a: maxUint256, b: maxUint256, d: maxUint256, want: maxUint256, wantUp: maxUint256},
+		{name: "max output", a: maxUint256, b: big.NewInt(1), d: big.NewInt(1), want: maxUint256, wantUp: maxUint256},
+		{name: "max output of two", a: maxUint256, b: big.NewInt(2), d: big.NewInt(2), want: maxUint256, wantUp: maxUint256},
+		{name: "max output overflowed", a: maxUint256, b: big.NewInt(2), d: big.NewInt(1), overflow: true, overUp: true},
+		{
+			name: "without phantom overflow", a: q128, b: q128Frac(50, 100), d: q128Frac(150, 100),
+			want: q128Frac(1, 3), wantUp: plusOne(q128Frac(1, 3)),
+		},
+		{
+			name: "with phantom overflow", a: q128, b: q128Frac(35, 1), d: q128Frac(8, 1),
+			want: q128Frac(4375, 1000), wantUp: q128Frac(4375, 1000),
+		},
+		{
+			name: "with phantom overflow and repeating decimal", a: q128, b: q128Frac(1000, 1), d: q128Frac(3000, 1),
+			want: q128Frac(1, 3), wantUp: plusOne(q128Frac(1, 3)),
+		},
+		{
+			// the floor fits 256 bits, but the result rounded up does not
+			name: "overflow after rounding up", a: bigInt("535006138814359"),
+			b:    bigInt("432862656469423142931042426214547535783388063929571229938474969"), d: big.NewInt(2),
+			want: maxUint256, overUp: true,
+		},
+		{
+			name: "overflow after rounding up of large values",
+			a:    bigInt("115792089237316195423570985008687907853269984659341747863450311749907997002549"),
+			b:    bigInt("115792089237316195423570985008687907853269984659341747863450311749907997002550"),
+			d:    bigInt("115792089237316195423570985008687907853269984653042931687443039491902864365164"),
+			want: maxUint256, overUp: true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := MulDiv(tt.a, tt.b, tt.d)
+		switch {
+		case tt.overflow:
+			if !errors.Is(err, ErrOverflow) {
+				t.Errorf("%s: expected the overflow, got %v, %v", tt.name, got, err)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case got.Cmp(tt.want) != 0:
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+
+		got, err = MulDivRoundingUp(tt.a, tt.b, tt.d)
+		switch {
+		case tt.overUp:
+			if !errors.Is(err, ErrOverflow) {
+				t.Errorf("%s: rounding up: expected the overflow, got %v, %v", tt.name, got, err)
+			}
+		case err != nil:
+			t.Errorf("%s: rounding up: %v", tt.name, err)
+		case got.Cmp(tt.wantUp) != 0:
+			t.Errorf("%s: rounding up: got %s, want %s", tt.name, got, tt.wantUp)
+		}
+	}
+}
+
+// the results rounded up and down differ by the remainder of the division only
+func TestMulDivRounding(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		a := new(big.Int).Rand(rnd, maxUint256)
+		b := new(big.Int).Rand(rnd, maxUint256)
+		d := new(big.Int).Add(new(big.Int).Rand(rnd, maxUint256), big.NewInt(1))
+
+		down, errDown := MulDiv(a, b, d)
+		up, errUp := MulDivRoundingUp(a, b, d)
+		if errDown != nil {
+			if errUp == nil {
+				t.Fatalf("%s×%s÷%s: rounded up despite the overflow of %v", a, b, d, errDown)
+			}
+			continue
+		}
+		if errUp != nil {
+			if down.Cmp(maxUint256) != 0 {
+				t.Fatalf("%s×%s÷%s: overflowed rounding up %s", a, b, d, down)
+			}
+			continue
+		}
+
+		exact := new(big.Int).Mod(new(big.Int).Mul(a, b), d).Sign() == 0
+		diff := new(big.Int).Sub(up, down).Int64()
+		if (exact && diff != 0) || (!exact && diff != 1) {
+			t.Fatalf("%s×%s÷%s: rounded down to %s and up to %s", a, b, d, down, up)
+		}
+	}
+}
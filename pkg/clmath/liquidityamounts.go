@@ -0,0 +1,108 @@
+package clmath
+
+import (
+	"math/big"
+)
+
+// GetLiquidityForAmount0 computes the amount of liquidity received for a given amount of token0 and price range,
+// amount0 * (sqrt(upper) * sqrt(lower)) / (sqrt(upper) - sqrt(lower))
+func GetLiquidityForAmount0(sqrtRatioAX96, sqrtRatioBX96, amount0 *big.Int) (*big.Int, error) {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+
+	intermediate, err := MulDiv(sqrtRatioAX96, sqrtRatioBX96, Q96)
+	if err != nil {
+		return nil, err
+	}
+	liquidity, err := MulDiv(amount0, intermediate, new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96))
+	if err != nil {
+		return nil, err
+	}
+	return toUint128(liquidity)
+}
+
+// GetLiquidityForAmount1 computes the amount of liquidity received for a given amount of token1 and price range,
+// amount1 / (sqrt(upper) - sqrt(lower))
+func GetLiquidityForAmount1(sqrtRatioAX96, sqrtRatioBX96, amount1 *big.Int) (*big.Int, error) {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+
+	liquidity, err := MulDiv(amount1, Q96, new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96))
+	if err != nil {
+		return nil, err
+	}
+	return toUint128(liquidity)
+}
+
+// GetLiquidityForAmounts computes the maximum amount of liquidity received for a given amount of token0, token1,
+// the current pool prices and the prices at the tick boundaries
+func GetLiquidityForAmounts(sqrtRatioX96, sqrtRatioAX96, sqrtRatioBX96, amount0, amount1 *big.Int) (*big.Int, error) {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+
+	if sqrtRatioX96.Cmp(sqrtRatioAX96) <= 0 {
+		return GetLiquidityForAmount0(sqrtRatioAX96, sqrtRatioBX96, amount0)
+	}
+	if sqrtRatioX96.Cmp(sqrtRatioBX96) >= 0 {
+		return GetLiquidityForAmount1(sqrtRatioAX96, sqrtRatioBX96, amount1)
+	}
+
+	liquidity0, err := GetLiquidityForAmount0(sqrtRatioX96, sqrtRatioBX96, amount0)
+	if err != nil {
+		return nil, err
+	}
+	liquidity1, err := GetLiquidityForAmount1(sqrtRatioAX96, sqrtRatioX96, amount1)
+	if err != nil {
+		return nil, err
+	}
+	if liquidity0.Cmp(liquidity1) < 0 {
+		return liquidity0, nil
+	}
+	return liquidity1, nil
+}
+
+// GetAmount0ForLiquidity computes the amount of token0 for a given amount of liquidity and a price range
+func GetAmount0ForLiquidity(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int) (*big.Int, error) {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+	if sqrtRatioAX96.Sign() <= 0 {
+		return nil, ErrInvalidPrice
+	}
+
+	amount, err := MulDiv(new(big.Int).Lsh(liquidity, 96), new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96), sqrtRatioBX96)
+	if err != nil {
+		return nil, err
+	}
+	return amount.Quo(amount, sqrtRatioAX96), nil
+}
+
+// GetAmount1ForLiquidity computes the amount of token1 for a given amount of liquidity and a price range
+func GetAmount1ForLiquidity(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int) (*big.Int, error) {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+	return MulDiv(liquidity, new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96), Q96)
+}
+
+// GetAmountsForLiquidity computes the token0 and token1 value for a given amount of liquidity,
+// the current pool prices and the prices at the tick boundaries
+func GetAmountsForLiquidity(sqrtRatioX96, sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int) (*big.Int, *big.Int, error) {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+
+	var (
+		amount0 = big.NewInt(0)
+		amount1 = big.NewInt(0)
+		err     error
+	)
+
+	switch {
+	case sqrtRatioX96.Cmp(sqrtRatioAX96) <= 0:
+		amount0, err = GetAmount0ForLiquidity(sqrtRatioAX96, sqrtRatioBX96, liquidity)
+	case sqrtRatioX96.Cmp(sqrtRatioBX96) < 0:
+		amount0, err = GetAmount0ForLiquidity(sqrtRatioX96, sqrtRatioBX96, liquidity)
+		if err == nil {
+			amount1, err = GetAmount1ForLiquidity(sqrtRatioAX96, sqrtRatioX96, liquidity)
+		}
+	default:
+		amount1, err = GetAmount1ForLiquidity(sqrtRatioAX96, sqrtRatioBX96, liquidity)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return amount0, amount1, nil
+}
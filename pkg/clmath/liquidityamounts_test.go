@@ -0,0 +1,130 @@
+package clmath
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// encodePriceSqrt returns the sqrt price of the reserve1/reserve0 price, like the v3 test utility
+func encodePriceSqrt(reserve1, reserve0 int64) *big.Int {
+	ratio := new(big.Int).Lsh(big.NewInt(reserve1), 192)
+	return ratio.Sqrt(ratio.Quo(ratio, big.NewInt(reserve0)))
+}
+
+// the values of the Solidity LiquidityAmounts library, see the v3-periphery LiquidityAmounts tests
+func TestGetLiquidityForAmounts(t *testing.T) {
+	sqrtPriceAX96, sqrtPriceBX96 := encodePriceSqrt(100, 110), encodePriceSqrt(110, 100)
+	tests := []struct {
+		name         string
+		sqrtPriceX96 *big.Int
+		want         int64
+	}{
+		{"in range", encodePriceSqrt(1, 1), 2148},
+		{"below", encodePriceSqrt(99, 110), 1048},
+		{"above", encodePriceSqrt(111, 100), 2097},
+		{"at lower", sqrtPriceAX96, 1048},
+		{"at upper", sqrtPriceBX96, 2097},
+	}
+	for _, tt := range tests {
+		// the boundaries are sorted
+		for _, bounds := range [][2]*big.Int{{sqrtPriceAX96, sqrtPriceBX96}, {sqrtPriceBX96, sqrtPriceAX96}} {
+			got, err := GetLiquidityForAmounts(tt.sqrtPriceX96, bounds[0], bounds[1], big.NewInt(100), big.NewInt(200))
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if got.Int64() != tt.want {
+				t.Errorf("%s: got %s, want %d", tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestGetAmountsForLiquidity(t *testing.T) {
+	sqrtPriceAX96, sqrtPriceBX96 := encodePriceSqrt(100, 110), encodePriceSqrt(110, 100)
+	tests := []struct {
+		name             string
+		sqrtPriceX96     *big.Int
+		amount0, amount1 int64
+	}{
+		{"in range", encodePriceSqrt(1, 1), 99, 99},
+		{"below", encodePriceSqrt(99, 110), 204, 0},
+		{"above", encodePriceSqrt(111, 100), 0, 204},
+		{"at lower", sqrtPriceAX96, 204, 0},
+		{"at upper", sqrtPriceBX96, 0, 204},
+	}
+	for _, tt := range tests {
+		for _, bounds := range [][2]*big.Int{{sqrtPriceAX96, sqrtPriceBX96}, {sqrtPriceBX96, sqrtPriceAX96}} {
+			amount0, amount1, err := GetAmountsForLiquidity(tt.sqrtPriceX96, bounds[0], bounds[1], big.NewInt(2148))
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if amount0.Int64() != tt.amount0 || amount1.Int64() != tt.amount1 {
+				t.Errorf("%s: got %s and %s, want %d and %d", tt.name, amount0, amount1, tt.amount0, tt.amount1)
+			}
+		}
+	}
+}
+
+func TestLiquidityAmountsBoundaries(t *testing.T) {
+	minRatio, maxRatio := mustSqrtRatio(t, MinTick), mustSqrtRatio(t, MaxTick)
+
+	// the liquidity of the full range at the price 1 is a bit more than the amounts,
+	// so it fits uint128 for the amounts of 2^127 and overflows it for the max uint128 amounts
+	half := new(big.Int).Rsh(maxUint128, 1)
+	got, err := GetLiquidityForAmounts(Q96, minRatio, maxRatio, half, half)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(half) < 0 || got.Cmp(maxUint128) > 0 {
+		t.Fatalf("full range liquidity %s", got)
+	}
+	if _, err = GetLiquidityForAmounts(Q96, minRatio, maxRatio, maxUint128, maxUint128); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("expected the uint128 overflow, got %v", err)
+	}
+
+	// the liquidity of a single tick range overflows uint128 before the amount does
+	lower, upper := mustSqrtRatio(t, 0), mustSqrtRatio(t, 1)
+	for name, fn := range map[string]func(a, b, amount *big.Int) (*big.Int, error){
+		"amount0": GetLiquidityForAmount0,
+		"amount1": GetLiquidityForAmount1,
+	} {
+		if _, err = fn(lower, upper, maxUint128); !errors.Is(err, ErrOverflow) {
+			t.Errorf("%s: expected the uint128 overflow, got %v", name, err)
+		}
+		// an empty range is the division by zero
+		if _, err = fn(lower, lower, big.NewInt(1)); !errors.Is(err, ErrOverflow) {
+			t.Errorf("%s: empty range: expected the overflow, got %v", name, err)
+		}
+	}
+
+	// the amounts of the max liquidity over the full range fit 256 bits
+	amount0, amount1, err := GetAmountsForLiquidity(Q96, minRatio, maxRatio, maxUint128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount0.Cmp(maxUint256) > 0 || amount1.Cmp(maxUint256) > 0 || amount0.Sign() <= 0 || amount1.Sign() <= 0 {
+		t.Fatalf("full range amounts %s and %s", amount0, amount1)
+	}
+
+	// the zero sqrt price has no token0 amount
+	if _, err = GetAmount0ForLiquidity(new(big.Int), maxRatio, big.NewInt(1)); !errors.Is(err, ErrInvalidPrice) {
+		t.Errorf("expected the invalid price error, got %v", err)
+	}
+
+	// the amounts of the liquidity got for the amounts never exceed them
+	for _, price := range []*big.Int{encodePriceSqrt(1, 1), encodePriceSqrt(2000, 1), encodePriceSqrt(1, 3000)} {
+		desired0, desired1 := big.NewInt(1e18), big.NewInt(3e9)
+		liquidity, err := GetLiquidityForAmounts(price, mustSqrtRatio(t, -887220), mustSqrtRatio(t, 887220), desired0, desired1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		amount0, amount1, err := GetAmountsForLiquidity(price, mustSqrtRatio(t, -887220), mustSqrtRatio(t, 887220), liquidity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amount0.Cmp(desired0) > 0 || amount1.Cmp(desired1) > 0 {
+			t.Errorf("price %s: liquidity %s holds %s and %s of %s and %s", price, liquidity, amount0, amount1, desired0, desired1)
+		}
+	}
+}
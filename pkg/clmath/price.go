@@ -0,0 +1,64 @@
+package clmath
+
+import (
+	"math/big"
+)
+
+// Precision is the mantissa precision of the big.Float prices
+const Precision = 256
+
+// decimalsFactor returns 10^(decimals1 - decimals0), the ratio between the raw and the human price
+func decimalsFactor(decimals0, decimals1 int) *big.Float {
+	pow := func(decimals int) *big.Float {
+		return new(big.Float).SetPrec(Precision).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	}
+	return new(big.Float).SetPrec(Precision).Quo(pow(decimals1), pow(decimals0))
+}
+
+// PriceToSqrtPriceX96 converts the human price of token0 in token1 to the pool sqrt price,
+// the result is rounded down and clamped to the sqrt price bounds
+func PriceToSqrtPriceX96(price *big.Float, decimals0, decimals1 int) (*big.Int, error) {
+	if price.Sign() <= 0 {
+		return nil, ErrInvalidPrice
+	}
+
+	raw := new(big.Float).SetPrec(Precision).Mul(price, decimalsFactor(decimals0, decimals1))
+	raw.Sqrt(raw)
+	raw.Mul(raw, new(big.Float).SetPrec(Precision).SetInt(Q96))
+
+	sqrtPriceX96, _ := raw.Int(nil)
+	if sqrtPriceX96.Cmp(MinSqrtRatio) < 0 {
+		return new(big.Int).Set(MinSqrtRatio), nil
+	}
+	if sqrtPriceX96.Cmp(MaxSqrtRatio) >= 0 {
+		return new(big.Int).Sub(MaxSqrtRatio, big.NewInt(1)), nil
+	}
+	return sqrtPriceX96, nil
+}
+
+// SqrtPriceX96ToPrice converts the pool sqrt price to the human price of token0 in token1
+func SqrtPriceX96ToPrice(sqrtPriceX96 *big.Int, decimals0, decimals1 int) *big.Float {
+	sqrtPrice := new(big.Float).SetPrec(Precision).SetInt(sqrtPriceX96)
+	sqrtPrice.Quo(sqrtPrice, new(big.Float).SetPrec(Precision).SetInt(Q96))
+
+	price := new(big.Float).SetPrec(Precision).Mul(sqrtPrice, sqrtPrice)
+	return price.Quo(price, decimalsFactor(decimals0, decimals1))
+}
+
+// PriceToTick converts the human price of token0 in token1 to the greatest tick whose price does not exceed it
+func PriceToTick(price *big.Float, decimals0, decimals1 int) (int, error) {
+	sqrtPriceX96, err := PriceToSqrtPriceX96(price, decimals0, decimals1)
+	if err != nil {
+		return 0, err
+	}
+	return GetTickAtSqrtRatio(sqrtPriceX96)
+}
+
+// TickToPrice converts the tick to the human price of token0 in token1
+func TickToPrice(tick int, decimals0, decimals1 int) (*big.Float, error) {
+	sqrtPriceX96, err := GetSqrtRatioAtTick(tick)
+	if err != nil {
+		return nil, err
+	}
+	return SqrtPriceX96ToPrice(sqrtPriceX96, decimals0, decimals1), nil
+}
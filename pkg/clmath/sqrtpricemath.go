@@ -0,0 +1,162 @@
+package clmath
+
+import (
+	"math/big"
+)
+
+// GetNextSqrtPriceFromAmount0RoundingUp gets the next sqrt price given a delta of token0,
+// always rounds up because in the exact output case (increasing price) the price must move at least far enough
+// to get the desired output amount, and in the exact input case (decreasing price) the price must move less
+// in order to not send too much output
+func GetNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if amount.Sign() == 0 {
+		return new(big.Int).Set(sqrtPX96), nil
+	}
+
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	product := new(big.Int).Mul(amount, sqrtPX96)
+
+	if add {
+		if product.Cmp(maxUint256) <= 0 {
+			denominator := new(big.Int).Add(numerator1, product)
+			if denominator.Cmp(maxUint256) <= 0 {
+				return MulDivRoundingUp(numerator1, sqrtPX96, denominator)
+			}
+		}
+		return divRoundingUp(numerator1, new(big.Int).Add(new(big.Int).Quo(numerator1, sqrtPX96), amount)), nil
+	}
+
+	if product.Cmp(maxUint256) > 0 || numerator1.Cmp(product) <= 0 {
+		return nil, ErrInvalidPrice
+	}
+	next, err := MulDivRoundingUp(numerator1, sqrtPX96, new(big.Int).Sub(numerator1, product))
+	if err != nil {
+		return nil, err
+	}
+	return toUint160(next)
+}
+
+// GetNextSqrtPriceFromAmount1RoundingDown gets the next sqrt price given a delta of token1,
+// always rounds down because in the exact output case (decreasing price) the price must move at least far enough
+// to get the desired output amount, and in the exact input case (increasing price) the price must move less
+// in order to not send too much output
+func GetNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if add {
+		quotient, err := MulDiv(amount, Q96, liquidity)
+		if err != nil {
+			return nil, err
+		}
+		return toUint160(quotient.Add(quotient, sqrtPX96))
+	}
+
+	quotient, err := MulDivRoundingUp(amount, Q96, liquidity)
+	if err != nil {
+		return nil, err
+	}
+	if sqrtPX96.Cmp(quotient) <= 0 {
+		return nil, ErrInvalidPrice
+	}
+	return quotient.Sub(sqrtPX96, quotient), nil
+}
+
+// GetNextSqrtPriceFromInput gets the next sqrt price given an input amount of token0 or token1
+func GetNextSqrtPriceFromInput(sqrtPX96, liquidity, amountIn *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPX96.Sign() <= 0 {
+		return nil, ErrInvalidPrice
+	}
+	if liquidity.Sign() <= 0 {
+		return nil, ErrInvalidLiquidity
+	}
+
+	// round to make sure that we don't pass the target price
+	if zeroForOne {
+		return GetNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountIn, true)
+	}
+	return GetNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountIn, true)
+}
+
+// GetNextSqrtPriceFromOutput gets the next sqrt price given an output amount of token0 or token1
+func GetNextSqrtPriceFromOutput(sqrtPX96, liquidity, amountOut *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPX96.Sign() <= 0 {
+		return nil, ErrInvalidPrice
+	}
+	if liquidity.Sign() <= 0 {
+		return nil, ErrInvalidLiquidity
+	}
+
+	// round to make sure that we pass the target price
+	if zeroForOne {
+		return GetNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountOut, false)
+	}
+	return GetNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountOut, false)
+}
+
+// GetAmount0Delta gets the amount0 delta between two prices,
+// liquidity / sqrt(lower) - liquidity / sqrt(upper), i.e. liquidity * (sqrt(upper) - sqrt(lower)) / (sqrt(upper) * sqrt(lower))
+func GetAmount0Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) (*big.Int, error) {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+	if sqrtRatioAX96.Sign() <= 0 {
+		return nil, ErrInvalidPrice
+	}
+
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	numerator2 := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+
+	if roundUp {
+		amount, err := MulDivRoundingUp(numerator1, numerator2, sqrtRatioBX96)
+		if err != nil {
+			return nil, err
+		}
+		return divRoundingUp(amount, sqrtRatioAX96), nil
+	}
+
+	amount, err := MulDiv(numerator1, numerator2, sqrtRatioBX96)
+	if err != nil {
+		return nil, err
+	}
+	return amount.Quo(amount, sqrtRatioAX96), nil
+}
+
+// GetAmount1Delta gets the amount1 delta between two prices, liquidity * (sqrt(upper) - sqrt(lower))
+func GetAmount1Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) (*big.Int, error) {
+	sqrtRatioAX96, sqrtRatioBX96 = sortRatios(sqrtRatioAX96, sqrtRatioBX96)
+
+	delta := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+	if roundUp {
+		return MulDivRoundingUp(liquidity, delta, Q96)
+	}
+	return MulDiv(liquidity, delta, Q96)
+}
+
+// GetAmount0DeltaSigned gets the signed token0 delta for the signed liquidity change,
+// a negative liquidity delta rounds down and a positive one rounds up
+func GetAmount0DeltaSigned(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int) (*big.Int, error) {
+	if liquidity.Sign() < 0 {
+		amount, err := GetAmount0Delta(sqrtRatioAX96, sqrtRatioBX96, new(big.Int).Neg(liquidity), false)
+		if err != nil {
+			return nil, err
+		}
+		return amount.Neg(amount), nil
+	}
+	return GetAmount0Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity, true)
+}
+
+// GetAmount1DeltaSigned gets the signed token1 delta for the signed liquidity change,
+// a negative liquidity delta rounds down and a positive one rounds up
+func GetAmount1DeltaSigned(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int) (*big.Int, error) {
+	if liquidity.Sign() < 0 {
+		amount, err := GetAmount1Delta(sqrtRatioAX96, sqrtRatioBX96, new(big.Int).Neg(liquidity), false)
+		if err != nil {
+			return nil, err
+		}
+		return amount.Neg(amount), nil
+	}
+	return GetAmount1Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity, true)
+}
+
+func sortRatios(sqrtRatioAX96, sqrtRatioBX96 *big.Int) (*big.Int, *big.Int) {
+	if sqrtRatioAX96.Cmp(sqrtRatioBX96) > 0 {
+		return sqrtRatioBX96, sqrtRatioAX96
+	}
+	return sqrtRatioAX96, sqrtRatioBX96
+}
@@ -0,0 +1,125 @@
+package clmath
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+var (
+	e18 = bigInt("1000000000000000000")
+	e17 = bigInt("100000000000000000")
+	// sqrtPrice121 is the sqrt price of 1.21 moved from the price 1 by 0.1 token1 into 1e18 liquidity
+	sqrtPrice121 = bigInt("87150978765690771352898345369")
+)
+
+// the values of the Solidity SqrtPriceMath library, see the v3-core SqrtPriceMath tests
+func TestGetNextSqrtPrice(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(sqrtPX96, liquidity, amount *big.Int, zeroForOne bool) (*big.Int, error)
+		in   *big.Int
+		zero bool
+		want string
+	}{
+		{"input token1", GetNextSqrtPriceFromInput, e17, false, "87150978765690771352898345369"},
+		{"input token0", GetNextSqrtPriceFromInput, e17, true, "72025602285694852357767227579"},
+		{"output token1", GetNextSqrtPriceFromOutput, e17, true, "71305346262837903834189555302"},
+		{"output token0", GetNextSqrtPriceFromOutput, e17, false, "88031291682515930659493278152"},
+		{"zero input", GetNextSqrtPriceFromInput, new(big.Int), true, Q96.String()},
+	}
+	for _, tt := range tests {
+		got, err := tt.fn(Q96, e18, tt.in, tt.zero)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got.Cmp(bigInt(tt.want)) != 0 {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetAmountDelta(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(a, b, liquidity *big.Int, roundUp bool) (*big.Int, error)
+		roundUp bool
+		want    string
+	}{
+		{"amount0 up", GetAmount0Delta, true, "90909090909090910"},
+		{"amount0 down", GetAmount0Delta, false, "90909090909090909"},
+		{"amount1 up", GetAmount1Delta, true, "100000000000000000"},
+		{"amount1 down", GetAmount1Delta, false, "99999999999999999"},
+	}
+	for _, tt := range tests {
+		// the order of the prices does not matter
+		for _, prices := range [][2]*big.Int{{Q96, sqrtPrice121}, {sqrtPrice121, Q96}} {
+			got, err := tt.fn(prices[0], prices[1], e18, tt.roundUp)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if got.Cmp(bigInt(tt.want)) != 0 {
+				t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+			}
+		}
+	}
+
+	for _, fn := range []func(a, b, liquidity *big.Int, roundUp bool) (*big.Int, error){GetAmount0Delta, GetAmount1Delta} {
+		if got, _ := fn(Q96, sqrtPrice121, new(big.Int), true); got.Sign() != 0 {
+			t.Errorf("zero liquidity: got %s", got)
+		}
+		if got, _ := fn(Q96, Q96, e18, true); got.Sign() != 0 {
+			t.Errorf("equal prices: got %s", got)
+		}
+	}
+}
+
+// the amounts are the exact L * 2^96 * (B - A) / (A * B) and L * (B - A) / 2^96 rounded in the requested direction
+func TestGetAmountDeltaRounding(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	span := new(big.Int).Sub(MaxSqrtRatio, MinSqrtRatio)
+
+	for i := 0; i < 5000; i++ {
+		a := new(big.Int).Add(MinSqrtRatio, new(big.Int).Rand(rnd, span))
+		b := new(big.Int).Add(MinSqrtRatio, new(big.Int).Rand(rnd, span))
+		if i%3 == 0 {
+			// the close prices of the narrow ranges
+			b = new(big.Int).Add(a, new(big.Int).Rand(rnd, big.NewInt(1<<40)))
+		}
+		liquidity := new(big.Int).Rand(rnd, new(big.Int).Lsh(big.NewInt(1), 128))
+		lo, hi := sortRatios(a, b)
+		diff := new(big.Int).Sub(hi, lo)
+
+		exact0 := new(big.Rat).SetFrac(
+			new(big.Int).Mul(new(big.Int).Mul(liquidity, Q96), diff),
+			new(big.Int).Mul(lo, hi))
+		exact1 := new(big.Rat).SetFrac(new(big.Int).Mul(liquidity, diff), Q96)
+
+		for _, c := range []struct {
+			name  string
+			fn    func(a, b, liquidity *big.Int, roundUp bool) (*big.Int, error)
+			exact *big.Rat
+		}{
+			{"amount0", GetAmount0Delta, exact0},
+			{"amount1", GetAmount1Delta, exact1},
+		} {
+			floor := new(big.Int).Quo(c.exact.Num(), c.exact.Denom())
+			ceil := new(big.Int).Set(floor)
+			if !c.exact.IsInt() {
+				ceil.Add(ceil, big.NewInt(1))
+			}
+
+			down, err := c.fn(a, b, liquidity, false)
+			if err != nil {
+				t.Fatalf("%s of %s, %s, %s: %v", c.name, a, b, liquidity, err)
+			}
+			up, err := c.fn(a, b, liquidity, true)
+			if err != nil {
+				t.Fatalf("%s of %s, %s, %s: %v", c.name, a, b, liquidity, err)
+			}
+			if down.Cmp(floor) != 0 || up.Cmp(ceil) != 0 {
+				t.Fatalf("%s of %s, %s, %s: got %s..%s, want %s..%s", c.name, a, b, liquidity, down, up, floor, ceil)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package clmath
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrTickOutOfRange      = errors.New("tick out of range")
+	ErrSqrtPriceOutOfRange = errors.New("sqrt price out of range")
+)
+
+const (
+	// MinTick is the minimum tick that may be passed to GetSqrtRatioAtTick computed from log base 1.0001 of 2**-128
+	MinTick = -887272
+	// MaxTick is the maximum tick that may be passed to GetSqrtRatioAtTick computed from log base 1.0001 of 2**128
+	MaxTick = -MinTick
+)
+
+var (
+	// MinSqrtRatio is the minimum value that can be returned from GetSqrtRatioAtTick
+	MinSqrtRatio = big.NewInt(4295128739)
+	// MaxSqrtRatio is the maximum value that can be returned from GetSqrtRatioAtTick
+	MaxSqrtRatio, _ = new(big.Int).SetString("1461446703485210103287273052203988822378723970342", 10)
+)
+
+var (
+	ratioTick1     = hexInt("fffcb933bd6fad37aa2d162d1a594001")
+	ratioTickZero  = new(big.Int).Lsh(big.NewInt(1), 128)
+	ratioTickPow2s = []*big.Int{
+		hexInt("fff97272373d413259a46990580e213a"),
+		hexInt("fff2e50f5f656932ef12357cf3c7fdcc"),
+		hexInt("ffe5caca7e10e4e61c3624eaa0941cd0"),
+		hexInt("ffcb9843d60f6159c9db58835c926644"),
+		hexInt("ff973b41fa98c081472e6896dfb254c0"),
+		hexInt("ff2ea16466c96a3843ec78b326b52861"),
+		hexInt("fe5dee046a99a2a811c461f1969c3053"),
+		hexInt("fcbe86c7900a88aedcffc83b479aa3a4"),
+		hexInt("f987a7253ac413176f2b074cf7815e54"),
+		hexInt("f3392b0822b70005940c7a398e4b70f3"),
+		hexInt("e7159475a2c29b7443b29c7fa6e889d9"),
+		hexInt("d097f3bdfd2022b8845ad8f792aa5825"),
+		hexInt("a9f746462d870fdf8a65dc1f90e061e5"),
+		hexInt("70d869a156d2a1b890bb3df62baf32f7"),
+		hexInt("31be135f97d08fd981231505542fcfa6"),
+		hexInt("9aa508b5b7a84e1c677de54f3e99bc9"),
+		hexInt("5d6af8dedb81196699c329225ee604"),
+		hexInt("2216e584f5fa1ea926041bedfe98"),
+		hexInt("48a170391f7dc42444e8fa2"),
+	}
+
+	log2ToLogSqrt10001, _ = new(big.Int).SetString("255738958999603826347141", 10)
+	tickLowOffset, _      = new(big.Int).SetString("3402992956809132418596140100660247210", 10)
+	tickHighOffset, _     = new(big.Int).SetString("291339464771989622907027621153398088495", 10)
+	q32                   = new(big.Int).Lsh(big.NewInt(1), 32)
+)
+
+func hexInt(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("clmath: invalid hex constant " + s)
+	}
+	return v
+}
+
+// GetSqrtRatioAtTick calculates sqrt(1.0001^tick) * 2^96
+func GetSqrtRatioAtTick(tick int) (*big.Int, error) {
+	absTick := tick
+	if absTick < 0 {
+		absTick = -absTick
+	}
+	if absTick > MaxTick {
+		return nil, ErrTickOutOfRange
+	}
+
+	ratio := new(big.Int).Set(ratioTickZero)
+	if absTick&0x1 != 0 {
+		ratio.Set(ratioTick1)
+	}
+	for i, multiplier := range ratioTickPow2s {
+		if absTick&(0x2<<i) != 0 {
+			ratio.Mul(ratio, multiplier).Rsh(ratio, 128)
+		}
+	}
+
+	if tick > 0 {
+		ratio.Quo(maxUint256, ratio)
+	}
+
+	// this divides by 1<<32 rounding up to go from a Q128.128 to a Q128.96
+	remainder := new(big.Int).Mod(ratio, q32)
+	ratio.Rsh(ratio, 32)
+	if remainder.Sign() != 0 {
+		ratio.Add(ratio, big.NewInt(1))
+	}
+
+	return ratio, nil
+}
+
+// GetTickAtSqrtRatio calculates the greatest tick value such that GetSqrtRatioAtTick(tick) <= sqrtPriceX96
+func GetTickAtSqrtRatio(sqrtPriceX96 *big.Int) (int, error) {
+	if sqrtPriceX96.Cmp(MinSqrtRatio) < 0 || sqrtPriceX96.Cmp(MaxSqrtRatio) >= 0 {
+		return 0, ErrSqrtPriceOutOfRange
+	}
+
+	ratio := new(big.Int).Lsh(sqrtPriceX96, 32)
+	msb := ratio.BitLen() - 1
+
+	r := new(big.Int)
+	if msb >= 128 {
+		r.Rsh(ratio, uint(msb-127))
+	} else {
+		r.Lsh(ratio, uint(127-msb))
+	}
+
+	log2 := new(big.Int).Lsh(big.NewInt(int64(msb-128)), 64)
+	for i := 0; i < 14; i++ {
+		r.Mul(r, r).Rsh(r, 127)
+		f := new(big.Int).Rsh(r, 128)
+		log2.Or(log2, new(big.Int).Lsh(f, uint(63-i)))
+		r.Rsh(r, uint(f.Uint64()))
+	}
+
+	logSqrt10001 := new(big.Int).Mul(log2, log2ToLogSqrt10001)
+
+	tickLow := int(new(big.Int).Rsh(new(big.Int).Sub(logSqrt10001, tickLowOffset), 128).Int64())
+	tickHigh := int(new(big.Int).Rsh(new(big.Int).Add(logSqrt10001, tickHighOffset), 128).Int64())
+
+	if tickLow == tickHigh {
+		return tickLow, nil
+	}
+
+	sqrtRatioAtTickHigh, err := GetSqrtRatioAtTick(tickHigh)
+	if err != nil {
+		return 0, err
+	}
+	if sqrtRatioAtTickHigh.Cmp(sqrtPriceX96) <= 0 {
+		return tickHigh, nil
+	}
+	return tickLow, nil
+}
+
+// MinUsableTick returns the minimum tick aligned to the tick spacing
+func MinUsableTick(tickSpacing int) int {
+	return -(MaxTick / tickSpacing) * tickSpacing
+}
+
+// MaxUsableTick returns the maximum tick aligned to the tick spacing
+func MaxUsableTick(tickSpacing int) int {
+	return (MaxTick / tickSpacing) * tickSpacing
+}
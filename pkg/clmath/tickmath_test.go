@@ -0,0 +1,138 @@
+package clmath
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func bigInt(s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid test integer " + s)
+	}
+	return v
+}
+
+// the values of the Solidity TickMath library, see the v3-core TickMath tests
+func TestGetSqrtRatioAtTick(t *testing.T) {
+	tests := []struct {
+		tick int
+		want string
+	}{
+		{MinTick, "4295128739"},
+		{MinTick + 1, "4295343490"},
+		{0, "79228162514264337593543950336"},
+		{MaxTick - 1, "1461373636630004318706518188784493106690254656249"},
+		{MaxTick, "1461446703485210103287273052203988822378723970342"},
+	}
+	for _, tt := range tests {
+		got, err := GetSqrtRatioAtTick(tt.tick)
+		if err != nil {
+			t.Fatalf("tick %d: %v", tt.tick, err)
+		}
+		if got.Cmp(bigInt(tt.want)) != 0 {
+			t.Errorf("tick %d: got %s, want %s", tt.tick, got, tt.want)
+		}
+	}
+
+	if MinSqrtRatio.Cmp(mustSqrtRatio(t, MinTick)) != 0 || MaxSqrtRatio.Cmp(mustSqrtRatio(t, MaxTick)) != 0 {
+		t.Errorf("the sqrt ratio bounds are not the ratios of the tick bounds")
+	}
+	for _, tick := range []int{MinTick - 1, MaxTick + 1} {
+		if _, err := GetSqrtRatioAtTick(tick); !errors.Is(err, ErrTickOutOfRange) {
+			t.Errorf("tick %d: expected the out of range error, got %v", tick, err)
+		}
+	}
+}
+
+// the ratios are off the exact sqrt(1.0001^tick) * 2^96 by at most 1/100th of a bip
+func TestGetSqrtRatioAtTickPrecision(t *testing.T) {
+	for _, absTick := range []int{50, 100, 250, 500, 1000, 2500, 3000, 4000, 5000, 50000, 150000, 250000, 500000, 738203} {
+		for _, tick := range []int{-absTick, absTick} {
+			got := new(big.Float).SetPrec(512).SetInt(mustSqrtRatio(t, tick))
+
+			exact := new(big.Float).SetPrec(512).SetInt64(1)
+			base := new(big.Float).SetPrec(512).Quo(big.NewFloat(10001).SetPrec(512), big.NewFloat(10000).SetPrec(512))
+			for i := 0; i < absTick; i++ {
+				exact.Mul(exact, base)
+			}
+			if tick < 0 {
+				exact.Quo(new(big.Float).SetPrec(512).SetInt64(1), exact)
+			}
+			exact.Sqrt(exact).Mul(exact, new(big.Float).SetPrec(512).SetInt(Q96))
+
+			diff := new(big.Float).SetPrec(512).Sub(got, exact)
+			diff.Abs(diff).Quo(diff, exact)
+			if diff.Cmp(big.NewFloat(0.000001)) > 0 {
+				t.Errorf("tick %d: %s is off %s by %s", tick, got.Text('f', 0), exact.Text('f', 0), diff.Text('g', 3))
+			}
+		}
+	}
+}
+
+func TestGetTickAtSqrtRatio(t *testing.T) {
+	tests := []struct {
+		ratio *big.Int
+		want  int
+	}{
+		{MinSqrtRatio, MinTick},
+		{bigInt("4295343490"), MinTick + 1},
+		{Q96, 0},
+		{bigInt("1461373636630004318706518188784493106690254656249"), MaxTick - 1},
+		{new(big.Int).Sub(MaxSqrtRatio, big.NewInt(1)), MaxTick - 1},
+	}
+	for _, tt := range tests {
+		got, err := GetTickAtSqrtRatio(tt.ratio)
+		if err != nil {
+			t.Fatalf("ratio %s: %v", tt.ratio, err)
+		}
+		if got != tt.want {
+			t.Errorf("ratio %s: got %d, want %d", tt.ratio, got, tt.want)
+		}
+	}
+
+	for _, ratio := range []*big.Int{new(big.Int).Sub(MinSqrtRatio, big.NewInt(1)), MaxSqrtRatio} {
+		if _, err := GetTickAtSqrtRatio(ratio); !errors.Is(err, ErrSqrtPriceOutOfRange) {
+			t.Errorf("ratio %s: expected the out of range error, got %v", ratio, err)
+		}
+	}
+}
+
+// the tick of a ratio is the greatest tick whose ratio is not above it
+func TestTickRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	ticks := []int{MinTick, MinTick + 1, -1, 0, 1, MaxTick - 2, MaxTick - 1}
+	for i := 0; i < 2000; i++ {
+		ticks = append(ticks, rnd.Intn(MaxTick-MinTick)+MinTick)
+	}
+
+	for _, tick := range ticks {
+		ratio := mustSqrtRatio(t, tick)
+		next := mustSqrtRatio(t, tick+1)
+		if ratio.Cmp(next) >= 0 {
+			t.Fatalf("tick %d: the ratio %s is not below the next %s", tick, ratio, next)
+		}
+
+		inside := new(big.Int).Add(ratio, new(big.Int).Rand(rnd, new(big.Int).Sub(next, ratio)))
+		for _, r := range []*big.Int{ratio, inside, new(big.Int).Sub(next, big.NewInt(1))} {
+			got, err := GetTickAtSqrtRatio(r)
+			if err != nil {
+				t.Fatalf("ratio %s: %v", r, err)
+			}
+			if got != tick {
+				t.Fatalf("ratio %s in [%s, %s): got tick %d, want %d", r, ratio, next, got, tick)
+			}
+		}
+	}
+}
+
+func mustSqrtRatio(t *testing.T, tick int) *big.Int {
+	t.Helper()
+	r, err := GetSqrtRatioAtTick(tick)
+	if err != nil {
+		t.Fatalf("tick %d: %v", tick, err)
+	}
+	return r
+}
@@ -2,17 +2,186 @@ package erc20
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"strings"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
+var (
+	ErrTransactionReverted = errors.New("transaction reverted")
+)
+
+const erc20ABI = `[
+	{"type":"function","name":"balanceOf","stateMutability":"view",
+	 "inputs":[{"name":"account","type":"address"}],
+	 "outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"allowance","stateMutability":"view",
+	 "inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],
+	 "outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"approve","stateMutability":"nonpayable",
+	 "inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],
+	 "outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[],
+	 "outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"symbol","stateMutability":"view","inputs":[],
+	 "outputs":[{"name":"","type":"string"}]}
+]`
+
+var contractABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(erc20ABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// Backend is the chain access of the manager
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ethereum.ChainStateReader
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// Signer signs the transactions of the token holder
+type Signer interface {
+	Address() common.Address
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// NewTransactor creates the transaction options sending the transactions signed by the signer
+func NewTransactor(ctx context.Context, signer Signer, chainID *big.Int) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: signer.Address(),
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != signer.Address() {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(ctx, tx, chainID)
+		},
+		Context: ctx,
+	}
+}
+
 type Manager interface {
 	GetBalance(ctx context.Context, address common.Address) (*big.Int, error)
 	GetTokenBalance(ctx context.Context, address, tokenAddress common.Address) (*big.Int, error)
+	GetAllowance(ctx context.Context, owner, spender, tokenAddress common.Address) (*big.Int, error)
+	Approve(ctx context.Context, signer Signer, spender, tokenAddress common.Address, amount *big.Int) error
+	EnsureAllowance(ctx context.Context, signer Signer, spender, tokenAddress common.Address, amount *big.Int) error
+	GetDecimals(ctx context.Context, tokenAddress common.Address) (int, error)
+	GetSymbol(ctx context.Context, tokenAddress common.Address) (string, error)
 }
 
 type manager struct {
-	cli ethclient.Client
+	cli Backend
+}
+
+func NewManager(cli Backend) Manager {
+	return &manager{cli: cli}
+}
+
+// GetBalance gets the native coin balance
+func (m *manager) GetBalance(ctx context.Context, address common.Address) (*big.Int, error) {
+	balance, err := m.cli.BalanceAt(ctx, address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("balance at: %w", err)
+	}
+	return balance, nil
+}
+
+// GetTokenBalance gets the token balance
+func (m *manager) GetTokenBalance(ctx context.Context, address, tokenAddress common.Address) (*big.Int, error) {
+	out, err := m.call(ctx, tokenAddress, "balanceOf", address)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].(*big.Int), nil
+}
+
+// GetAllowance gets the amount of the token the spender is allowed to withdraw from the owner
+func (m *manager) GetAllowance(ctx context.Context, owner, spender, tokenAddress common.Address) (*big.Int, error) {
+	out, err := m.call(ctx, tokenAddress, "allowance", owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].(*big.Int), nil
+}
+
+// Approve allows the spender to withdraw the amount of the token from the signer
+func (m *manager) Approve(ctx context.Context, signer Signer, spender, tokenAddress common.Address, amount *big.Int) error {
+	chainID, err := m.cli.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("get chain id: %w", err)
+	}
+
+	tx, err := m.contract(tokenAddress).Transact(NewTransactor(ctx, signer, chainID), "approve", spender, amount)
+	if err != nil {
+		return fmt.Errorf("send approve transaction: %w", err)
+	}
+
+	receipt, err := bind.WaitMined(ctx, m.cli, tx)
+	if err != nil {
+		return fmt.Errorf("wait approve transaction %s: %w", tx.Hash(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return fmt.Errorf("approve transaction %s: %w", tx.Hash(), ErrTransactionReverted)
+	}
+
+	return nil
+}
+
+// EnsureAllowance approves the amount if the current allowance of the spender is lower,
+// a non-zero allowance is reset first as the tokens like USDT revert changing it
+func (m *manager) EnsureAllowance(ctx context.Context, signer Signer, spender, tokenAddress common.Address, amount *big.Int) error {
+	allowance, err := m.GetAllowance(ctx, signer.Address(), spender, tokenAddress)
+	if err != nil {
+		return err
+	}
+	if allowance.Cmp(amount) >= 0 {
+		return nil
+	}
+	if allowance.Sign() > 0 {
+		if err = m.Approve(ctx, signer, spender, tokenAddress, new(big.Int)); err != nil {
+			return fmt.Errorf("reset allowance: %w", err)
+		}
+	}
+	return m.Approve(ctx, signer, spender, tokenAddress, amount)
+}
+
+// GetDecimals gets the token decimals
+func (m *manager) GetDecimals(ctx context.Context, tokenAddress common.Address) (int, error) {
+	out, err := m.call(ctx, tokenAddress, "decimals")
+	if err != nil {
+		return 0, err
+	}
+	return int(out[0].(uint8)), nil
+}
+
+// GetSymbol gets the token symbol
+func (m *manager) GetSymbol(ctx context.Context, tokenAddress common.Address) (string, error) {
+	out, err := m.call(ctx, tokenAddress, "symbol")
+	if err != nil {
+		return "", err
+	}
+	return out[0].(string), nil
+}
+
+func (m *manager) contract(tokenAddress common.Address) *bind.BoundContract {
+	return bind.NewBoundContract(tokenAddress, contractABI, m.cli, m.cli, m.cli)
+}
+
+func (m *manager) call(ctx context.Context, tokenAddress common.Address, method string, params ...interface{}) ([]interface{}, error) {
+	var out []interface{}
+	if err := m.contract(tokenAddress).Call(&bind.CallOpts{Context: ctx}, &out, method, params...); err != nil {
+		return nil, fmt.Errorf("token %s: %s: %w", tokenAddress, method, err)
+	}
+	return out, nil
 }
@@ -0,0 +1,256 @@
+package erc20
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	chainID      = big.NewInt(1337)
+	tokenAddress = common.HexToAddress("0x5000000000000000000000000000000000000001")
+	spender      = common.HexToAddress("0x5000000000000000000000000000000000000002")
+)
+
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s *keySigner) Address() common.Address { return crypto.PubkeyToAddress(s.key.PublicKey) }
+
+func (s *keySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// tokenBackend stands in for the chain with a single token contract keeping the allowances of the spender
+type tokenBackend struct {
+	allowance *big.Int
+	// revert reverts the approve transactions of the amount
+	revert func(amount *big.Int) bool
+	// sendErr fails sending the transactions when set
+	sendErr error
+
+	approved []*big.Int
+	receipts map[common.Hash]*types.Receipt
+}
+
+func newTokenBackend(allowance int64) *tokenBackend {
+	return &tokenBackend{
+		allowance: big.NewInt(allowance),
+		revert:    func(*big.Int) bool { return false },
+		receipts:  map[common.Hash]*types.Receipt{},
+	}
+}
+
+func (b *tokenBackend) CallContract(_ context.Context, call ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	if *call.To != tokenAddress {
+		return nil, nil
+	}
+	method, err := contractABI.MethodById(call.Data)
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "allowance":
+		return method.Outputs.Pack(b.allowance)
+	case "balanceOf":
+		return method.Outputs.Pack(big.NewInt(5_000_000))
+	case "decimals":
+		return method.Outputs.Pack(uint8(6))
+	case "symbol":
+		return method.Outputs.Pack("USDC")
+	}
+	return nil, errors.New("execution reverted")
+}
+
+func (b *tokenBackend) SendTransaction(_ context.Context, tx *types.Transaction) error {
+	if b.sendErr != nil {
+		return b.sendErr
+	}
+	method, err := contractABI.MethodById(tx.Data())
+	if err != nil || method.Name != "approve" {
+		return errors.New("unexpected transaction")
+	}
+	args, err := method.Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		return err
+	}
+	if args[0].(common.Address) != spender {
+		return errors.New("unexpected spender")
+	}
+
+	amount := args[1].(*big.Int)
+	b.approved = append(b.approved, amount)
+	status := types.ReceiptStatusSuccessful
+	if b.revert(amount) {
+		status = types.ReceiptStatusFailed
+	} else {
+		b.allowance = amount
+	}
+	b.receipts[tx.Hash()] = &types.Receipt{Status: status, TxHash: tx.Hash()}
+	return nil
+}
+
+func (b *tokenBackend) TransactionReceipt(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	if r, ok := b.receipts[hash]; ok {
+		return r, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (b *tokenBackend) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (b *tokenBackend) PendingCodeAt(context.Context, common.Address) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (b *tokenBackend) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(1e9)}, nil
+}
+
+func (b *tokenBackend) PendingNonceAt(context.Context, common.Address) (uint64, error) {
+	return uint64(len(b.approved)), nil
+}
+
+func (b *tokenBackend) SuggestGasPrice(context.Context) (*big.Int, error) {
+	return big.NewInt(1e9), nil
+}
+
+func (b *tokenBackend) SuggestGasTipCap(context.Context) (*big.Int, error) { return big.NewInt(1), nil }
+
+func (b *tokenBackend) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 50_000, nil
+}
+
+func (b *tokenBackend) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, nil
+}
+
+func (b *tokenBackend) SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("subscriptions are not supported")
+}
+
+func (b *tokenBackend) BalanceAt(context.Context, common.Address, *big.Int) (*big.Int, error) {
+	return big.NewInt(1e18), nil
+}
+
+func (b *tokenBackend) StorageAt(context.Context, common.Address, common.Hash, *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (b *tokenBackend) NonceAt(context.Context, common.Address, *big.Int) (uint64, error) {
+	return uint64(len(b.approved)), nil
+}
+
+func (b *tokenBackend) ChainID(context.Context) (*big.Int, error) { return chainID, nil }
+
+func newTestSigner(t *testing.T) Signer {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &keySigner{key: key}
+}
+
+func TestEnsureAllowance(t *testing.T) {
+	ctx := context.Background()
+	signer := newTestSigner(t)
+
+	tests := []struct {
+		name      string
+		allowance int64
+		amount    int64
+		approved  []int64
+	}{
+		{"sufficient", 1000, 1000, nil},
+		{"more than sufficient", 2000, 1000, nil},
+		{"zero", 0, 1000, []int64{1000}},
+		// the non-zero allowance is reset before it is raised
+		{"too small", 999, 1000, []int64{0, 1000}},
+		{"zero amount", 0, 0, nil},
+	}
+	for _, tt := range tests {
+		b := newTokenBackend(tt.allowance)
+		if err := NewManager(b).EnsureAllowance(ctx, signer, spender, tokenAddress, big.NewInt(tt.amount)); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(b.approved) != len(tt.approved) {
+			t.Fatalf("%s: approved %v, want %v", tt.name, b.approved, tt.approved)
+		}
+		for i, amount := range tt.approved {
+			if b.approved[i].Int64() != amount {
+				t.Errorf("%s: approved %v, want %v", tt.name, b.approved, tt.approved)
+			}
+		}
+		if b.allowance.Cmp(big.NewInt(tt.amount)) < 0 {
+			t.Errorf("%s: allowance %s after ensuring %d", tt.name, b.allowance, tt.amount)
+		}
+	}
+}
+
+func TestEnsureAllowanceErrors(t *testing.T) {
+	ctx := context.Background()
+	signer := newTestSigner(t)
+
+	// the reverted reset stops the approval of the new amount
+	b := newTokenBackend(999)
+	b.revert = func(amount *big.Int) bool { return amount.Sign() == 0 }
+	err := NewManager(b).EnsureAllowance(ctx, signer, spender, tokenAddress, big.NewInt(1000))
+	if !errors.Is(err, ErrTransactionReverted) {
+		t.Fatalf("expected the reverted reset error, got %v", err)
+	}
+	if len(b.approved) != 1 || b.allowance.Int64() != 999 {
+		t.Fatalf("approved %v to the allowance %s after the reverted reset", b.approved, b.allowance)
+	}
+
+	// the reset transaction that is not sent is not followed by the approval either
+	b = newTokenBackend(999)
+	b.sendErr = errors.New("nonce too low")
+	err = NewManager(b).EnsureAllowance(ctx, signer, spender, tokenAddress, big.NewInt(1000))
+	if !errors.Is(err, b.sendErr) {
+		t.Fatalf("expected the send error, got %v", err)
+	}
+	if len(b.approved) != 0 {
+		t.Fatalf("approved %v after the failed reset", b.approved)
+	}
+
+	// the reverted approval of a zero allowance is reported
+	b = newTokenBackend(0)
+	b.revert = func(amount *big.Int) bool { return amount.Sign() > 0 }
+	err = NewManager(b).EnsureAllowance(ctx, signer, spender, tokenAddress, big.NewInt(1000))
+	if !errors.Is(err, ErrTransactionReverted) {
+		t.Fatalf("expected the reverted approval error, got %v", err)
+	}
+}
+
+func TestTokenInfo(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newTokenBackend(0))
+
+	if balance, err := m.GetTokenBalance(ctx, spender, tokenAddress); err != nil || balance.Int64() != 5_000_000 {
+		t.Errorf("token balance %s, %v", balance, err)
+	}
+	if balance, err := m.GetBalance(ctx, spender); err != nil || balance.Int64() != 1e18 {
+		t.Errorf("balance %s, %v", balance, err)
+	}
+	if decimals, err := m.GetDecimals(ctx, tokenAddress); err != nil || decimals != 6 {
+		t.Errorf("decimals %d, %v", decimals, err)
+	}
+	if symbol, err := m.GetSymbol(ctx, tokenAddress); err != nil || symbol != "USDC" {
+		t.Errorf("symbol %q, %v", symbol, err)
+	}
+	// the call of an account without the contract returns no data
+	if _, err := m.GetDecimals(ctx, spender); err == nil {
+		t.Error("expected the empty reply error")
+	}
+}
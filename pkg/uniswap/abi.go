@@ -0,0 +1,152 @@
+package uniswap
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+const factoryABI = `[
+	{"type":"function","name":"getPool","stateMutability":"view",
+	 "inputs":[{"name":"tokenA","type":"address"},{"name":"tokenB","type":"address"},{"name":"fee","type":"uint24"}],
+	 "outputs":[{"name":"pool","type":"address"}]}
+]`
+
+const poolABI = `[
+	{"type":"function","name":"slot0","stateMutability":"view","inputs":[],
+	 "outputs":[
+		{"name":"sqrtPriceX96","type":"uint160"},
+		{"name":"tick","type":"int24"},
+		{"name":"observationIndex","type":"uint16"},
+		{"name":"observationCardinality","type":"uint16"},
+		{"name":"observationCardinalityNext","type":"uint16"},
+		{"name":"feeProtocol","type":"uint8"},
+		{"name":"unlocked","type":"bool"}]},
+	{"type":"function","name":"liquidity","stateMutability":"view","inputs":[],
+	 "outputs":[{"name":"","type":"uint128"}]},
+	{"type":"function","name":"token0","stateMutability":"view","inputs":[],
+	 "outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"token1","stateMutability":"view","inputs":[],
+	 "outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"fee","stateMutability":"view","inputs":[],
+	 "outputs":[{"name":"","type":"uint24"}]},
+	{"type":"function","name":"tickSpacing","stateMutability":"view","inputs":[],
+	 "outputs":[{"name":"","type":"int24"}]},
+	{"type":"function","name":"observe","stateMutability":"view",
+	 "inputs":[{"name":"secondsAgos","type":"uint32[]"}],
+	 "outputs":[
+		{"name":"tickCumulatives","type":"int56[]"},
+		{"name":"secondsPerLiquidityCumulativeX128s","type":"uint160[]"}]},
+	{"type":"event","name":"Swap","anonymous":false,"inputs":[
+		{"name":"sender","type":"address","indexed":true},
+		{"name":"recipient","type":"address","indexed":true},
+		{"name":"amount0","type":"int256","indexed":false},
+		{"name":"amount1","type":"int256","indexed":false},
+		{"name":"sqrtPriceX96","type":"uint160","indexed":false},
+		{"name":"liquidity","type":"uint128","indexed":false},
+		{"name":"tick","type":"int24","indexed":false}]}
+]`
+
+const positionManagerABI = `[
+	{"type":"function","name":"mint","stateMutability":"payable",
+	 "inputs":[{"name":"params","type":"tuple","components":[
+		{"name":"token0","type":"address"},
+		{"name":"token1","type":"address"},
+		{"name":"fee","type":"uint24"},
+		{"name":"tickLower","type":"int24"},
+		{"name":"tickUpper","type":"int24"},
+		{"name":"amount0Desired","type":"uint256"},
+		{"name":"amount1Desired","type":"uint256"},
+		{"name":"amount0Min","type":"uint256"},
+		{"name":"amount1Min","type":"uint256"},
+		{"name":"recipient","type":"address"},
+		{"name":"deadline","type":"uint256"}]}],
+	 "outputs":[
+		{"name":"tokenId","type":"uint256"},
+		{"name":"liquidity","type":"uint128"},
+		{"name":"amount0","type":"uint256"},
+		{"name":"amount1","type":"uint256"}]},
+	{"type":"function","name":"decreaseLiquidity","stateMutability":"payable",
+	 "inputs":[{"name":"params","type":"tuple","components":[
+		{"name":"tokenId","type":"uint256"},
+		{"name":"liquidity","type":"uint128"},
+		{"name":"amount0Min","type":"uint256"},
+		{"name":"amount1Min","type":"uint256"},
+		{"name":"deadline","type":"uint256"}]}],
+	 "outputs":[{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"}]},
+	{"type":"function","name":"collect","stateMutability":"payable",
+	 "inputs":[{"name":"params","type":"tuple","components":[
+		{"name":"tokenId","type":"uint256"},
+		{"name":"recipient","type":"address"},
+		{"name":"amount0Max","type":"uint128"},
+		{"name":"amount1Max","type":"uint128"}]}],
+	 "outputs":[{"name":"amount0","type":"uint256"},{"name":"amount1","type":"uint256"}]},
+	{"type":"function","name":"positions","stateMutability":"view",
+	 "inputs":[{"name":"tokenId","type":"uint256"}],
+	 "outputs":[
+		{"name":"nonce","type":"uint96"},
+		{"name":"operator","type":"address"},
+		{"name":"token0","type":"address"},
+		{"name":"token1","type":"address"},
+		{"name":"fee","type":"uint24"},
+		{"name":"tickLower","type":"int24"},
+		{"name":"tickUpper","type":"int24"},
+		{"name":"liquidity","type":"uint128"},
+		{"name":"feeGrowthInside0LastX128","type":"uint256"},
+		{"name":"feeGrowthInside1LastX128","type":"uint256"},
+		{"name":"tokensOwed0","type":"uint128"},
+		{"name":"tokensOwed1","type":"uint128"}]},
+	{"type":"event","name":"IncreaseLiquidity","anonymous":false,"inputs":[
+		{"name":"tokenId","type":"uint256","indexed":true},
+		{"name":"liquidity","type":"uint128","indexed":false},
+		{"name":"amount0","type":"uint256","indexed":false},
+		{"name":"amount1","type":"uint256","indexed":false}]},
+	{"type":"event","name":"DecreaseLiquidity","anonymous":false,"inputs":[
+		{"name":"tokenId","type":"uint256","indexed":true},
+		{"name":"liquidity","type":"uint128","indexed":false},
+		{"name":"amount0","type":"uint256","indexed":false},
+		{"name":"amount1","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Collect","anonymous":false,"inputs":[
+		{"name":"tokenId","type":"uint256","indexed":true},
+		{"name":"recipient","type":"address","indexed":false},
+		{"name":"amount0","type":"uint256","indexed":false},
+		{"name":"amount1","type":"uint256","indexed":false}]}
+]`
+
+const swapRouterABI = `[
+	{"type":"function","name":"exactInputSingle","stateMutability":"payable",
+	 "inputs":[{"name":"params","type":"tuple","components":[
+		{"name":"tokenIn","type":"address"},
+		{"name":"tokenOut","type":"address"},
+		{"name":"fee","type":"uint24"},
+		{"name":"recipient","type":"address"},
+		{"name":"amountIn","type":"uint256"},
+		{"name":"amountOutMinimum","type":"uint256"},
+		{"name":"sqrtPriceLimitX96","type":"uint160"}]}],
+	 "outputs":[{"name":"amountOut","type":"uint256"}]},
+	{"type":"function","name":"exactOutputSingle","stateMutability":"payable",
+	 "inputs":[{"name":"params","type":"tuple","components":[
+		{"name":"tokenIn","type":"address"},
+		{"name":"tokenOut","type":"address"},
+		{"name":"fee","type":"uint24"},
+		{"name":"recipient","type":"address"},
+		{"name":"amountOut","type":"uint256"},
+		{"name":"amountInMaximum","type":"uint256"},
+		{"name":"sqrtPriceLimitX96","type":"uint160"}]}],
+	 "outputs":[{"name":"amountIn","type":"uint256"}]}
+]`
+
+var (
+	swapRouterContractABI      = mustParseABI(swapRouterABI)
+	factoryContractABI         = mustParseABI(factoryABI)
+	poolContractABI            = mustParseABI(poolABI)
+	positionManagerContractABI = mustParseABI(positionManagerABI)
+)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
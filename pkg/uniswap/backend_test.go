@@ -0,0 +1,374 @@
+package uniswap
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// the EVM opcodes of the stub contracts
+const (
+	opEQ           = 0x14
+	opSHR          = 0x1c
+	opCALLDATALOAD = 0x35
+	opCODECOPY     = 0x39
+	opPOP          = 0x50
+	opJUMPI        = 0x57
+	opGAS          = 0x5a
+	opJUMPDEST     = 0x5b
+	opPUSH1        = 0x60
+	opPUSH2        = 0x61
+	opPUSH4        = 0x63
+	opPUSH20       = 0x73
+	opPUSH32       = 0x7f
+	opDUP1         = 0x80
+	opLOG0         = 0xa0
+	opCALL         = 0xf1
+	opRETURN       = 0xf3
+	opREVERT       = 0xfd
+)
+
+// stubReply is the reply of a stub contract to the calls of a method: the event logged,
+// the contract called with no calldata after it and the returned data
+type stubReply struct {
+	topics []common.Hash
+	data   []byte
+	call   *common.Address
+	ret    []byte
+}
+
+// stubCode assembles the code of a contract replying to the calls by the selectors, the empty calldata
+// is the zero selector and the calls of the unknown selectors revert
+func stubCode(replies map[[4]byte]stubReply) []byte {
+	selectors := make([][4]byte, 0, len(replies))
+	for sel := range replies {
+		selectors = append(selectors, sel)
+	}
+	sort.Slice(selectors, func(i, j int) bool {
+		return binary.BigEndian.Uint32(selectors[i][:]) < binary.BigEndian.Uint32(selectors[j][:])
+	})
+
+	var code, payload []byte
+	// the positions of the jump destinations and of the payload offsets patched after the code is assembled
+	var jumps, offsets []int
+	push2 := func(v int) {
+		code = append(code, opPUSH2, byte(v>>8), byte(v))
+	}
+
+	code = append(code, opPUSH1, 0, opCALLDATALOAD, opPUSH1, 0xe0, opSHR)
+	for _, sel := range selectors {
+		code = append(code, opDUP1, opPUSH4, sel[0], sel[1], sel[2], sel[3], opEQ)
+		jumps = append(jumps, len(code)+1)
+		push2(0)
+		code = append(code, opJUMPI)
+	}
+	code = append(code, opPUSH1, 0, opDUP1, opREVERT)
+
+	var targets, payloads []int
+	for _, sel := range selectors {
+		r := replies[sel]
+		targets = append(targets, len(code))
+		code = append(code, opJUMPDEST)
+
+		// the data and the returned data are copied from the payload to the memory
+		push2(len(r.data) + len(r.ret))
+		offsets = append(offsets, len(code)+1)
+		push2(0)
+		payloads = append(payloads, len(payload))
+		payload = append(payload, r.data...)
+		payload = append(payload, r.ret...)
+		code = append(code, opPUSH1, 0, opCODECOPY)
+
+		if len(r.topics) > 0 {
+			for i := len(r.topics) - 1; i >= 0; i-- {
+				code = append(append(code, opPUSH32), r.topics[i].Bytes()...)
+			}
+			push2(len(r.data))
+			code = append(code, opPUSH1, 0, byte(opLOG0+len(r.topics)))
+		}
+		if r.call != nil {
+			code = append(code, opPUSH1, 0, opDUP1, opDUP1, opDUP1, opDUP1, opPUSH20)
+			code = append(code, r.call.Bytes()...)
+			code = append(code, opGAS, opCALL, opPOP)
+		}
+
+		push2(len(r.ret))
+		push2(len(r.data))
+		code = append(code, opRETURN)
+	}
+
+	for i, pos := range jumps {
+		binary.BigEndian.PutUint16(code[pos:], uint16(targets[i]))
+	}
+	for i, pos := range offsets {
+		binary.BigEndian.PutUint16(code[pos:], uint16(len(code)+payloads[i]))
+	}
+	return append(code, payload...)
+}
+
+// selector is the selector of the method of the contract
+func selector(contractABI abi.ABI, method string) [4]byte {
+	return [4]byte(contractABI.Methods[method].ID)
+}
+
+// returns packs the outputs of the method of the contract
+func returns(t *testing.T, contractABI abi.ABI, method string, values ...interface{}) []byte {
+	t.Helper()
+	data, err := contractABI.Methods[method].Outputs.Pack(values...)
+	if err != nil {
+		t.Fatalf("pack %s outputs: %v", method, err)
+	}
+	return data
+}
+
+// event packs the event of the contract, the indexed values go first
+func event(t *testing.T, contractABI abi.ABI, name string, values ...interface{}) ([]common.Hash, []byte) {
+	t.Helper()
+	ev := contractABI.Events[name]
+	topics := []common.Hash{ev.ID}
+	var data []interface{}
+	for i, in := range ev.Inputs {
+		if !in.Indexed {
+			data = append(data, values[i])
+			continue
+		}
+		switch v := values[i].(type) {
+		case *big.Int:
+			topics = append(topics, common.BigToHash(v))
+		case common.Address:
+			topics = append(topics, common.BytesToHash(v.Bytes()))
+		default:
+			t.Fatalf("unsupported indexed %s value %T", in.Name, v)
+		}
+	}
+	packed, err := ev.Inputs.NonIndexed().Pack(data...)
+	if err != nil {
+		t.Fatalf("pack %s event: %v", name, err)
+	}
+	return topics, packed
+}
+
+// chainBackend is an in-process chain mining a block with every sent transaction, so the receipts are waited
+// for no longer than a send, the simulated.Backend node is not used as it links the memsize package
+// rejected by the recent Go linkers
+type chainBackend struct {
+	db    ethdb.Database
+	chain *core.BlockChain
+
+	mu       sync.Mutex
+	receipts map[common.Hash]*types.Receipt
+}
+
+// newBackend starts the chain with the stub contracts and a funded signer
+func newBackend(t *testing.T, contracts map[common.Address][]byte) (Backend, Signer) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alloc := types.GenesisAlloc{
+		signer.Address(): {Balance: new(big.Int).Lsh(big.NewInt(1), 100)},
+	}
+	for address, code := range contracts {
+		alloc[address] = types.Account{Code: code, Balance: new(big.Int)}
+	}
+
+	db := rawdb.NewMemoryDatabase()
+	chain, err := core.NewBlockChain(db, nil, &core.Genesis{
+		Config:   params.AllEthashProtocolChanges,
+		GasLimit: 30_000_000,
+		BaseFee:  big.NewInt(params.InitialBaseFee),
+		Alloc:    alloc,
+	}, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(chain.Stop)
+
+	return &chainBackend{db: db, chain: chain, receipts: make(map[common.Hash]*types.Receipt)}, signer
+}
+
+func (b *chainBackend) state() (*state.StateDB, error) {
+	return b.chain.StateAt(b.chain.CurrentBlock().Root)
+}
+
+// SendTransaction mines the transaction into a new block
+func (b *chainBackend) SendTransaction(_ context.Context, tx *types.Transaction) (err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// the block generator panics adding the transactions which can not be executed
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("add transaction %s: %v", tx.Hash(), r)
+		}
+	}()
+
+	head := b.chain.CurrentBlock()
+	parent := b.chain.GetBlock(head.Hash(), head.Number.Uint64())
+	blocks, _ := core.GenerateChain(b.chain.Config(), parent, ethash.NewFaker(), b.db, 1, func(_ int, g *core.BlockGen) {
+		g.AddTx(tx)
+	})
+	if _, err = b.chain.InsertChain(blocks); err != nil {
+		return fmt.Errorf("insert block: %w", err)
+	}
+	for _, receipt := range b.chain.GetReceiptsByHash(blocks[0].Hash()) {
+		b.receipts[receipt.TxHash] = receipt
+	}
+	return nil
+}
+
+func (b *chainBackend) TransactionReceipt(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	receipt, ok := b.receipts[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+// execute runs the message on top of the current block discarding the state changes
+func (b *chainBackend) execute(msg ethereum.CallMsg) (*core.ExecutionResult, error) {
+	statedb, err := b.state()
+	if err != nil {
+		return nil, err
+	}
+	value := msg.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	gas := msg.Gas
+	if gas == 0 {
+		gas = b.chain.CurrentBlock().GasLimit
+	}
+
+	head := b.chain.CurrentBlock()
+	evm := vm.NewEVM(core.NewEVMBlockContext(head, b.chain, nil), vm.TxContext{Origin: msg.From, GasPrice: new(big.Int)},
+		statedb, b.chain.Config(), vm.Config{NoBaseFee: true})
+	return core.ApplyMessage(evm, &core.Message{
+		From:              msg.From,
+		To:                msg.To,
+		Value:             value,
+		GasLimit:          gas,
+		GasPrice:          new(big.Int),
+		GasFeeCap:         new(big.Int),
+		GasTipCap:         new(big.Int),
+		Data:              msg.Data,
+		SkipAccountChecks: true,
+	}, new(core.GasPool).AddGas(gas))
+}
+
+func (b *chainBackend) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	res, err := b.execute(msg)
+	if err != nil {
+		return nil, err
+	}
+	if res.Failed() {
+		return nil, res.Err
+	}
+	return res.Return(), nil
+}
+
+// EstimateGas doubles the gas used leaving enough of it to the calls made by the stubs
+func (b *chainBackend) EstimateGas(_ context.Context, msg ethereum.CallMsg) (uint64, error) {
+	msg.Gas = 0
+	res, err := b.execute(msg)
+	if err != nil {
+		return 0, err
+	}
+	if res.Failed() {
+		return 0, res.Err
+	}
+	return 2 * res.UsedGas, nil
+}
+
+func (b *chainBackend) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return b.chain.CurrentBlock(), nil
+}
+
+func (b *chainBackend) SuggestGasPrice(context.Context) (*big.Int, error) {
+	return new(big.Int).Add(b.chain.CurrentBlock().BaseFee, big.NewInt(1)), nil
+}
+
+func (b *chainBackend) SuggestGasTipCap(context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (b *chainBackend) ChainID(context.Context) (*big.Int, error) {
+	return b.chain.Config().ChainID, nil
+}
+
+func (b *chainBackend) BalanceAt(_ context.Context, account common.Address, _ *big.Int) (*big.Int, error) {
+	statedb, err := b.state()
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetBalance(account).ToBig(), nil
+}
+
+func (b *chainBackend) StorageAt(_ context.Context, account common.Address, key common.Hash, _ *big.Int) ([]byte, error) {
+	statedb, err := b.state()
+	if err != nil {
+		return nil, err
+	}
+	v := statedb.GetState(account, key)
+	return v.Bytes(), nil
+}
+
+func (b *chainBackend) CodeAt(_ context.Context, account common.Address, _ *big.Int) ([]byte, error) {
+	statedb, err := b.state()
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetCode(account), nil
+}
+
+func (b *chainBackend) NonceAt(_ context.Context, account common.Address, _ *big.Int) (uint64, error) {
+	statedb, err := b.state()
+	if err != nil {
+		return 0, err
+	}
+	return statedb.GetNonce(account), nil
+}
+
+func (b *chainBackend) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return b.CodeAt(ctx, account, nil)
+}
+
+func (b *chainBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return b.NonceAt(ctx, account, nil)
+}
+
+func (b *chainBackend) FilterLogs(context.Context, ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, errors.New("logs are not filtered by the test chain")
+}
+
+func (b *chainBackend) SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("logs are not filtered by the test chain")
+}
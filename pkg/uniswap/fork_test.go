@@ -0,0 +1,174 @@
+//go:build anvil
+
+package uniswap
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/r1der/epos/pkg/erc20"
+)
+
+// The fork test runs the client against the Uniswap v3 contracts deployed on the Ethereum mainnet,
+// it needs an anvil node forking the mainnet, e.g.
+//
+//	anvil --fork-url $MAINNET_RPC_URL
+//	EPOS_TEST_ANVIL_URL=http://127.0.0.1:8545 go test -tags anvil ./pkg/uniswap/
+
+// anvilKey is the key of the first account funded by anvil
+const anvilKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+var (
+	mainnet = Config{
+		FactoryAddress:         common.HexToAddress("0x1F98431c8aD98523631AE4a59f267346ea31F984"),
+		PositionManagerAddress: common.HexToAddress("0xC36442b4a4522E871399CD717aBDD847Ab11FE88"),
+		SwapRouterAddress:      common.HexToAddress("0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45"),
+	}
+	mainnetUSDC     = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	mainnetWETH     = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	mainnetUSDCWETH = common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640")
+
+	wethContractABI = mustParseABI(`[
+		{"type":"function","name":"deposit","stateMutability":"payable","inputs":[],"outputs":[]}
+	]`)
+)
+
+func TestFork(t *testing.T) {
+	url := os.Getenv("EPOS_TEST_ANVIL_URL")
+	if url == "" {
+		t.Skip("EPOS_TEST_ANVIL_URL is not set")
+	}
+	ctx := context.Background()
+
+	backend, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(backend.Close)
+	signer, err := NewSigner(anvilKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := New(backend, mainnet)
+	tokens := erc20.NewManager(backend)
+
+	// wrap some of the anvil ether to trade it
+	opts := erc20.NewTransactor(ctx, signer, chainID)
+	opts.Value = big.NewInt(5e18)
+	tx, err := bind.NewBoundContract(mainnetWETH, wethContractABI, backend, backend, backend).Transact(opts, "deposit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bind.WaitMined(ctx, backend, tx); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := u.FindPool(ctx, mainnetWETH, mainnetUSDC, big.NewInt(500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Address != mainnetUSDCWETH || p.Token0 != mainnetUSDC || p.Token1 != mainnetWETH || p.TickSpacing != 10 ||
+		p.SqrtPriceX96.Sign() <= 0 || p.Liquidity.Sign() <= 0 {
+		t.Fatalf("unexpected pool %+v", p)
+	}
+
+	cumulatives, err := u.Observe(ctx, p.Address, []uint32{600, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cumulatives) != 2 {
+		t.Fatalf("got %d tick cumulatives", len(cumulatives))
+	}
+
+	sold, err := u.ExactInputSingle(ctx, signer, &ExactInputSingleInput{
+		Pool: p.Address, TokenIn: mainnetWETH, TokenOut: mainnetUSDC, Fee: p.Fee,
+		AmountIn: big.NewInt(2e18), AmountOutMinimum: big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sold.AmountIn.Cmp(big.NewInt(2e18)) != 0 || sold.AmountOut.Sign() <= 0 || sold.TransactionFee.Sign() <= 0 {
+		t.Fatalf("unexpected exact input swap %+v", sold)
+	}
+
+	bought, err := u.ExactOutputSingle(ctx, signer, &ExactOutputSingleInput{
+		Pool: p.Address, TokenIn: mainnetUSDC, TokenOut: mainnetWETH, Fee: p.Fee,
+		AmountOut: big.NewInt(1e17), AmountInMaximum: sold.AmountOut,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bought.AmountOut.Cmp(big.NewInt(1e17)) != 0 || bought.AmountIn.Sign() <= 0 || bought.AmountIn.Cmp(sold.AmountOut) > 0 {
+		t.Fatalf("unexpected exact output swap %+v", bought)
+	}
+
+	p, err = u.GetPool(ctx, p.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usdcBalance, err := tokens.GetTokenBalance(ctx, signer.Address(), mainnetUSDC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the range of 20 tick spacings around the current tick
+	tickLower := (p.Tick/p.TickSpacing - 10) * p.TickSpacing
+	tickUpper := (p.Tick/p.TickSpacing + 10) * p.TickSpacing
+	minted, err := u.IncreaseLiquidity(ctx, signer, &IncreaseLiquidityInput{
+		Token0: p.Token0, Token1: p.Token1, Fee: p.Fee, TickLower: tickLower, TickUpper: tickUpper,
+		Amount0Desired: usdcBalance, Amount1Desired: big.NewInt(1e18),
+		Amount0Min: new(big.Int), Amount1Min: new(big.Int),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minted.TokenID.Sign() <= 0 || minted.Liquidity.Sign() <= 0 || minted.Amount0.Cmp(usdcBalance) > 0 ||
+		minted.Amount1.Cmp(big.NewInt(1e18)) > 0 || minted.TransactionFee.Sign() <= 0 {
+		t.Fatalf("unexpected minted position %+v", minted)
+	}
+
+	pos, err := u.GetPosition(ctx, minted.TokenID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.Token0 != p.Token0 || pos.Token1 != p.Token1 || pos.TickLower != tickLower || pos.TickUpper != tickUpper ||
+		pos.Liquidity.Cmp(minted.Liquidity) != 0 {
+		t.Fatalf("unexpected position %+v", pos)
+	}
+	if _, err = u.GetFees(ctx, signer.Address(), minted.TokenID); err != nil {
+		t.Fatal(err)
+	}
+
+	burned, err := u.DecreaseLiquidity(ctx, signer, &DecreaseLiquidityInput{
+		TokenID: minted.TokenID, Liquidity: minted.Liquidity, Amount0Min: new(big.Int), Amount1Min: new(big.Int),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the pool rounds the withdrawn amounts down by at most a unit
+	for i, amounts := range [][2]*big.Int{{burned.Amount0, minted.Amount0}, {burned.Amount1, minted.Amount1}} {
+		if diff := new(big.Int).Sub(amounts[1], amounts[0]); diff.Sign() < 0 || diff.Cmp(big.NewInt(1)) > 0 {
+			t.Errorf("token%d: withdrawn %s of %s deposited", i, amounts[0], amounts[1])
+		}
+	}
+
+	pos, err = u.GetPosition(ctx, minted.TokenID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.Liquidity.Sign() != 0 {
+		t.Fatalf("liquidity %s left after the decrease", pos.Liquidity)
+	}
+	if _, err = u.CollectFees(ctx, signer, &CollectFeesInput{TokenID: minted.TokenID}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,89 @@
+package uniswap
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Pool is a snapshot of the Uniswap v3 pool state
+type Pool struct {
+	Address      common.Address
+	Token0       common.Address
+	Token1       common.Address
+	Fee          *big.Int
+	TickSpacing  int
+	SqrtPriceX96 *big.Int
+	Tick         int
+	Liquidity    *big.Int
+}
+
+// FindPool resolves the pool of the token pair via the factory and loads its state
+func (u *uniswap) FindPool(ctx context.Context, tokenA, tokenB common.Address, fee *big.Int) (*Pool, error) {
+	out, err := u.call(ctx, u.factory, "getPool", tokenA, tokenB, fee)
+	if err != nil {
+		return nil, fmt.Errorf("factory: get pool: %w", err)
+	}
+
+	address := out[0].(common.Address)
+	if address == (common.Address{}) {
+		return nil, ErrPoolNotFound
+	}
+
+	return u.GetPool(ctx, address)
+}
+
+// GetPool loads the current pool state
+func (u *uniswap) GetPool(ctx context.Context, address common.Address) (*Pool, error) {
+	contract := bind.NewBoundContract(address, poolContractABI, u.backend, u.backend, u.backend)
+
+	slot0, err := u.call(ctx, contract, "slot0")
+	if err != nil {
+		return nil, fmt.Errorf("pool: slot0: %w", err)
+	}
+	liquidity, err := u.call(ctx, contract, "liquidity")
+	if err != nil {
+		return nil, fmt.Errorf("pool: liquidity: %w", err)
+	}
+	token0, err := u.call(ctx, contract, "token0")
+	if err != nil {
+		return nil, fmt.Errorf("pool: token0: %w", err)
+	}
+	token1, err := u.call(ctx, contract, "token1")
+	if err != nil {
+		return nil, fmt.Errorf("pool: token1: %w", err)
+	}
+	fee, err := u.call(ctx, contract, "fee")
+	if err != nil {
+		return nil, fmt.Errorf("pool: fee: %w", err)
+	}
+	tickSpacing, err := u.call(ctx, contract, "tickSpacing")
+	if err != nil {
+		return nil, fmt.Errorf("pool: tick spacing: %w", err)
+	}
+
+	return &Pool{
+		Address:      address,
+		Token0:       token0[0].(common.Address),
+		Token1:       token1[0].(common.Address),
+		Fee:          fee[0].(*big.Int),
+		TickSpacing:  int(tickSpacing[0].(*big.Int).Int64()),
+		SqrtPriceX96: slot0[0].(*big.Int),
+		Tick:         int(slot0[1].(*big.Int).Int64()),
+		Liquidity:    liquidity[0].(*big.Int),
+	}, nil
+}
+
+// Observe returns the pool oracle tick cumulatives as of each of the seconds ago
+func (u *uniswap) Observe(ctx context.Context, address common.Address, secondsAgos []uint32) ([]*big.Int, error) {
+	contract := bind.NewBoundContract(address, poolContractABI, u.backend, u.backend, u.backend)
+
+	out, err := u.call(ctx, contract, "observe", secondsAgos)
+	if err != nil {
+		return nil, fmt.Errorf("pool: observe: %w", err)
+	}
+	return out[0].([]*big.Int), nil
+}
@@ -0,0 +1,276 @@
+package uniswap
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TransactionDeadline limits the time a sent transaction stays valid
+const TransactionDeadline = 10 * time.Minute
+
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// Position is a NonfungiblePositionManager position
+type Position struct {
+	TokenID     *big.Int
+	Token0      common.Address
+	Token1      common.Address
+	Fee         *big.Int
+	TickLower   int
+	TickUpper   int
+	Liquidity   *big.Int
+	TokensOwed0 *big.Int
+	TokensOwed1 *big.Int
+}
+
+type IncreaseLiquidityInput struct {
+	Token0         common.Address
+	Token1         common.Address
+	Fee            *big.Int
+	TickLower      int
+	TickUpper      int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+}
+
+type IncreaseLiquidityOutput struct {
+	TransactionHash common.Hash
+	TokenID         *big.Int
+	Liquidity       *big.Int
+	Amount0         *big.Int
+	Amount1         *big.Int
+	TransactionFee  *big.Int
+}
+
+type DecreaseLiquidityInput struct {
+	TokenID    *big.Int
+	Liquidity  *big.Int
+	Amount0Min *big.Int
+	Amount1Min *big.Int
+}
+
+type DecreaseLiquidityOutput struct {
+	TransactionHash common.Hash
+	Amount0         *big.Int
+	Amount1         *big.Int
+	TransactionFee  *big.Int
+}
+
+type CollectFeesInput struct {
+	TokenID    *big.Int
+	Amount0Max *big.Int
+	Amount1Max *big.Int
+}
+
+type CollectFeesOutput struct {
+	TransactionHash common.Hash
+	Amount0         *big.Int
+	Amount1         *big.Int
+	TransactionFee  *big.Int
+}
+
+type Fees struct {
+	Amount0 *big.Int
+	Amount1 *big.Int
+}
+
+type mintParams struct {
+	Token0         common.Address
+	Token1         common.Address
+	Fee            *big.Int
+	TickLower      *big.Int
+	TickUpper      *big.Int
+	Amount0Desired *big.Int
+	Amount1Desired *big.Int
+	Amount0Min     *big.Int
+	Amount1Min     *big.Int
+	Recipient      common.Address
+	Deadline       *big.Int
+}
+
+type decreaseLiquidityParams struct {
+	TokenId    *big.Int
+	Liquidity  *big.Int
+	Amount0Min *big.Int
+	Amount1Min *big.Int
+	Deadline   *big.Int
+}
+
+type collectParams struct {
+	TokenId    *big.Int
+	Recipient  common.Address
+	Amount0Max *big.Int
+	Amount1Max *big.Int
+}
+
+type liquidityEvent struct {
+	TokenId   *big.Int
+	Liquidity *big.Int
+	Amount0   *big.Int
+	Amount1   *big.Int
+}
+
+type collectEvent struct {
+	TokenId   *big.Int
+	Recipient common.Address
+	Amount0   *big.Int
+	Amount1   *big.Int
+}
+
+// IncreaseLiquidity mints a new position owned by the signer
+func (u *uniswap) IncreaseLiquidity(ctx context.Context, signer Signer, in *IncreaseLiquidityInput) (*IncreaseLiquidityOutput, error) {
+	err := u.approve(ctx, signer, u.cfg.PositionManagerAddress,
+		[]common.Address{in.Token0, in.Token1}, []*big.Int{in.Amount0Desired, in.Amount1Desired})
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := u.transact(ctx, signer, u.positionManager, "mint", mintParams{
+		Token0:         in.Token0,
+		Token1:         in.Token1,
+		Fee:            in.Fee,
+		TickLower:      big.NewInt(int64(in.TickLower)),
+		TickUpper:      big.NewInt(int64(in.TickUpper)),
+		Amount0Desired: in.Amount0Desired,
+		Amount1Desired: in.Amount1Desired,
+		Amount0Min:     in.Amount0Min,
+		Amount1Min:     in.Amount1Min,
+		Recipient:      signer.Address(),
+		Deadline:       deadline(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var event liquidityEvent
+	if err = unpackEvent(receipt, u.cfg.PositionManagerAddress, positionManagerContractABI, u.positionManager, "IncreaseLiquidity", &event); err != nil {
+		return nil, err
+	}
+
+	return &IncreaseLiquidityOutput{
+		TransactionHash: receipt.TxHash,
+		TokenID:         event.TokenId,
+		Liquidity:       event.Liquidity,
+		Amount0:         event.Amount0,
+		Amount1:         event.Amount1,
+		TransactionFee:  transactionFee(receipt),
+	}, nil
+}
+
+// DecreaseLiquidity removes the liquidity and withdraws the released principal, the fees stay until CollectFees
+func (u *uniswap) DecreaseLiquidity(ctx context.Context, signer Signer, in *DecreaseLiquidityInput) (*DecreaseLiquidityOutput, error) {
+	receipt, err := u.transact(ctx, signer, u.positionManager, "decreaseLiquidity", decreaseLiquidityParams{
+		TokenId:    in.TokenID,
+		Liquidity:  in.Liquidity,
+		Amount0Min: in.Amount0Min,
+		Amount1Min: in.Amount1Min,
+		Deadline:   deadline(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var event liquidityEvent
+	if err = unpackEvent(receipt, u.cfg.PositionManagerAddress, positionManagerContractABI, u.positionManager, "DecreaseLiquidity", &event); err != nil {
+		return nil, err
+	}
+
+	collected, err := u.CollectFees(ctx, signer, &CollectFeesInput{
+		TokenID:    in.TokenID,
+		Amount0Max: event.Amount0,
+		Amount1Max: event.Amount1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collect released liquidity: %w", err)
+	}
+
+	return &DecreaseLiquidityOutput{
+		TransactionHash: receipt.TxHash,
+		Amount0:         collected.Amount0,
+		Amount1:         collected.Amount1,
+		TransactionFee:  new(big.Int).Add(transactionFee(receipt), collected.TransactionFee),
+	}, nil
+}
+
+// CollectFees withdraws the tokens owed to the position, by default everything it is owed
+func (u *uniswap) CollectFees(ctx context.Context, signer Signer, in *CollectFeesInput) (*CollectFeesOutput, error) {
+	amount0Max, amount1Max := in.Amount0Max, in.Amount1Max
+	if amount0Max == nil {
+		amount0Max = maxUint128
+	}
+	if amount1Max == nil {
+		amount1Max = maxUint128
+	}
+
+	receipt, err := u.transact(ctx, signer, u.positionManager, "collect", collectParams{
+		TokenId:    in.TokenID,
+		Recipient:  signer.Address(),
+		Amount0Max: amount0Max,
+		Amount1Max: amount1Max,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var event collectEvent
+	if err = unpackEvent(receipt, u.cfg.PositionManagerAddress, positionManagerContractABI, u.positionManager, "Collect", &event); err != nil {
+		return nil, err
+	}
+
+	return &CollectFeesOutput{
+		TransactionHash: receipt.TxHash,
+		Amount0:         event.Amount0,
+		Amount1:         event.Amount1,
+		TransactionFee:  transactionFee(receipt),
+	}, nil
+}
+
+// GetPosition reads the position state
+func (u *uniswap) GetPosition(ctx context.Context, tokenID *big.Int) (*Position, error) {
+	out, err := u.call(ctx, u.positionManager, "positions", tokenID)
+	if err != nil {
+		return nil, fmt.Errorf("position manager: positions: %w", err)
+	}
+
+	return &Position{
+		TokenID:     tokenID,
+		Token0:      out[2].(common.Address),
+		Token1:      out[3].(common.Address),
+		Fee:         out[4].(*big.Int),
+		TickLower:   int(out[5].(*big.Int).Int64()),
+		TickUpper:   int(out[6].(*big.Int).Int64()),
+		Liquidity:   out[7].(*big.Int),
+		TokensOwed0: out[10].(*big.Int),
+		TokensOwed1: out[11].(*big.Int),
+	}, nil
+}
+
+// GetFees simulates a full collect on behalf of the owner to get the fees accrued by the position
+func (u *uniswap) GetFees(ctx context.Context, owner common.Address, tokenID *big.Int) (*Fees, error) {
+	var out []interface{}
+	err := u.positionManager.Call(&bind.CallOpts{Context: ctx, From: owner}, &out, "collect", collectParams{
+		TokenId:    tokenID,
+		Recipient:  owner,
+		Amount0Max: maxUint128,
+		Amount1Max: maxUint128,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("position manager: simulate collect: %w", err)
+	}
+
+	return &Fees{
+		Amount0: out[0].(*big.Int),
+		Amount1: out[1].(*big.Int),
+	}, nil
+}
+
+func deadline() *big.Int {
+	return big.NewInt(time.Now().Add(TransactionDeadline).Unix())
+}
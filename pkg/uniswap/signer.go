@@ -1,13 +1,38 @@
 package uniswap
 
 import (
+	"context"
 	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
+// Signer signs the transactions sent on behalf of its address
 type Signer interface {
-	PrivateKey() *ecdsa.PrivateKey
-	PublicKey() *ecdsa.PublicKey
 	Address() common.Address
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+type keySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewSigner creates a signer from a hex encoded private key
+func NewSigner(privateKey string) (Signer, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %w", err)
+	}
+	return &keySigner{key: key}, nil
+}
+
+func (s *keySigner) Address() common.Address { return crypto.PubkeyToAddress(s.key.PublicKey) }
+
+func (s *keySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
 }
@@ -0,0 +1,135 @@
+package uniswap
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ExactInputSingleInput swaps exactly AmountIn receiving at least AmountOutMinimum
+type ExactInputSingleInput struct {
+	Pool             common.Address
+	TokenIn          common.Address
+	TokenOut         common.Address
+	Fee              *big.Int
+	AmountIn         *big.Int
+	AmountOutMinimum *big.Int
+}
+
+// ExactOutputSingleInput swaps at most AmountInMaximum receiving exactly AmountOut
+type ExactOutputSingleInput struct {
+	Pool            common.Address
+	TokenIn         common.Address
+	TokenOut        common.Address
+	Fee             *big.Int
+	AmountOut       *big.Int
+	AmountInMaximum *big.Int
+}
+
+// SwapOutput holds the amounts actually swapped by the pool
+type SwapOutput struct {
+	TransactionHash common.Hash
+	AmountIn        *big.Int
+	AmountOut       *big.Int
+	TransactionFee  *big.Int
+}
+
+type exactInputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	AmountIn          *big.Int
+	AmountOutMinimum  *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+type exactOutputSingleParams struct {
+	TokenIn           common.Address
+	TokenOut          common.Address
+	Fee               *big.Int
+	Recipient         common.Address
+	AmountOut         *big.Int
+	AmountInMaximum   *big.Int
+	SqrtPriceLimitX96 *big.Int
+}
+
+type swapEvent struct {
+	Sender       common.Address
+	Recipient    common.Address
+	Amount0      *big.Int
+	Amount1      *big.Int
+	SqrtPriceX96 *big.Int
+	Liquidity    *big.Int
+	Tick         *big.Int
+}
+
+// ExactInputSingle swaps an exact amount of the input token via the SwapRouter02
+func (u *uniswap) ExactInputSingle(ctx context.Context, signer Signer, in *ExactInputSingleInput) (*SwapOutput, error) {
+	err := u.approve(ctx, signer, u.cfg.SwapRouterAddress, []common.Address{in.TokenIn}, []*big.Int{in.AmountIn})
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := u.transact(ctx, signer, u.swapRouter, "exactInputSingle", exactInputSingleParams{
+		TokenIn:           in.TokenIn,
+		TokenOut:          in.TokenOut,
+		Fee:               in.Fee,
+		Recipient:         signer.Address(),
+		AmountIn:          in.AmountIn,
+		AmountOutMinimum:  in.AmountOutMinimum,
+		SqrtPriceLimitX96: big.NewInt(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.swapOutput(receipt, in.Pool, in.TokenIn, in.TokenOut)
+}
+
+// ExactOutputSingle swaps the input token for an exact amount of the output token via the SwapRouter02
+func (u *uniswap) ExactOutputSingle(ctx context.Context, signer Signer, in *ExactOutputSingleInput) (*SwapOutput, error) {
+	err := u.approve(ctx, signer, u.cfg.SwapRouterAddress, []common.Address{in.TokenIn}, []*big.Int{in.AmountInMaximum})
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := u.transact(ctx, signer, u.swapRouter, "exactOutputSingle", exactOutputSingleParams{
+		TokenIn:           in.TokenIn,
+		TokenOut:          in.TokenOut,
+		Fee:               in.Fee,
+		Recipient:         signer.Address(),
+		AmountOut:         in.AmountOut,
+		AmountInMaximum:   in.AmountInMaximum,
+		SqrtPriceLimitX96: big.NewInt(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.swapOutput(receipt, in.Pool, in.TokenIn, in.TokenOut)
+}
+
+// swapOutput reads the swapped amounts from the pool Swap event
+func (u *uniswap) swapOutput(receipt *types.Receipt, pool, tokenIn, tokenOut common.Address) (*SwapOutput, error) {
+	contract := bind.NewBoundContract(pool, poolContractABI, u.backend, u.backend, u.backend)
+
+	var event swapEvent
+	if err := unpackEvent(receipt, pool, poolContractABI, contract, "Swap", &event); err != nil {
+		return nil, err
+	}
+
+	amountIn, amountOut := event.Amount0, new(big.Int).Neg(event.Amount1)
+	if bytes.Compare(tokenIn.Bytes(), tokenOut.Bytes()) > 0 {
+		amountIn, amountOut = event.Amount1, new(big.Int).Neg(event.Amount0)
+	}
+
+	return &SwapOutput{
+		TransactionHash: receipt.TxHash,
+		AmountIn:        amountIn,
+		AmountOut:       amountOut,
+		TransactionFee:  transactionFee(receipt),
+	}, nil
+}
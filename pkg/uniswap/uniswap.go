@@ -1,10 +1,126 @@
 package uniswap
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/r1der/epos/pkg/erc20"
+)
+
+var (
+	ErrPoolNotFound        = errors.New("pool not found")
+	ErrTransactionReverted = errors.New("transaction reverted")
+)
+
+// Backend is the chain access of the client
+type Backend interface {
+	erc20.Backend
+}
+
+// Config holds the addresses of the Uniswap v3 periphery deployed on a network
+type Config struct {
+	FactoryAddress         common.Address
+	PositionManagerAddress common.Address
+	SwapRouterAddress      common.Address
+}
+
 type Uniswap interface {
-	FindPool()
-	GetPool()
-	IncreaseLiquidity()
-	DecreaseLiquidity()
-	GetPosition()
-	CollectFees()
+	FindPool(ctx context.Context, tokenA, tokenB common.Address, fee *big.Int) (*Pool, error)
+	GetPool(ctx context.Context, address common.Address) (*Pool, error)
+	Observe(ctx context.Context, address common.Address, secondsAgos []uint32) ([]*big.Int, error)
+	IncreaseLiquidity(ctx context.Context, signer Signer, in *IncreaseLiquidityInput) (*IncreaseLiquidityOutput, error)
+	DecreaseLiquidity(ctx context.Context, signer Signer, in *DecreaseLiquidityInput) (*DecreaseLiquidityOutput, error)
+	GetPosition(ctx context.Context, tokenID *big.Int) (*Position, error)
+	GetFees(ctx context.Context, owner common.Address, tokenID *big.Int) (*Fees, error)
+	CollectFees(ctx context.Context, signer Signer, in *CollectFeesInput) (*CollectFeesOutput, error)
+	ExactInputSingle(ctx context.Context, signer Signer, in *ExactInputSingleInput) (*SwapOutput, error)
+	ExactOutputSingle(ctx context.Context, signer Signer, in *ExactOutputSingleInput) (*SwapOutput, error)
+}
+
+type uniswap struct {
+	backend         Backend
+	cfg             Config
+	tokens          erc20.Manager
+	factory         *bind.BoundContract
+	positionManager *bind.BoundContract
+	swapRouter      *bind.BoundContract
+}
+
+func New(backend Backend, cfg Config) Uniswap {
+	return &uniswap{
+		backend:         backend,
+		cfg:             cfg,
+		tokens:          erc20.NewManager(backend),
+		factory:         bind.NewBoundContract(cfg.FactoryAddress, factoryContractABI, backend, backend, backend),
+		positionManager: bind.NewBoundContract(cfg.PositionManagerAddress, positionManagerContractABI, backend, backend, backend),
+		swapRouter:      bind.NewBoundContract(cfg.SwapRouterAddress, swapRouterContractABI, backend, backend, backend),
+	}
+}
+
+func (u *uniswap) call(ctx context.Context, contract *bind.BoundContract, method string, params ...interface{}) ([]interface{}, error) {
+	var out []interface{}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, method, params...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// transact sends a transaction signed by the signer and waits for its receipt
+func (u *uniswap) transact(ctx context.Context, signer Signer, contract *bind.BoundContract, method string, params ...interface{}) (*types.Receipt, error) {
+	chainID, err := u.backend.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get chain id: %w", err)
+	}
+
+	tx, err := contract.Transact(erc20.NewTransactor(ctx, signer, chainID), method, params...)
+	if err != nil {
+		return nil, fmt.Errorf("send %s transaction: %w", method, err)
+	}
+
+	receipt, err := bind.WaitMined(ctx, u.backend, tx)
+	if err != nil {
+		return nil, fmt.Errorf("wait %s transaction %s: %w", method, tx.Hash(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("%s transaction %s: %w", method, tx.Hash(), ErrTransactionReverted)
+	}
+
+	return receipt, nil
+}
+
+// approve makes sure the spender is allowed to withdraw the amounts of the tokens from the signer
+func (u *uniswap) approve(ctx context.Context, signer Signer, spender common.Address, tokens []common.Address, amounts []*big.Int) error {
+	for i, token := range tokens {
+		if amounts[i] == nil || amounts[i].Sign() <= 0 {
+			continue
+		}
+		if err := u.tokens.EnsureAllowance(ctx, signer, spender, token, amounts[i]); err != nil {
+			return fmt.Errorf("approve %s: %w", token, err)
+		}
+	}
+	return nil
+}
+
+// transactionFee calculates the gas cost paid for the transaction
+func transactionFee(receipt *types.Receipt) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+}
+
+// unpackEvent finds the first event emitted by the contract in the receipt and unpacks it
+func unpackEvent(receipt *types.Receipt, address common.Address, contractABI abi.ABI, contract *bind.BoundContract, event string, out interface{}) error {
+	id := contractABI.Events[event].ID
+	for _, log := range receipt.Logs {
+		if log.Address != address || len(log.Topics) == 0 || log.Topics[0] != id {
+			continue
+		}
+		return contract.UnpackLog(out, event, *log)
+	}
+	return fmt.Errorf("event %s not found in transaction %s", event, receipt.TxHash)
 }
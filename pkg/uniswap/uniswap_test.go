@@ -0,0 +1,259 @@
+package uniswap
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	token0          = common.HexToAddress("0x5000000000000000000000000000000000000001")
+	token1          = common.HexToAddress("0x5000000000000000000000000000000000000002")
+	factory         = common.HexToAddress("0x5000000000000000000000000000000000000003")
+	pool            = common.HexToAddress("0x5000000000000000000000000000000000000004")
+	positionManager = common.HexToAddress("0x5000000000000000000000000000000000000005")
+	swapRouter      = common.HexToAddress("0x5000000000000000000000000000000000000006")
+	// reversePool logs the swaps of token1 for token0
+	reversePool = common.HexToAddress("0x5000000000000000000000000000000000000007")
+
+	fee       = big.NewInt(3000)
+	tokenID   = big.NewInt(42)
+	liquidity = big.NewInt(1_000_000)
+)
+
+var tokenContractABI = mustParseABI(`[
+	{"type":"function","name":"allowance","stateMutability":"view",
+	 "inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],
+	 "outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"approve","stateMutability":"nonpayable",
+	 "inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],
+	 "outputs":[{"name":"","type":"bool"}]}
+]`)
+
+// newTestUniswap deploys the stubs of the tokens and the Uniswap contracts replying with the fixed values
+func newTestUniswap(t *testing.T) (Uniswap, Backend, Signer) {
+	t.Helper()
+
+	tokenStub := stubCode(map[[4]byte]stubReply{
+		selector(tokenContractABI, "allowance"): {ret: returns(t, tokenContractABI, "allowance", new(big.Int))},
+		selector(tokenContractABI, "approve"):   {ret: returns(t, tokenContractABI, "approve", true)},
+	})
+
+	factoryStub := stubCode(map[[4]byte]stubReply{
+		selector(factoryContractABI, "getPool"): {ret: returns(t, factoryContractABI, "getPool", pool)},
+	})
+
+	swapTopics, swapData := event(t, poolContractABI, "Swap", swapRouter, common.Address{},
+		big.NewInt(1000), big.NewInt(-1990), new(big.Int).Lsh(big.NewInt(1), 96), liquidity, big.NewInt(-7))
+	reverseTopics, reverseData := event(t, poolContractABI, "Swap", swapRouter, common.Address{},
+		big.NewInt(-500), big.NewInt(1010), new(big.Int).Lsh(big.NewInt(1), 96), liquidity, big.NewInt(-7))
+	poolViews := map[[4]byte]stubReply{
+		selector(poolContractABI, "slot0"): {ret: returns(t, poolContractABI, "slot0",
+			new(big.Int).Lsh(big.NewInt(1), 96), big.NewInt(-7), uint16(1), uint16(2), uint16(2), uint8(0), true)},
+		selector(poolContractABI, "liquidity"):   {ret: returns(t, poolContractABI, "liquidity", liquidity)},
+		selector(poolContractABI, "token0"):      {ret: returns(t, poolContractABI, "token0", token0)},
+		selector(poolContractABI, "token1"):      {ret: returns(t, poolContractABI, "token1", token1)},
+		selector(poolContractABI, "fee"):         {ret: returns(t, poolContractABI, "fee", fee)},
+		selector(poolContractABI, "tickSpacing"): {ret: returns(t, poolContractABI, "tickSpacing", big.NewInt(60))},
+		selector(poolContractABI, "observe"): {ret: returns(t, poolContractABI, "observe",
+			[]*big.Int{big.NewInt(-4200), big.NewInt(-700)}, []*big.Int{big.NewInt(1), big.NewInt(2)})},
+		{}: {topics: swapTopics, data: swapData},
+	}
+	poolStub := stubCode(poolViews)
+	poolViews[[4]byte{}] = stubReply{topics: reverseTopics, data: reverseData}
+	reversePoolStub := stubCode(poolViews)
+
+	// the router logs a decoy Swap event of its own before the pool swaps
+	routerStub := stubCode(map[[4]byte]stubReply{
+		selector(swapRouterContractABI, "exactInputSingle"): {
+			topics: swapTopics, data: reverseData, call: &pool,
+			ret: returns(t, swapRouterContractABI, "exactInputSingle", big.NewInt(1990)),
+		},
+		selector(swapRouterContractABI, "exactOutputSingle"): {
+			topics: reverseTopics, data: swapData, call: &reversePool,
+			ret: returns(t, swapRouterContractABI, "exactOutputSingle", big.NewInt(1010)),
+		},
+	})
+
+	increaseTopics, increaseData := event(t, positionManagerContractABI, "IncreaseLiquidity", tokenID, liquidity, big.NewInt(100), big.NewInt(200))
+	decreaseTopics, decreaseData := event(t, positionManagerContractABI, "DecreaseLiquidity", tokenID, liquidity, big.NewInt(99), big.NewInt(199))
+	collectTopics, collectData := event(t, positionManagerContractABI, "Collect", tokenID, common.Address{}, big.NewInt(105), big.NewInt(207))
+	positionManagerStub := stubCode(map[[4]byte]stubReply{
+		selector(positionManagerContractABI, "mint"): {topics: increaseTopics, data: increaseData,
+			ret: returns(t, positionManagerContractABI, "mint", tokenID, liquidity, big.NewInt(100), big.NewInt(200))},
+		selector(positionManagerContractABI, "decreaseLiquidity"): {topics: decreaseTopics, data: decreaseData,
+			ret: returns(t, positionManagerContractABI, "decreaseLiquidity", big.NewInt(99), big.NewInt(199))},
+		selector(positionManagerContractABI, "collect"): {topics: collectTopics, data: collectData,
+			ret: returns(t, positionManagerContractABI, "collect", big.NewInt(105), big.NewInt(207))},
+		selector(positionManagerContractABI, "positions"): {ret: returns(t, positionManagerContractABI, "positions",
+			new(big.Int), common.Address{}, token0, token1, fee, big.NewInt(-120), big.NewInt(60), liquidity,
+			new(big.Int), new(big.Int), big.NewInt(5), big.NewInt(8))},
+	})
+
+	backend, signer := newBackend(t, map[common.Address][]byte{
+		token0:          tokenStub,
+		token1:          tokenStub,
+		factory:         factoryStub,
+		pool:            poolStub,
+		reversePool:     reversePoolStub,
+		positionManager: positionManagerStub,
+		swapRouter:      routerStub,
+	})
+	return New(backend, Config{
+		FactoryAddress:         factory,
+		PositionManagerAddress: positionManager,
+		SwapRouterAddress:      swapRouter,
+	}), backend, signer
+}
+
+func TestPool(t *testing.T) {
+	ctx := context.Background()
+	u, _, _ := newTestUniswap(t)
+
+	p, err := u.FindPool(ctx, token1, token0, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Address != pool || p.Token0 != token0 || p.Token1 != token1 || p.Fee.Cmp(fee) != 0 || p.TickSpacing != 60 {
+		t.Errorf("unexpected pool %+v", p)
+	}
+	if p.SqrtPriceX96.Cmp(new(big.Int).Lsh(big.NewInt(1), 96)) != 0 || p.Tick != -7 || p.Liquidity.Cmp(liquidity) != 0 {
+		t.Errorf("unexpected pool state %s, %d, %s", p.SqrtPriceX96, p.Tick, p.Liquidity)
+	}
+
+	cumulatives, err := u.Observe(ctx, pool, []uint32{600, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cumulatives) != 2 || cumulatives[0].Int64() != -4200 || cumulatives[1].Int64() != -700 {
+		t.Errorf("unexpected tick cumulatives %v", cumulatives)
+	}
+}
+
+func TestLiquidity(t *testing.T) {
+	ctx := context.Background()
+	u, backend, signer := newTestUniswap(t)
+
+	minted, err := u.IncreaseLiquidity(ctx, signer, &IncreaseLiquidityInput{
+		Token0:         token0,
+		Token1:         token1,
+		Fee:            fee,
+		TickLower:      -120,
+		TickUpper:      60,
+		Amount0Desired: big.NewInt(100),
+		Amount1Desired: big.NewInt(200),
+		Amount0Min:     new(big.Int),
+		Amount1Min:     new(big.Int),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minted.TokenID.Cmp(tokenID) != 0 || minted.Liquidity.Cmp(liquidity) != 0 ||
+		minted.Amount0.Int64() != 100 || minted.Amount1.Int64() != 200 {
+		t.Errorf("unexpected mint %+v", minted)
+	}
+	receipt, err := backend.TransactionReceipt(ctx, minted.TransactionHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minted.TransactionFee.Sign() <= 0 || minted.TransactionFee.Cmp(transactionFee(receipt)) != 0 {
+		t.Errorf("mint fee %s, the receipt paid %s", minted.TransactionFee, transactionFee(receipt))
+	}
+
+	position, err := u.GetPosition(ctx, tokenID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if position.Token0 != token0 || position.Token1 != token1 || position.TickLower != -120 || position.TickUpper != 60 ||
+		position.Liquidity.Cmp(liquidity) != 0 || position.TokensOwed0.Int64() != 5 || position.TokensOwed1.Int64() != 8 {
+		t.Errorf("unexpected position %+v", position)
+	}
+
+	fees, err := u.GetFees(ctx, signer.Address(), tokenID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Amount0.Int64() != 105 || fees.Amount1.Int64() != 207 {
+		t.Errorf("unexpected fees %+v", fees)
+	}
+
+	// the decreased position is collected, the collected amounts are returned
+	decreased, err := u.DecreaseLiquidity(ctx, signer, &DecreaseLiquidityInput{
+		TokenID:    tokenID,
+		Liquidity:  liquidity,
+		Amount0Min: new(big.Int),
+		Amount1Min: new(big.Int),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decreased.Amount0.Int64() != 105 || decreased.Amount1.Int64() != 207 {
+		t.Errorf("unexpected decrease %+v", decreased)
+	}
+	receipt, err = backend.TransactionReceipt(ctx, decreased.TransactionHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decreased.TransactionFee.Cmp(transactionFee(receipt)) <= 0 {
+		t.Errorf("decrease fee %s does not include the collect fee over %s", decreased.TransactionFee, transactionFee(receipt))
+	}
+
+	collected, err := u.CollectFees(ctx, signer, &CollectFeesInput{TokenID: tokenID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if collected.Amount0.Int64() != 105 || collected.Amount1.Int64() != 207 || collected.TransactionFee.Sign() <= 0 {
+		t.Errorf("unexpected collect %+v", collected)
+	}
+}
+
+func TestSwap(t *testing.T) {
+	ctx := context.Background()
+	u, _, signer := newTestUniswap(t)
+
+	// the decoy event of the router is skipped, the amounts are of the pool event
+	out, err := u.ExactInputSingle(ctx, signer, &ExactInputSingleInput{
+		Pool:             pool,
+		TokenIn:          token0,
+		TokenOut:         token1,
+		Fee:              fee,
+		AmountIn:         big.NewInt(1000),
+		AmountOutMinimum: new(big.Int),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.AmountIn.Int64() != 1000 || out.AmountOut.Int64() != 1990 || out.TransactionFee.Sign() <= 0 {
+		t.Errorf("unexpected exact input swap %+v", out)
+	}
+
+	out, err = u.ExactOutputSingle(ctx, signer, &ExactOutputSingleInput{
+		Pool:            reversePool,
+		TokenIn:         token1,
+		TokenOut:        token0,
+		Fee:             fee,
+		AmountOut:       big.NewInt(500),
+		AmountInMaximum: big.NewInt(2000),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.AmountIn.Int64() != 1010 || out.AmountOut.Int64() != 500 {
+		t.Errorf("unexpected exact output swap %+v", out)
+	}
+
+	// the receipt of a transaction not swapping in the pool has no event
+	if _, err = u.ExactInputSingle(ctx, signer, &ExactInputSingleInput{
+		Pool:             factory,
+		TokenIn:          token0,
+		TokenOut:         token1,
+		Fee:              fee,
+		AmountIn:         big.NewInt(1000),
+		AmountOutMinimum: new(big.Int),
+	}); err == nil || !strings.Contains(err.Error(), "event Swap not found") {
+		t.Errorf("expected the missing event error, got %v", err)
+	}
+}